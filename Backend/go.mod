@@ -10,7 +10,6 @@ require (
 	github.com/go-playground/validator/v10 v10.15.4
 	github.com/golang-jwt/jwt/v4 v4.5.0
 	github.com/joho/godotenv v1.5.1
-	github.com/jpoz/groq v0.0.0-20240513145022-7a02894105a0
 	golang.org/x/crypto v0.12.0
 	google.golang.org/api v0.138.0
 	gorm.io/driver/postgres v1.5.2
@@ -16,6 +16,38 @@ type Config struct {
 	DBPort     string
 	JWTSecret  string
 	ServerPort string
+
+	// Recipe generation model settings
+	LLMProvider    string
+	LLMBaseURL     string
+	LLMAPIKey      string
+	LLMModel       string
+	LLMTemperature float64
+	LLMMaxTokens   int
+
+	// Recipe generation limits
+	RecipeDailyQuota    int // Model generations per user per day, 0 for unlimited
+	RecipeCacheTTLHours int // How long results are reused for an unchanged pantry, 0 to disable
+
+	// Bundled nutrition database, imported on startup when the table is empty
+	NutritionCSVPath string
+
+	// Notification channels. Push needs Firebase credentials and email an SMTP
+	// host; channels that aren't configured are only logged.
+	FirebaseProjectID       string
+	FirebaseCredentialsFile string
+	SMTPHost                string
+	SMTPPort                string
+	SMTPUsername            string
+	SMTPPassword            string
+	SMTPFrom                string
+
+	// Admin broadcasts are sent to at most this many users a minute
+	BroadcastRatePerMinute int
+
+	// Base URL clients reach the API at, e.g. https://kitchen.example.com, for
+	// links handed out to other apps. Empty to use the request's host.
+	PublicURL string
 }
 
 var AppConfig Config
@@ -36,6 +68,30 @@ func LoadConfig() {
 		DBPort:     getEnv("DB_PORT", "5432"),
 		JWTSecret:  getEnv("JWT_SECRET", "your_jwt_secret_key"),
 		ServerPort: getEnv("PORT", "8080"),
+
+		LLMProvider:    getEnv("LLM_PROVIDER", "groq"),
+		LLMBaseURL:     getEnv("LLM_BASE_URL", ""),
+		LLMAPIKey:      getEnv("LLM_API_KEY", os.Getenv("GROQ_API_KEY")),
+		LLMModel:       getEnv("LLM_MODEL", "llama3-70b-8192"),
+		LLMTemperature: getEnvAsFloat("LLM_TEMPERATURE", 0.7),
+		LLMMaxTokens:   getEnvAsInt("LLM_MAX_TOKENS", 1500),
+
+		RecipeDailyQuota:    getEnvAsInt("RECIPE_DAILY_QUOTA", 20),
+		RecipeCacheTTLHours: getEnvAsInt("RECIPE_CACHE_TTL_HOURS", 24),
+
+		NutritionCSVPath: getEnv("NUTRITION_CSV", "data/nutrition.csv"),
+
+		FirebaseProjectID:       getEnv("FIREBASE_PROJECT_ID", ""),
+		FirebaseCredentialsFile: getEnv("FIREBASE_CREDENTIALS_FILE", "serviceAccountKey.json"),
+		SMTPHost:                getEnv("SMTP_HOST", ""),
+		SMTPPort:                getEnv("SMTP_PORT", "1025"), // MailHog's SMTP port
+		SMTPUsername:            getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:            getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:                getEnv("SMTP_FROM", "Zero Waste Kitchen <noreply@zerowaste.local>"),
+
+		BroadcastRatePerMinute: getEnvAsInt("BROADCAST_RATE_PER_MINUTE", 300),
+
+		PublicURL: getEnv("PUBLIC_URL", ""),
 	}
 
 	// Validate required configurations
@@ -65,6 +121,21 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return value
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		log.Printf("Invalid value for %s, using default: %v", key, defaultValue)
+		return defaultValue
+	}
+
+	return value
+}
+
 func validateConfig() {
 	required := []struct {
 		value     string
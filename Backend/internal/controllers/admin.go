@@ -1,12 +1,9 @@
 package controllers
 
 import (
-	"fmt"
-	"log"
 	"net/http"
 
 	"zero-waste-kitchen/internal/models"
-	"zero-waste-kitchen/internal/services"
 	"zero-waste-kitchen/pkg/database"
 
 	"github.com/gin-gonic/gin"
@@ -36,11 +33,12 @@ func CheckAdminStatus(c *gin.Context) {
 func GetUsersList(c *gin.Context) {
 	// Define a struct to hold the filtered user data
 	type UserResponse struct {
-		ID       uint   `json:"id"`
-		Name     string `json:"name"`
-		Email    string `json:"email"`
-		FCMToken string `json:"fcm_token"`
-		IsAdmin  bool   `json:"is_admin"`
+		ID          uint   `json:"id"`
+		Name        string `json:"name"`
+		Email       string `json:"email"`
+		FCMToken    string `json:"fcm_token"` // Token of the most recently seen device
+		DeviceCount int    `json:"device_count"`
+		IsAdmin     bool   `json:"is_admin"`
 	}
 
 	var users []models.User
@@ -49,50 +47,31 @@ func GetUsersList(c *gin.Context) {
 		return
 	}
 
+	var devices []models.Device
+	if err := database.DB.Order("last_seen_at ASC").Find(&devices).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch devices"})
+		return
+	}
+	latestToken := make(map[uint]string)
+	deviceCount := make(map[uint]int)
+	for _, device := range devices {
+		latestToken[device.UserID] = device.Token
+		deviceCount[device.UserID]++
+	}
+
 	// Map the users to the filtered response
 	var userResponses []UserResponse
 	for _, user := range users {
 		userResponses = append(userResponses, UserResponse{
-			ID:       user.ID,
-			Name:     user.Name,
-			Email:    user.Email,
-			FCMToken: user.FCMToken,
-			IsAdmin:  user.IsAdmin,
+			ID:          user.ID,
+			Name:        user.Name,
+			Email:       user.Email,
+			FCMToken:    latestToken[user.ID],
+			DeviceCount: deviceCount[user.ID],
+			IsAdmin:     user.IsAdmin,
 		})
 	}
 
 	// Return the filtered user data
 	c.JSON(http.StatusOK, userResponses)
 }
-
-// SendNotification sends a push notification to a user
-func SendNotification(c *gin.Context) {
-	var input struct {
-		UserID  uint   `json:"userId" binding:"required"`
-		Message string `json:"message" binding:"required"`
-	}
-
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	var user models.User
-	if err := database.DB.First(&user, input.UserID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-		return
-	}
-
-	if user.FCMToken == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User does not have an FCM token"})
-		return
-	}
-	log.Printf("notification's users FCM token: %s", user.FCMToken)
-
-	if err := services.SendPushNotification(user.FCMToken, input.Message); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to send notification: %v", err)})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Notification sent successfully"})
-}
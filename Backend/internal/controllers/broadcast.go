@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BroadcastController struct {
+	broadcastService services.BroadcastService
+}
+
+func NewBroadcastController(broadcastService services.BroadcastService) *BroadcastController {
+	return &BroadcastController{broadcastService: broadcastService}
+}
+
+// CreateBroadcast schedules a notification to a segment of users, right away or at scheduled_at
+func (c *BroadcastController) CreateBroadcast(ctx *gin.Context) {
+	var input struct {
+		Title        string     `json:"title" binding:"required"`
+		Body         string     `json:"body" binding:"required"`
+		Segment      string     `json:"segment" binding:"required"`
+		InactiveDays int        `json:"inactive_days"`
+		ExpiringDays int        `json:"expiring_days"`
+		HouseholdID  uint       `json:"household_id"`
+		ScheduledAt  *time.Time `json:"scheduled_at"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	broadcast, err := c.broadcastService.CreateBroadcast(ctx.GetUint("userID"), services.BroadcastInput{
+		Title:        input.Title,
+		Body:         input.Body,
+		Segment:      input.Segment,
+		InactiveDays: input.InactiveDays,
+		ExpiringDays: input.ExpiringDays,
+		HouseholdID:  input.HouseholdID,
+		ScheduledAt:  input.ScheduledAt,
+	})
+	if err != nil {
+		respondBroadcastError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"broadcast": broadcast})
+}
+
+// GetBroadcasts returns a page of broadcasts, newest first
+func (c *BroadcastController) GetBroadcasts(ctx *gin.Context) {
+	limit, offset, ok := parsePage(ctx)
+	if !ok {
+		return
+	}
+
+	broadcasts, total, err := c.broadcastService.GetBroadcasts(limit, offset)
+	if err != nil {
+		respondBroadcastError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"broadcasts": broadcasts, "total": total})
+}
+
+// GetBroadcast returns a broadcast with its delivery counts
+func (c *BroadcastController) GetBroadcast(ctx *gin.Context) {
+	id, ok := parseIDParam(ctx, "id", "invalid broadcast ID")
+	if !ok {
+		return
+	}
+
+	broadcast, err := c.broadcastService.GetBroadcast(id)
+	if err != nil {
+		respondBroadcastError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"broadcast": broadcast})
+}
+
+// CancelBroadcast cancels a broadcast that hasn't started sending
+func (c *BroadcastController) CancelBroadcast(ctx *gin.Context) {
+	id, ok := parseIDParam(ctx, "id", "invalid broadcast ID")
+	if !ok {
+		return
+	}
+
+	broadcast, err := c.broadcastService.CancelBroadcast(id)
+	if err != nil {
+		respondBroadcastError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"broadcast": broadcast})
+}
+
+// GetBroadcastDeliveries returns a page of the per-recipient delivery report,
+// optionally only one ?status (delivered, inbox_only or failed)
+func (c *BroadcastController) GetBroadcastDeliveries(ctx *gin.Context) {
+	id, ok := parseIDParam(ctx, "id", "invalid broadcast ID")
+	if !ok {
+		return
+	}
+	limit, offset, ok := parsePage(ctx)
+	if !ok {
+		return
+	}
+
+	deliveries, total, err := c.broadcastService.GetDeliveries(id, ctx.Query("status"), limit, offset)
+	if err != nil {
+		respondBroadcastError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"deliveries": deliveries, "total": total})
+}
+
+func respondBroadcastError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrBroadcastNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrBroadcastNotPending):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrInvalidBroadcast):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
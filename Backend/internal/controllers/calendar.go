@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"zero-waste-kitchen/internal/config"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CalendarController struct {
+	calendarService services.CalendarService
+}
+
+func NewCalendarController(calendarService services.CalendarService) *CalendarController {
+	return &CalendarController{calendarService: calendarService}
+}
+
+// GetCalendarFeed returns the authenticated user's calendar subscription URL,
+// creating it the first time
+func (c *CalendarController) GetCalendarFeed(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	feed, err := c.calendarService.GetFeed(userID)
+	if err != nil {
+		respondCalendarError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"feed": feed, "url": calendarURL(ctx, feed.Token)})
+}
+
+// RotateCalendarFeed replaces the subscription URL, e.g. after it was shared by mistake
+func (c *CalendarController) RotateCalendarFeed(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	feed, err := c.calendarService.RotateFeed(userID)
+	if err != nil {
+		respondCalendarError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"feed": feed, "url": calendarURL(ctx, feed.Token)})
+}
+
+// DeleteCalendarFeed revokes the subscription URL
+func (c *CalendarController) DeleteCalendarFeed(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	if err := c.calendarService.DeleteFeed(userID); err != nil {
+		respondCalendarError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Calendar feed deleted"})
+}
+
+// ServeCalendar serves the iCalendar feed behind a token. Calendar apps can't
+// log in, so the token in the URL is the only credential.
+func (c *CalendarController) ServeCalendar(ctx *gin.Context) {
+	token := strings.TrimSuffix(ctx.Param("token"), ".ics")
+
+	feed, err := c.calendarService.RenderFeed(token, time.Now())
+	if err != nil {
+		if errors.Is(err, models.ErrCalendarFeedNotFound) {
+			ctx.String(http.StatusNotFound, "calendar not found")
+			return
+		}
+		ctx.String(http.StatusInternalServerError, "failed to render calendar")
+		return
+	}
+
+	ctx.Header("Cache-Control", "private, max-age=900")
+	ctx.Data(http.StatusOK, "text/calendar; charset=utf-8", feed)
+}
+
+// calendarURL is where calendar apps subscribe to a feed
+func calendarURL(ctx *gin.Context, token string) string {
+	base := strings.TrimSuffix(config.AppConfig.PublicURL, "/")
+	if base == "" {
+		scheme := "http"
+		if ctx.Request.TLS != nil || ctx.GetHeader("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		base = scheme + "://" + ctx.Request.Host
+	}
+	return base + "/api/calendar/" + token + ".ics"
+}
+
+func respondCalendarError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrCalendarFeedNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeviceController struct {
+	deviceService services.DeviceService
+}
+
+func NewDeviceController(deviceService services.DeviceService) *DeviceController {
+	return &DeviceController{deviceService: deviceService}
+}
+
+// RegisterDevice registers a push token for the authenticated user. Apps call it
+// on every launch, which keeps the device's last-seen time and app version current.
+func (c *DeviceController) RegisterDevice(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	var input struct {
+		Token      string `json:"token" binding:"required"`
+		Platform   string `json:"platform"`
+		AppVersion string `json:"app_version"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	device := models.Device{UserID: userID, Token: input.Token, Platform: input.Platform, AppVersion: input.AppVersion}
+	if err := c.deviceService.RegisterDevice(&device); err != nil {
+		respondDeviceError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"device": device})
+}
+
+// GetDevices lists the authenticated user's registered devices
+func (c *DeviceController) GetDevices(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	devices, err := c.deviceService.GetDevices(userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"devices": devices})
+}
+
+// RemoveDevice unregisters one of the authenticated user's devices
+func (c *DeviceController) RemoveDevice(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	id, ok := parseIDParam(ctx, "id", "invalid device ID")
+	if !ok {
+		return
+	}
+
+	if err := c.deviceService.RemoveDevice(userID, id); err != nil {
+		respondDeviceError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Device removed"})
+}
+
+func respondDeviceError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrDeviceNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrInvalidDevice):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"net/http"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FoodProfileController struct {
+	profileService services.FoodProfileService
+}
+
+func NewFoodProfileController(profileService services.FoodProfileService) *FoodProfileController {
+	return &FoodProfileController{profileService: profileService}
+}
+
+// GetFoodProfile returns the authenticated user's food profile
+func (c *FoodProfileController) GetFoodProfile(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	profile, err := c.profileService.GetProfile(userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
+// UpdateFoodProfile replaces the authenticated user's food profile
+func (c *FoodProfileController) UpdateFoodProfile(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	var profile models.FoodProfile
+	if err := ctx.ShouldBindJSON(&profile); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	profile.UserID = userID
+
+	if err := c.profileService.UpdateProfile(&profile); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"profile": profile})
+}
@@ -3,18 +3,26 @@ package controllers
 import (
 	"net/http"
 	"time"
+	"zero-waste-kitchen/internal/events"
 	"zero-waste-kitchen/internal/models"
-	"zero-waste-kitchen/internal/services"
 	"zero-waste-kitchen/pkg/database"
 
 	"github.com/gin-gonic/gin"
 )
 
-func GetAllGroceries(c *gin.Context) {
-	userID := c.GetUint("userID")
+type GroceryController struct {
+	publisher events.Publisher
+}
+
+func NewGroceryController(publisher events.Publisher) *GroceryController {
+	return &GroceryController{publisher: publisher}
+}
+
+func (gc *GroceryController) GetAllGroceries(c *gin.Context) {
+	householdID := c.GetUint("householdID")
 
 	var groceries []models.GroceryItem
-	if err := database.DB.Where("user_id = ?", userID).Find(&groceries).Error; err != nil {
+	if err := database.DB.Where("household_id = ?", householdID).Find(&groceries).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch groceries"})
 		return
 	}
@@ -22,8 +30,9 @@ func GetAllGroceries(c *gin.Context) {
 	c.JSON(http.StatusOK, groceries)
 }
 
-func CreateGrocery(c *gin.Context) {
+func (gc *GroceryController) CreateGrocery(c *gin.Context) {
 	userID := c.GetUint("userID")
+	householdID := c.GetUint("householdID")
 
 	var grocery models.GroceryItem
 	if err := c.ShouldBindJSON(&grocery); err != nil {
@@ -32,21 +41,23 @@ func CreateGrocery(c *gin.Context) {
 	}
 
 	grocery.UserID = userID
+	grocery.HouseholdID = householdID
 
 	if err := database.DB.Create(&grocery).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create grocery item"})
 		return
 	}
+	gc.publisher.Publish(events.New(events.ItemCreated, householdID, userID, gin.H{"item": grocery}))
 
 	c.JSON(http.StatusCreated, grocery)
 }
 
-func GetGrocery(c *gin.Context) {
-	userID := c.GetUint("userID")
+func (gc *GroceryController) GetGrocery(c *gin.Context) {
+	householdID := c.GetUint("householdID")
 	id := c.Param("id")
 
 	var grocery models.GroceryItem
-	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&grocery).Error; err != nil {
+	if err := database.DB.Where("id = ? AND household_id = ?", id, householdID).First(&grocery).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Grocery item not found"})
 		return
 	}
@@ -54,12 +65,12 @@ func GetGrocery(c *gin.Context) {
 	c.JSON(http.StatusOK, grocery)
 }
 
-func UpdateGrocery(c *gin.Context) {
-	userID := c.GetUint("userID")
+func (gc *GroceryController) UpdateGrocery(c *gin.Context) {
+	householdID := c.GetUint("householdID")
 	id := c.Param("id")
 
 	var grocery models.GroceryItem
-	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&grocery).Error; err != nil {
+	if err := database.DB.Where("id = ? AND household_id = ?", id, householdID).First(&grocery).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Grocery item not found"})
 		return
 	}
@@ -68,21 +79,23 @@ func UpdateGrocery(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	grocery.HouseholdID = householdID // The item stays in the household
 
 	if err := database.DB.Save(&grocery).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update grocery item"})
 		return
 	}
+	gc.publisher.Publish(events.New(events.ItemUpdated, householdID, c.GetUint("userID"), gin.H{"item": grocery}))
 
 	c.JSON(http.StatusOK, grocery)
 }
 
-func DeleteGrocery(c *gin.Context) {
-	userID := c.GetUint("userID")
+func (gc *GroceryController) DeleteGrocery(c *gin.Context) {
+	householdID := c.GetUint("householdID")
 	id := c.Param("id")
 
 	var grocery models.GroceryItem
-	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&grocery).Error; err != nil {
+	if err := database.DB.Where("id = ? AND household_id = ?", id, householdID).First(&grocery).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Grocery item not found"})
 		return
 	}
@@ -91,40 +104,21 @@ func DeleteGrocery(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete grocery item"})
 		return
 	}
+	gc.publisher.Publish(events.New(events.ItemDeleted, householdID, c.GetUint("userID"), gin.H{"item_id": grocery.ID}))
 
 	c.JSON(http.StatusOK, gin.H{"message": "Grocery item deleted successfully"})
 }
 
-func GetExpiringGroceries(c *gin.Context) {
-	userID := c.GetUint("userID")
+func (gc *GroceryController) GetExpiringGroceries(c *gin.Context) {
+	householdID := c.GetUint("householdID")
 
 	var groceries []models.GroceryItem
 	threshold := time.Now().Add(7 * 24 * time.Hour) // Items expiring in next 7 days
 
-	if err := database.DB.Where("user_id = ? AND expiry_date <= ?", userID, threshold).Find(&groceries).Error; err != nil {
+	if err := database.DB.Where("household_id = ? AND expiry_date <= ?", householdID, threshold).Find(&groceries).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch expiring groceries"})
 		return
 	}
 
 	c.JSON(http.StatusOK, groceries)
 }
-
-func CheckExpiringItems() {
-	var users []models.User
-	if err := database.DB.Find(&users).Error; err != nil {
-		return
-	}
-
-	for _, user := range users {
-		var expiringItems []models.GroceryItem
-		threshold := time.Now().Add(7 * 24 * time.Hour)
-
-		if err := database.DB.Where("user_id = ? AND expiry_date <= ?", user.ID, threshold).Find(&expiringItems).Error; err != nil {
-			continue
-		}
-
-		if len(expiringItems) > 0 {
-			services.SendExpiryNotification(user, expiringItems)
-		}
-	}
-}
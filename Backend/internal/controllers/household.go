@@ -0,0 +1,236 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HouseholdController struct {
+	householdService services.HouseholdService
+}
+
+func NewHouseholdController(householdService services.HouseholdService) *HouseholdController {
+	return &HouseholdController{householdService: householdService}
+}
+
+type HouseholdRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type HouseholdInviteRequest struct {
+	Role      models.HouseholdRole `json:"role"`       // member or viewer, defaults to member
+	MaxUses   int                  `json:"max_uses"`   // Defaults to 1
+	ValidDays int                  `json:"valid_days"` // Defaults to 7
+}
+
+type JoinHouseholdRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TransferHouseholdRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+type HouseholdMemberRequest struct {
+	Role models.HouseholdRole `json:"role" binding:"required"`
+}
+
+// GetHousehold returns the authenticated user's household with its members
+func (c *HouseholdController) GetHousehold(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	household, err := c.householdService.GetHousehold(userID)
+	if err != nil {
+		respondHouseholdError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"household": household})
+}
+
+// RenameHousehold renames the household, for its owner
+func (c *HouseholdController) RenameHousehold(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	var req HouseholdRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	household, err := c.householdService.RenameHousehold(userID, req.Name)
+	if err != nil {
+		respondHouseholdError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"household": household})
+}
+
+// CreateInvite creates an invite code for the household, for its owner
+func (c *HouseholdController) CreateInvite(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	var req HouseholdInviteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	invite, err := c.householdService.CreateInvite(userID, services.InviteInput{
+		Role:      req.Role,
+		MaxUses:   req.MaxUses,
+		ValidDays: req.ValidDays,
+	})
+	if err != nil {
+		respondHouseholdError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"invite": invite})
+}
+
+// GetInvites returns the household's invites that can still be used
+func (c *HouseholdController) GetInvites(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	invites, err := c.householdService.GetInvites(userID)
+	if err != nil {
+		respondHouseholdError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"invites": invites})
+}
+
+// RevokeInvite deletes an invite so its code can no longer be used
+func (c *HouseholdController) RevokeInvite(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	inviteID, ok := parseIDParam(ctx, "id", "invalid invite ID")
+	if !ok {
+		return
+	}
+
+	if err := c.householdService.RevokeInvite(userID, inviteID); err != nil {
+		respondHouseholdError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Invite revoked successfully"})
+}
+
+// JoinHousehold moves the authenticated user into the household of an invite code
+func (c *HouseholdController) JoinHousehold(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	var req JoinHouseholdRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	household, err := c.householdService.Join(userID, req.Code)
+	if err != nil {
+		respondHouseholdError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"household": household})
+}
+
+// LeaveHousehold moves the authenticated user into a new household of their own
+func (c *HouseholdController) LeaveHousehold(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	household, err := c.householdService.Leave(userID)
+	if err != nil {
+		respondHouseholdError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"household": household})
+}
+
+// TransferHousehold makes another member the owner of the household
+func (c *HouseholdController) TransferHousehold(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	var req TransferHouseholdRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	household, err := c.householdService.TransferOwnership(userID, req.UserID)
+	if err != nil {
+		respondHouseholdError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"household": household})
+}
+
+// UpdateHouseholdMember changes a member's role, for the owner
+func (c *HouseholdController) UpdateHouseholdMember(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	memberID, ok := parseIDParam(ctx, "userId", "invalid user ID")
+	if !ok {
+		return
+	}
+
+	var req HouseholdMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	household, err := c.householdService.UpdateMemberRole(userID, memberID, req.Role)
+	if err != nil {
+		respondHouseholdError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"household": household})
+}
+
+// RemoveHouseholdMember takes a member out of the household, for the owner
+func (c *HouseholdController) RemoveHouseholdMember(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	memberID, ok := parseIDParam(ctx, "userId", "invalid user ID")
+	if !ok {
+		return
+	}
+
+	household, err := c.householdService.RemoveMember(userID, memberID)
+	if err != nil {
+		respondHouseholdError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"household": household})
+}
+
+func respondHouseholdError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrHouseholdNotFound),
+		errors.Is(err, models.ErrNotHouseholdMember),
+		errors.Is(err, models.ErrInviteInvalid):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrNotHouseholdOwner):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrAlreadyInHousehold),
+		errors.Is(err, models.ErrOwnerMustTransfer),
+		errors.Is(err, models.ErrSoleHouseholdMember):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrInvalidHouseholdRole),
+		errors.Is(err, models.ErrInvalidHouseholdName),
+		errors.Is(err, models.ErrInvalidInvite):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
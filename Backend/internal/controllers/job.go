@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxJobRunsPage = 200
+
+type JobController struct {
+	jobService services.JobService
+}
+
+func NewJobController(jobService services.JobService) *JobController {
+	return &JobController{jobService: jobService}
+}
+
+// GetJobs lists the scheduled background jobs with their schedules and next runs
+func (c *JobController) GetJobs(ctx *gin.Context) {
+	jobs, err := c.jobService.GetJobs()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"jobs": jobs})
+}
+
+// UpdateJob changes a job's cron schedule or enables and disables it
+func (c *JobController) UpdateJob(ctx *gin.Context) {
+	var input struct {
+		Schedule *string `json:"schedule"`
+		Enabled  *bool   `json:"enabled"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	job, err := c.jobService.UpdateJob(ctx.Param("name"), services.JobUpdate{Schedule: input.Schedule, Enabled: input.Enabled})
+	if err != nil {
+		respondJobError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"job": job})
+}
+
+// RunJob makes a job run at the scheduler's next poll, within a minute
+func (c *JobController) RunJob(ctx *gin.Context) {
+	job, err := c.jobService.TriggerJob(ctx.Param("name"))
+	if err != nil {
+		respondJobError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"job": job})
+}
+
+// GetJobRuns returns a job's run history, newest first, ?limit runs (default 50)
+func (c *JobController) GetJobRuns(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > maxJobRunsPage {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	runs, err := c.jobService.GetRuns(ctx.Param("name"), limit)
+	if err != nil {
+		respondJobError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+func respondJobError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrJobNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrInvalidJob):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"zero-waste-kitchen/internal/events"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const dateLayout = "2006-01-02"
+
+type MealPlanController struct {
+	mealPlanService services.MealPlanService
+	publisher       events.Publisher
+}
+
+func NewMealPlanController(mealPlanService services.MealPlanService, publisher events.Publisher) *MealPlanController {
+	return &MealPlanController{mealPlanService: mealPlanService, publisher: publisher}
+}
+
+type MealPlanRequest struct {
+	RecipeID uint   `json:"recipe_id"`
+	Date     string `json:"date"` // YYYY-MM-DD
+	Meal     string `json:"meal"` // breakfast, lunch, dinner or snack
+	Servings int    `json:"servings"`
+}
+
+type UpdateMealPlanRequest struct {
+	Date     *string `json:"date"`
+	Meal     *string `json:"meal"`
+	Servings *int    `json:"servings"`
+}
+
+// GetMealPlan returns the household's meal plan from ?from to ?to (YYYY-MM-DD),
+// by default the coming week
+func (c *MealPlanController) GetMealPlan(ctx *gin.Context) {
+	householdID := ctx.GetUint("householdID")
+
+	from, to, ok := dateRange(ctx)
+	if !ok {
+		return
+	}
+
+	entries, err := c.mealPlanService.GetPlan(householdID, from, to)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+// AddMealPlanEntry plans a saved recipe for a date and meal and reserves its ingredients
+func (c *MealPlanController) AddMealPlanEntry(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	householdID := ctx.GetUint("householdID")
+
+	var req MealPlanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	date, err := time.Parse(dateLayout, req.Date)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "date must be written as YYYY-MM-DD"})
+		return
+	}
+
+	entry, err := c.mealPlanService.AddEntry(userID, householdID, services.MealPlanInput{
+		RecipeID: req.RecipeID,
+		Date:     date,
+		Meal:     req.Meal,
+		Servings: req.Servings,
+	})
+	if err != nil {
+		respondMealPlanError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"entry": entry})
+}
+
+// UpdateMealPlanEntry moves or resizes a planned meal
+func (c *MealPlanController) UpdateMealPlanEntry(ctx *gin.Context) {
+	householdID := ctx.GetUint("householdID")
+	entryID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid meal plan entry ID"})
+		return
+	}
+
+	var req UpdateMealPlanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	changes := services.MealPlanChanges{Meal: req.Meal, Servings: req.Servings}
+	if req.Date != nil {
+		date, err := time.Parse(dateLayout, *req.Date)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "date must be written as YYYY-MM-DD"})
+			return
+		}
+		changes.Date = &date
+	}
+
+	entry, err := c.mealPlanService.UpdateEntry(householdID, uint(entryID), changes)
+	if err != nil {
+		respondMealPlanError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"entry": entry})
+}
+
+// DeleteMealPlanEntry removes a meal from the plan and releases its reserved ingredients
+func (c *MealPlanController) DeleteMealPlanEntry(ctx *gin.Context) {
+	householdID := ctx.GetUint("householdID")
+	entryID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid meal plan entry ID"})
+		return
+	}
+
+	if err := c.mealPlanService.DeleteEntry(householdID, uint(entryID)); err != nil {
+		respondMealPlanError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Meal plan entry deleted successfully"})
+}
+
+// CookMealPlanEntry marks a planned meal as cooked and uses up its reserved ingredients
+func (c *MealPlanController) CookMealPlanEntry(ctx *gin.Context) {
+	householdID := ctx.GetUint("householdID")
+	entryID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid meal plan entry ID"})
+		return
+	}
+
+	entry, err := c.mealPlanService.CookEntry(householdID, uint(entryID))
+	if err != nil {
+		respondMealPlanError(ctx, err)
+		return
+	}
+	c.publisher.Publish(events.New(events.InventoryChanged, householdID, ctx.GetUint("userID"), gin.H{"reason": "meal_cooked", "meal_plan_entry_id": entry.ID}))
+
+	ctx.JSON(http.StatusOK, gin.H{"entry": entry})
+}
+
+// GetMealPlanSuggestions suggests moving planned meals so that expiring items are used first
+func (c *MealPlanController) GetMealPlanSuggestions(ctx *gin.Context) {
+	householdID := ctx.GetUint("householdID")
+
+	from, to, ok := dateRange(ctx)
+	if !ok {
+		return
+	}
+
+	suggestions, err := c.mealPlanService.SuggestReorder(householdID, from, to)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
+}
+
+// dateRange reads ?from and ?to, defaulting to today and six days later.
+// It responds with 400 and returns false when either is invalid.
+func dateRange(ctx *gin.Context) (time.Time, time.Time, bool) {
+	from := time.Now().UTC()
+	if value := ctx.Query("from"); value != "" {
+		parsed, err := time.Parse(dateLayout, value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "from must be written as YYYY-MM-DD"})
+			return time.Time{}, time.Time{}, false
+		}
+		from = parsed
+	}
+
+	to := from.AddDate(0, 0, 6)
+	if value := ctx.Query("to"); value != "" {
+		parsed, err := time.Parse(dateLayout, value)
+		if err != nil || parsed.Before(from) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "to must be a YYYY-MM-DD date on or after from"})
+			return time.Time{}, time.Time{}, false
+		}
+		to = parsed
+	}
+	return from, to, true
+}
+
+func respondMealPlanError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrMealPlanEntryNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "meal plan entry not found"})
+	case errors.Is(err, models.ErrRecipeNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "recipe not found"})
+	case errors.Is(err, models.ErrInvalidMeal), errors.Is(err, services.ErrInvalidServings):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrMealAlreadyCooked):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/notify"
+	"zero-waste-kitchen/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxNotificationsPage = 200
+
+type NotificationController struct {
+	notificationService services.NotificationService
+}
+
+func NewNotificationController(notificationService services.NotificationService) *NotificationController {
+	return &NotificationController{notificationService: notificationService}
+}
+
+// GetNotificationPreferences returns when and how the authenticated user hears about expiring items
+func (c *NotificationController) GetNotificationPreferences(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	prefs, err := c.notificationService.GetPreferences(userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"preferences": prefs})
+}
+
+// UpdateNotificationPreferences replaces the authenticated user's notification preferences
+func (c *NotificationController) UpdateNotificationPreferences(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	var prefs models.NotificationPreferences
+	if err := ctx.ShouldBindJSON(&prefs); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	prefs.ID = 0
+	prefs.UserID = userID
+
+	if err := c.notificationService.UpdatePreferences(&prefs); err != nil {
+		if errors.Is(err, models.ErrInvalidNotificationPreferences) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"preferences": prefs})
+}
+
+// SendNotification lets an admin send a user a message in their inbox and on their enabled channels
+func (c *NotificationController) SendNotification(ctx *gin.Context) {
+	var input struct {
+		UserID  uint   `json:"userId" binding:"required"`
+		Title   string `json:"title"`
+		Message string `json:"message" binding:"required"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Without a title the user gets a generic one in their language
+	channels, err := c.notificationService.Notify(input.UserID, notify.Message{
+		Title: strings.TrimSpace(input.Title),
+		Body:  input.Message,
+		Kind:  "admin_message",
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to send notification: %v", err)})
+	default:
+		ctx.JSON(http.StatusOK, gin.H{"message": "Notification sent successfully", "channels": channels})
+	}
+}
+
+// GetSentNotifications lets an admin page through the ledger of sent expiry alerts,
+// optionally filtered by ?user_id, ?item_id and ?channel
+func (c *NotificationController) GetSentNotifications(ctx *gin.Context) {
+	filter := models.SentNotificationFilter{Channel: ctx.Query("channel")}
+	for param, target := range map[string]*uint{"user_id": &filter.UserID, "item_id": &filter.GroceryItemID} {
+		if value := ctx.Query(param); value != "" {
+			id, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param})
+				return
+			}
+			*target = uint(id)
+		}
+	}
+
+	var ok bool
+	if filter.Limit, filter.Offset, ok = parsePage(ctx); !ok {
+		return
+	}
+
+	sent, total, err := c.notificationService.GetSentNotifications(filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"notifications": sent, "total": total})
+}
+
+// GetNotifications returns a page of the authenticated user's inbox, newest first.
+// ?unread=true leaves out notifications already read.
+func (c *NotificationController) GetNotifications(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	limit, offset, ok := parsePage(ctx)
+	if !ok {
+		return
+	}
+
+	inbox, err := c.notificationService.GetInbox(userID, ctx.Query("unread") == "true", limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, inbox)
+}
+
+// MarkNotificationsRead marks the given inbox notifications as read, or all of them with "all": true
+func (c *NotificationController) MarkNotificationsRead(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	var input struct {
+		IDs []uint `json:"ids"`
+		All bool   `json:"all"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil || (len(input.IDs) == 0) == !input.All {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "give either ids or all"})
+		return
+	}
+
+	count, err := c.notificationService.MarkRead(userID, input.IDs)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"marked": count})
+}
+
+// parsePage reads ?limit (default 50) and ?offset, answering 400 if they are invalid
+func parsePage(ctx *gin.Context) (limit, offset int, ok bool) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > maxNotificationsPage {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxNotificationsPage)})
+		return 0, 0, false
+	}
+	offset, err = strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+		return 0, 0, false
+	}
+	return limit, offset, true
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"zero-waste-kitchen/internal/events"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/repositories"
+	"zero-waste-kitchen/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NutritionController struct {
+	nutritionService services.NutritionService
+	publisher        events.Publisher
+}
+
+func NewNutritionController(nutritionService services.NutritionService, publisher events.Publisher) *NutritionController {
+	return &NutritionController{nutritionService: nutritionService, publisher: publisher}
+}
+
+// SearchFoods searches the nutrition database by name, e.g. to link a grocery item
+func (c *NutritionController) SearchFoods(ctx *gin.Context) {
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+
+	foods, err := c.nutritionService.SearchFoods(ctx.Query("q"), limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"foods": foods})
+}
+
+// ImportFoods imports nutrition data from an uploaded CSV file or a text/csv body.
+// ?source= labels where the data came from.
+func (c *NutritionController) ImportFoods(ctx *gin.Context) {
+	source := ctx.DefaultQuery("source", "import")
+
+	var (
+		imported int
+		err      error
+	)
+	if fileHeader, fileErr := ctx.FormFile("file"); fileErr == nil {
+		file, openErr := fileHeader.Open()
+		if openErr != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "failed to read uploaded file"})
+			return
+		}
+		defer file.Close()
+		imported, err = c.nutritionService.ImportCSV(file, source)
+	} else if strings.HasPrefix(ctx.ContentType(), "text/csv") {
+		imported, err = c.nutritionService.ImportCSV(ctx.Request.Body, source)
+	} else {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "upload a CSV file or send a text/csv body"})
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Nutrition data imported successfully", "imported": imported})
+}
+
+// GetRecipeNutrition returns the estimated nutrition of a recipe, in total and per serving
+func (c *NutritionController) GetRecipeNutrition(ctx *gin.Context) {
+	householdID := ctx.GetUint("householdID")
+	recipeID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid recipe ID"})
+		return
+	}
+
+	nutrition, err := c.nutritionService.RecipeNutrition(householdID, uint(recipeID))
+	if err != nil {
+		if errors.Is(err, models.ErrRecipeNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "recipe not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"nutrition": nutrition})
+}
+
+// ConsumeGrocery records that some or all of a grocery item was eaten
+func (c *NutritionController) ConsumeGrocery(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	householdID := ctx.GetUint("householdID")
+	groceryID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid grocery item ID"})
+		return
+	}
+
+	var input struct {
+		Quantity float64 `json:"quantity"` // In the item's unit, 0 for all of it
+	}
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&input); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+	}
+
+	entry, err := c.nutritionService.ConsumeGrocery(userID, householdID, uint(groceryID), input.Quantity)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Grocery item not found"})
+		case errors.Is(err, models.ErrInvalidConsumption):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.publisher.Publish(events.New(events.ItemConsumed, householdID, userID, gin.H{"consumption": entry}))
+
+	ctx.JSON(http.StatusCreated, gin.H{"consumption": entry})
+}
+
+// GetWeeklyNutrition summarizes the nutrition of consumed items per week over the last ?weeks weeks
+func (c *NutritionController) GetWeeklyNutrition(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	weeks, err := strconv.Atoi(ctx.DefaultQuery("weeks", "4"))
+	if err != nil || weeks <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid weeks"})
+		return
+	}
+
+	summary, err := c.nutritionService.WeeklySummary(userID, weeks)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"weeks": summary})
+}
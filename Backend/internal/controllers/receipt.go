@@ -3,15 +3,28 @@ package controllers
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"time"
+	"zero-waste-kitchen/internal/events"
 	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/services"
 	"zero-waste-kitchen/pkg/database"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+type ReceiptController struct {
+	overPurchaseService services.OverPurchaseService
+	publisher           events.Publisher
+}
+
+func NewReceiptController(overPurchaseService services.OverPurchaseService, publisher events.Publisher) *ReceiptController {
+	return &ReceiptController{overPurchaseService: overPurchaseService, publisher: publisher}
+}
+
 // receipt_controller.go
-func UploadReceipt(c *gin.Context) {
+func (rc *ReceiptController) UploadReceipt(c *gin.Context) {
 	// Initialize variables
 	var filePath string
 
@@ -69,22 +82,10 @@ func UploadReceipt(c *gin.Context) {
 		return
 	}
 
-	// Create receipt
-	receipt := models.Receipt{
-		UserID:       userID.(uint),
-		ImagePath:    filePath,
-		StoreName:    receiptData.StoreName,
-		PurchaseDate: purchaseDate,
-		TotalAmount:  receiptData.TotalAmount,
-	}
-
-	// Save receipt to database
-	if err := database.DB.Create(&receipt).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create receipt"})
-		return
-	}
-
-	// Create grocery items
+	// Parse every item before anything is saved
+	householdID := c.GetUint("householdID")
+	items := make([]models.GroceryItem, 0, len(receiptData.Items))
+	purchases := make([]services.PurchaseInput, 0, len(receiptData.Items))
 	for _, itemData := range receiptData.Items {
 		expiryDate, err := time.Parse(time.RFC3339, itemData.ExpiryDate)
 		if err != nil {
@@ -92,33 +93,77 @@ func UploadReceipt(c *gin.Context) {
 			return
 		}
 
-		item := models.GroceryItem{
+		items = append(items, models.GroceryItem{
 			UserID:          userID.(uint),
-			ReceiptID:       &receipt.ID,
+			HouseholdID:     householdID,
 			Name:            itemData.Name,
 			Quantity:        itemData.Quantity,
 			Unit:            itemData.Unit,
 			ExpiryDate:      expiryDate,
 			StorageLocation: itemData.StorageLocation,
-		}
+		})
+		purchases = append(purchases, services.PurchaseInput{Name: itemData.Name, Quantity: itemData.Quantity, Unit: itemData.Unit})
+	}
 
-		if err := database.DB.Create(&item).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create grocery item"})
-			return
+	// Flag products the pantry already holds enough of, before the new items count as stock
+	warnings, records, err := rc.overPurchaseService.EvaluatePurchases(userID.(uint), householdID, models.PurchaseSourceReceipt, purchases)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check for over-purchases"})
+		return
+	}
+
+	receipt := models.Receipt{
+		UserID:       userID.(uint),
+		HouseholdID:  householdID,
+		ImagePath:    filePath,
+		StoreName:    receiptData.StoreName,
+		PurchaseDate: purchaseDate,
+		TotalAmount:  receiptData.TotalAmount,
+	}
+
+	// Save the receipt, its purchase records and its items together
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&receipt).Error; err != nil {
+			return err
+		}
+		for i := range records {
+			records[i].ReceiptID = &receipt.ID
+		}
+		if len(records) > 0 {
+			if err := tx.Create(&records).Error; err != nil {
+				return err
+			}
 		}
+		for i := range items {
+			items[i].ReceiptID = &receipt.ID
+		}
+		if len(items) > 0 {
+			return tx.Create(&items).Error
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save receipt"})
+		return
 	}
 
+	for _, item := range items {
+		rc.publisher.Publish(events.New(events.ItemCreated, householdID, userID.(uint), gin.H{"item": item}))
+	}
+	rc.publisher.Publish(events.New(events.ReceiptProcessed, householdID, userID.(uint), gin.H{"receipt": receipt, "items": items, "warnings": warnings}))
+
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "Receipt and items saved successfully",
-		"receipt": receipt,
+		"message":  "Receipt and items saved successfully",
+		"receipt":  receipt,
+		"warnings": warnings,
 	})
 }
 
-func GetAllReceipts(c *gin.Context) {
-	userID := c.GetUint("userID")
+func (rc *ReceiptController) GetAllReceipts(c *gin.Context) {
+	householdID := c.GetUint("householdID")
 
 	var receipts []models.Receipt
-	if err := database.DB.Preload("Items").Where("user_id = ?", userID).Find(&receipts).Error; err != nil {
+	if err := database.DB.Preload("Items").Where("household_id = ?", householdID).Find(&receipts).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch receipts"})
 		return
 	}
@@ -126,12 +171,12 @@ func GetAllReceipts(c *gin.Context) {
 	c.JSON(http.StatusOK, receipts)
 }
 
-func GetReceipt(c *gin.Context) {
-	userID := c.GetUint("userID")
+func (rc *ReceiptController) GetReceipt(c *gin.Context) {
+	householdID := c.GetUint("householdID")
 	id := c.Param("id")
 
 	var receipt models.Receipt
-	if err := database.DB.Preload("Items").Where("id = ? AND user_id = ?", id, userID).First(&receipt).Error; err != nil {
+	if err := database.DB.Preload("Items").Where("id = ? AND household_id = ?", id, householdID).First(&receipt).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Receipt not found"})
 		return
 	}
@@ -139,6 +184,30 @@ func GetReceipt(c *gin.Context) {
 	c.JSON(http.StatusOK, receipt)
 }
 
+// GetOverPurchaseRates returns, per product, how often it was bought while the
+// pantry already held enough of it over the last ?days (default 90)
+func (rc *ReceiptController) GetOverPurchaseRates(c *gin.Context) {
+	householdID := c.GetUint("householdID")
+
+	days := 90
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a positive number"})
+			return
+		}
+		days = parsed
+	}
+
+	rates, err := rc.overPurchaseService.GetRates(householdID, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"days": days, "products": rates})
+}
+
 // processReceipt is a mock implementation of receipt processing
 func processReceipt(imagePath string) ([]models.GroceryItem, error) {
 	// In a real implementation, this would call an OCR API
@@ -1,29 +1,40 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+	"zero-waste-kitchen/internal/events"
 	"zero-waste-kitchen/internal/models"
 	"zero-waste-kitchen/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
 
+// streamWriteTimeout bounds each write to a streaming client, so a stalled
+// connection can't hold the generation open
+const streamWriteTimeout = 30 * time.Second
+
 type RecipeController struct {
 	recipeService *services.RecipeService
+	publisher     events.Publisher
 }
 
-func NewRecipeController(recipeService *services.RecipeService) *RecipeController {
-	return &RecipeController{recipeService: recipeService}
+func NewRecipeController(recipeService *services.RecipeService, publisher events.Publisher) *RecipeController {
+	return &RecipeController{recipeService: recipeService, publisher: publisher}
 }
 
 type GenerateRecipesRequest struct {
-	Cuisine string `json:"cuisine"` // optional cuisine preference
+	Cuisine     string `json:"cuisine"`      // optional cuisine preference
+	MinExpiring int    `json:"min_expiring"` // optional, soonest-expiring items each recipe must use
 }
 
-// GenerateRecipes generates new recipes based on user's groceries
+// GenerateRecipes generates new recipes based on the household's groceries
 func (c *RecipeController) GenerateRecipes(ctx *gin.Context) {
 	userID := ctx.GetUint("userID") // Assuming you have auth middleware
+	householdID := ctx.GetUint("householdID")
 
 	var req GenerateRecipesRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -31,20 +42,72 @@ func (c *RecipeController) GenerateRecipes(ctx *gin.Context) {
 		return
 	}
 
-	recipes, err := c.recipeService.GenerateRecipes(userID, req.Cuisine)
+	recipes, err := c.recipeService.GenerateRecipes(ctx.Request.Context(), userID, householdID, req.Cuisine, req.MinExpiring)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if errors.Is(err, services.ErrNoSafeRecipes) || errors.Is(err, services.ErrNoExpiringRecipes) {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		} else if errors.Is(err, models.ErrGenerationQuotaExceeded) {
+			ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		} else if errors.Is(err, services.ErrEmptyRecipeLibrary) {
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "recipe generation is currently unavailable"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
+	c.publisher.Publish(events.New(events.RecipeGenerated, householdID, userID, gin.H{"recipes": recipes}))
+
 	ctx.JSON(http.StatusOK, gin.H{"recipes": recipes})
 }
 
-// GetAllRecipes returns all recipes for the authenticated user
-func (c *RecipeController) GetAllRecipes(ctx *gin.Context) {
+// GenerateRecipesStream generates recipes like GenerateRecipes, but sends each
+// recipe as a Server-Sent Event as soon as it is ready. Closing the connection
+// cancels the generation.
+func (c *RecipeController) GenerateRecipesStream(ctx *gin.Context) {
 	userID := ctx.GetUint("userID")
+	householdID := ctx.GetUint("householdID")
+
+	var req GenerateRecipesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
 
-	recipes, err := c.recipeService.GetAllRecipes(userID)
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	writer := http.NewResponseController(ctx.Writer)
+	var recipes []models.Recipe
+	send := func(event services.RecipeEvent) {
+		if event.Recipe != nil {
+			recipes = append(recipes, *event.Recipe)
+		}
+		writer.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		ctx.SSEvent(event.Type, event)
+		writer.Flush()
+	}
+
+	err := c.recipeService.GenerateRecipesStream(ctx.Request.Context(), userID, householdID, req.Cuisine, req.MinExpiring, send)
+	if err != nil && ctx.Request.Context().Err() == nil {
+		message := err.Error()
+		if errors.Is(err, services.ErrEmptyRecipeLibrary) {
+			message = "recipe generation is currently unavailable"
+		}
+		send(services.RecipeEvent{Type: services.RecipeEventError, Message: message})
+	}
+	if err == nil && len(recipes) > 0 {
+		c.publisher.Publish(events.New(events.RecipeGenerated, householdID, userID, gin.H{"recipes": recipes}))
+	}
+}
+
+// GetAllRecipes returns all recipes of the authenticated user's household
+func (c *RecipeController) GetAllRecipes(ctx *gin.Context) {
+	householdID := ctx.GetUint("householdID")
+
+	recipes, err := c.recipeService.GetAllRecipes(householdID, ctx.Query("favorite") == "true")
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -53,16 +116,42 @@ func (c *RecipeController) GetAllRecipes(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"recipes": recipes})
 }
 
-// GetRecipeByID returns a specific recipe by ID for the authenticated user
+// GetRecipeByID returns a specific recipe by ID of the authenticated user's household,
+// scaled to ?servings=N when given
 func (c *RecipeController) GetRecipeByID(ctx *gin.Context) {
 	userID := ctx.GetUint("userID")
+	householdID := ctx.GetUint("householdID")
 	recipeID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid recipe ID"})
 		return
 	}
 
-	recipe, err := c.recipeService.GetRecipeByID(userID, uint(recipeID))
+	if servingsParam := ctx.Query("servings"); servingsParam != "" {
+		servings, err := strconv.Atoi(servingsParam)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidServings.Error()})
+			return
+		}
+
+		scaled, err := c.recipeService.ScaleRecipe(userID, householdID, uint(recipeID), servings)
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrRecipeNotFound):
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "recipe not found"})
+			case errors.Is(err, services.ErrInvalidServings), errors.Is(err, services.ErrUnscalableRecipe):
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"recipe": scaled})
+		return
+	}
+
+	recipe, err := c.recipeService.GetRecipeByID(householdID, uint(recipeID))
 	if err != nil {
 		if err == models.ErrRecipeNotFound {
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "recipe not found"})
@@ -74,3 +163,130 @@ func (c *RecipeController) GetRecipeByID(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"recipe": recipe})
 }
+
+// UpdateRecipe favorites, rates or annotates a recipe of the authenticated user's household
+func (c *RecipeController) UpdateRecipe(ctx *gin.Context) {
+	householdID := ctx.GetUint("householdID")
+	recipeID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid recipe ID"})
+		return
+	}
+
+	var feedback services.RecipeFeedback
+	if err := ctx.ShouldBindJSON(&feedback); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	recipe, err := c.recipeService.UpdateFeedback(householdID, uint(recipeID), feedback)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecipeNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "recipe not found"})
+		case errors.Is(err, models.ErrInvalidRating):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"recipe": recipe})
+}
+
+// DeleteRecipe deletes a recipe of the authenticated user's household
+func (c *RecipeController) DeleteRecipe(ctx *gin.Context) {
+	householdID := ctx.GetUint("householdID")
+	recipeID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid recipe ID"})
+		return
+	}
+
+	if err := c.recipeService.DeleteRecipe(householdID, uint(recipeID)); err != nil {
+		if errors.Is(err, models.ErrRecipeNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "recipe not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Recipe deleted successfully"})
+}
+
+// MatchRecipes returns the library recipes that best fit the household's pantry, without calling the model
+func (c *RecipeController) MatchRecipes(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	householdID := ctx.GetUint("householdID")
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "0"))
+	if err != nil || limit < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	matches, err := c.recipeService.MatchLibrary(userID, householdID, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"matches": matches})
+}
+
+// ImportRecipeLibrary imports library recipes from an uploaded JSON or CSV file,
+// or from a JSON array in the request body
+func (c *RecipeController) ImportRecipeLibrary(ctx *gin.Context) {
+	var (
+		imported int
+		err      error
+	)
+
+	fileHeader, fileErr := ctx.FormFile("file")
+	switch {
+	case fileErr == nil:
+		file, openErr := fileHeader.Open()
+		if openErr != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "failed to read uploaded file"})
+			return
+		}
+		defer file.Close()
+
+		if strings.HasSuffix(strings.ToLower(fileHeader.Filename), ".csv") {
+			imported, err = c.recipeService.ImportLibraryCSV(file)
+		} else {
+			imported, err = c.recipeService.ImportLibraryJSON(file)
+		}
+	case strings.HasPrefix(ctx.ContentType(), "text/csv"):
+		imported, err = c.recipeService.ImportLibraryCSV(ctx.Request.Body)
+	default:
+		imported, err = c.recipeService.ImportLibraryJSON(ctx.Request.Body)
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Recipes imported successfully", "imported": imported})
+}
+
+// GetGenerationUsage returns recipe generations and token usage per user over the last ?days days (default 30)
+func (c *RecipeController) GetGenerationUsage(ctx *gin.Context) {
+	days, err := strconv.Atoi(ctx.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid days"})
+		return
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -days)
+	usage, err := c.recipeService.GenerationUsage(since)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"since": since, "usage": usage})
+}
@@ -0,0 +1,345 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"zero-waste-kitchen/internal/events"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ShoppingListController struct {
+	shoppingListService services.ShoppingListService
+	publisher           events.Publisher
+}
+
+func NewShoppingListController(shoppingListService services.ShoppingListService, publisher events.Publisher) *ShoppingListController {
+	return &ShoppingListController{shoppingListService: shoppingListService, publisher: publisher}
+}
+
+type ShoppingItemRequest struct {
+	Name     string  `json:"name"`
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+}
+
+type ShoppingListRequest struct {
+	Name  string                `json:"name"`
+	Items []ShoppingItemRequest `json:"items"`
+}
+
+type GenerateShoppingListRequest struct {
+	Name             string `json:"name"`
+	From             string `json:"from"` // YYYY-MM-DD, planned meals from this date are included
+	To               string `json:"to"`   // YYYY-MM-DD, defaults to six days after from
+	RecipeIDs        []uint `json:"recipe_ids"`
+	IncludeParLevels bool   `json:"include_par_levels"`
+}
+
+type UpdateShoppingItemRequest struct {
+	Name            *string  `json:"name"`
+	Quantity        *float64 `json:"quantity"`
+	Unit            *string  `json:"unit"`
+	Checked         *bool    `json:"checked"`
+	AddToPantry     bool     `json:"add_to_pantry"`    // Add checked items to the pantry
+	StorageLocation string   `json:"storage_location"` // Estimated when empty
+	ExpiryDate      *string  `json:"expiry_date"`      // YYYY-MM-DD, estimated when empty
+}
+
+// GetShoppingLists returns the household's shopping lists
+func (c *ShoppingListController) GetShoppingLists(ctx *gin.Context) {
+	householdID := ctx.GetUint("householdID")
+
+	lists, err := c.shoppingListService.GetLists(householdID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"shopping_lists": lists})
+}
+
+// GetShoppingList returns one shopping list with its items
+func (c *ShoppingListController) GetShoppingList(ctx *gin.Context) {
+	householdID := ctx.GetUint("householdID")
+	listID, ok := parseIDParam(ctx, "id", "invalid shopping list ID")
+	if !ok {
+		return
+	}
+
+	list, err := c.shoppingListService.GetList(householdID, listID)
+	if err != nil {
+		respondShoppingListError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"shopping_list": list})
+}
+
+// CreateShoppingList creates a shopping list from items entered by hand
+func (c *ShoppingListController) CreateShoppingList(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	householdID := ctx.GetUint("householdID")
+
+	var req ShoppingListRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	items := make([]services.ShoppingItemInput, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, services.ShoppingItemInput{Name: item.Name, Quantity: item.Quantity, Unit: item.Unit})
+	}
+
+	list, err := c.shoppingListService.CreateList(userID, householdID, req.Name, items)
+	if err != nil {
+		respondShoppingListError(ctx, err)
+		return
+	}
+	c.publisher.Publish(events.New(events.ShoppingListCreated, householdID, userID, gin.H{"shopping_list": list}))
+
+	ctx.JSON(http.StatusCreated, gin.H{"shopping_list": list})
+}
+
+// GenerateShoppingList creates a shopping list of what the pantry is missing for
+// planned meals, chosen recipes and par levels
+func (c *ShoppingListController) GenerateShoppingList(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	householdID := ctx.GetUint("householdID")
+
+	var req GenerateShoppingListRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	input := services.ShoppingListGenerateInput{
+		Name:             req.Name,
+		RecipeIDs:        req.RecipeIDs,
+		IncludeParLevels: req.IncludeParLevels,
+	}
+	if req.From != "" {
+		from, err := time.Parse(dateLayout, req.From)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "from must be written as YYYY-MM-DD"})
+			return
+		}
+		to := from.AddDate(0, 0, 6)
+		if req.To != "" {
+			to, err = time.Parse(dateLayout, req.To)
+			if err != nil || to.Before(from) {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "to must be a YYYY-MM-DD date on or after from"})
+				return
+			}
+		}
+		input.From, input.To = from, to
+	}
+	if input.From.IsZero() && len(input.RecipeIDs) == 0 && !input.IncludeParLevels {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "choose a meal plan range, recipes or par levels to shop for"})
+		return
+	}
+
+	list, err := c.shoppingListService.GenerateList(userID, householdID, input)
+	if err != nil {
+		respondShoppingListError(ctx, err)
+		return
+	}
+	c.publisher.Publish(events.New(events.ShoppingListCreated, householdID, userID, gin.H{"shopping_list": list}))
+
+	ctx.JSON(http.StatusCreated, gin.H{"shopping_list": list})
+}
+
+// DeleteShoppingList deletes a shopping list and its items
+func (c *ShoppingListController) DeleteShoppingList(ctx *gin.Context) {
+	householdID := ctx.GetUint("householdID")
+	listID, ok := parseIDParam(ctx, "id", "invalid shopping list ID")
+	if !ok {
+		return
+	}
+
+	if err := c.shoppingListService.DeleteList(householdID, listID); err != nil {
+		respondShoppingListError(ctx, err)
+		return
+	}
+	c.publisher.Publish(events.New(events.ShoppingListDeleted, householdID, ctx.GetUint("userID"), gin.H{"shopping_list_id": listID}))
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Shopping list deleted successfully"})
+}
+
+// AddShoppingListItem adds an item to a list, merging it with the same product
+func (c *ShoppingListController) AddShoppingListItem(ctx *gin.Context) {
+	householdID := ctx.GetUint("householdID")
+	listID, ok := parseIDParam(ctx, "id", "invalid shopping list ID")
+	if !ok {
+		return
+	}
+
+	var req ShoppingItemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	list, err := c.shoppingListService.AddItem(householdID, listID, services.ShoppingItemInput{Name: req.Name, Quantity: req.Quantity, Unit: req.Unit})
+	if err != nil {
+		respondShoppingListError(ctx, err)
+		return
+	}
+	c.publisher.Publish(events.New(events.ShoppingListUpdated, householdID, ctx.GetUint("userID"), gin.H{"shopping_list": list}))
+
+	ctx.JSON(http.StatusOK, gin.H{"shopping_list": list})
+}
+
+// UpdateShoppingListItem edits an item or checks it off, optionally adding it to the pantry
+func (c *ShoppingListController) UpdateShoppingListItem(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	householdID := ctx.GetUint("householdID")
+	listID, ok := parseIDParam(ctx, "id", "invalid shopping list ID")
+	if !ok {
+		return
+	}
+	itemID, ok := parseIDParam(ctx, "itemId", "invalid shopping list item ID")
+	if !ok {
+		return
+	}
+
+	var req UpdateShoppingItemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	changes := services.ShoppingItemChanges{
+		Name:            req.Name,
+		Quantity:        req.Quantity,
+		Unit:            req.Unit,
+		Checked:         req.Checked,
+		AddToPantry:     req.AddToPantry,
+		StorageLocation: req.StorageLocation,
+	}
+	if req.ExpiryDate != nil {
+		expiry, err := time.Parse(dateLayout, *req.ExpiryDate)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "expiry_date must be written as YYYY-MM-DD"})
+			return
+		}
+		changes.ExpiryDate = &expiry
+	}
+
+	list, err := c.shoppingListService.UpdateItem(userID, householdID, listID, itemID, changes)
+	if err != nil {
+		respondShoppingListError(ctx, err)
+		return
+	}
+	c.publisher.Publish(events.New(events.ShoppingListUpdated, householdID, userID, gin.H{"shopping_list": list}))
+	if req.AddToPantry {
+		c.publisher.Publish(events.New(events.InventoryChanged, householdID, userID, gin.H{"reason": "shopping_list", "shopping_list_id": listID}))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"shopping_list": list})
+}
+
+// DeleteShoppingListItem removes an item from a list
+func (c *ShoppingListController) DeleteShoppingListItem(ctx *gin.Context) {
+	householdID := ctx.GetUint("householdID")
+	listID, ok := parseIDParam(ctx, "id", "invalid shopping list ID")
+	if !ok {
+		return
+	}
+	itemID, ok := parseIDParam(ctx, "itemId", "invalid shopping list item ID")
+	if !ok {
+		return
+	}
+
+	list, err := c.shoppingListService.DeleteItem(householdID, listID, itemID)
+	if err != nil {
+		respondShoppingListError(ctx, err)
+		return
+	}
+	c.publisher.Publish(events.New(events.ShoppingListUpdated, householdID, ctx.GetUint("userID"), gin.H{"shopping_list": list}))
+
+	ctx.JSON(http.StatusOK, gin.H{"shopping_list": list})
+}
+
+// GetParLevels returns the minimum stock the household wants to keep per product
+func (c *ShoppingListController) GetParLevels(ctx *gin.Context) {
+	householdID := ctx.GetUint("householdID")
+
+	levels, err := c.shoppingListService.GetParLevels(householdID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"par_levels": levels})
+}
+
+// SetParLevel creates or replaces the par level for a product
+func (c *ShoppingListController) SetParLevel(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	householdID := ctx.GetUint("householdID")
+
+	var level models.ParLevel
+	if err := ctx.ShouldBindJSON(&level); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	level.ID = 0
+	level.UserID = userID
+	level.HouseholdID = householdID
+
+	if err := c.shoppingListService.SetParLevel(&level); err != nil {
+		respondShoppingListError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"par_level": level})
+}
+
+// DeleteParLevel stops tracking a product's par level
+func (c *ShoppingListController) DeleteParLevel(ctx *gin.Context) {
+	householdID := ctx.GetUint("householdID")
+	levelID, ok := parseIDParam(ctx, "id", "invalid par level ID")
+	if !ok {
+		return
+	}
+
+	if err := c.shoppingListService.DeleteParLevel(householdID, levelID); err != nil {
+		respondShoppingListError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Par level deleted successfully"})
+}
+
+// parseIDParam reads a numeric path parameter. It responds with 400 and
+// returns false when the parameter isn't a valid ID.
+func parseIDParam(ctx *gin.Context, name, message string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondShoppingListError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrShoppingListNotFound),
+		errors.Is(err, models.ErrShoppingListItemNotFound),
+		errors.Is(err, models.ErrParLevelNotFound),
+		errors.Is(err, models.ErrRecipeNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrInvalidShoppingItem),
+		errors.Is(err, models.ErrInvalidParLevel),
+		errors.Is(err, models.ErrInvalidStorageLocation):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
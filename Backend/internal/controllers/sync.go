@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"zero-waste-kitchen/internal/services"
+	"zero-waste-kitchen/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	syncPollInterval      = 5 * time.Second  // Picks up events published on other instances
+	syncHeartbeatInterval = 15 * time.Second // Keeps proxies from closing an idle stream, well within streamWriteTimeout
+	syncRetry             = 3 * time.Second  // How long EventSource clients wait before reconnecting
+)
+
+type SyncController struct {
+	syncService      services.SyncService
+	householdService services.HouseholdService
+}
+
+func NewSyncController(syncService services.SyncService, householdService services.HouseholdService) *SyncController {
+	return &SyncController{syncService: syncService, householdService: householdService}
+}
+
+// CreateStreamToken returns a short-lived token for opening the event stream
+// from a browser. EventSource can't send an Authorization header, so it passes
+// the token in ?token instead. A token only opens streams, until expires_at;
+// EventSource's own reconnects, which send Last-Event-ID, keep working for a day
+// after that. A client that closes the stream itself gets a new token and
+// reconnects with ?last_event_id.
+func (c *SyncController) CreateStreamToken(ctx *gin.Context) {
+	token, expiresAt, err := middleware.GenerateStreamToken(ctx.GetUint("userID"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create stream token"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"token": token, "expires_at": expiresAt})
+}
+
+// StreamEvents streams changes to the household's groceries, receipts and
+// shopping lists as Server-Sent Events, each with the event type as its name and
+// the event as JSON. Clients that reconnect with a Last-Event-ID header, or
+// ?last_event_id, get the events they missed first. When too much was missed,
+// or the user leaves the household, a reset event tells them to reload.
+func (c *SyncController) StreamEvents(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	householdID := ctx.GetUint("householdID")
+
+	lastEventID := ctx.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = ctx.Query("last_event_id")
+	}
+	resuming := lastEventID != ""
+	after, ok := parseSyncEventID(lastEventID, householdID)
+	if resuming && !ok {
+		resuming = false // From another household, so a reset follows
+	}
+
+	// Subscribe before reading where to start, so no event falls in between
+	wake, unsubscribe := c.syncService.Subscribe(householdID)
+	defer unsubscribe()
+
+	cursor, reset, err := c.syncService.Resume(householdID, after, resuming)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	reset = reset || (lastEventID != "" && !ok)
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	writer := http.NewResponseController(ctx.Writer)
+	write := func(format string, args ...any) bool {
+		writer.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if _, err := fmt.Fprintf(ctx.Writer, format, args...); err != nil {
+			return false
+		}
+		return writer.Flush() == nil
+	}
+
+	if !write("retry: %d\n\n", syncRetry.Milliseconds()) {
+		return
+	}
+	if reset {
+		write("id: %s\nevent: reset\ndata: {\"reason\":\"expired\"}\n\n", syncEventID(householdID, cursor))
+	} else {
+		write("id: %s\nevent: ready\ndata: {}\n\n", syncEventID(householdID, cursor))
+	}
+
+	poll := time.NewTicker(syncPollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(syncHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		householdEvents, err := c.syncService.EventsAfter(householdID, cursor)
+		if err != nil {
+			log.Printf("Failed to stream events to user %d: %v", userID, err)
+			return
+		}
+		for _, event := range householdEvents {
+			if !write("id: %s\nevent: %s\ndata: %s\n\n", syncEventID(householdID, event.Seq), event.Type, event.Payload) {
+				return
+			}
+			cursor = event.Seq
+		}
+		if len(householdEvents) > 0 {
+			continue // There may be more
+		}
+
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-wake:
+		case <-poll.C:
+		case <-heartbeat.C:
+			current, _, err := c.householdService.Membership(userID)
+			if err != nil {
+				log.Printf("Failed to check household of user %d: %v", userID, err)
+				return // The client reconnects and resumes
+			}
+			if current != householdID {
+				write("event: reset\ndata: {\"reason\":\"household_changed\"}\n\n")
+				return
+			}
+			if !write(": ping\n\n") {
+				return
+			}
+		}
+	}
+}
+
+// syncEventID is the SSE ID of a household's event: the household and the
+// event's Seq, e.g. "12-345", so a client that changed household is noticed
+func syncEventID(householdID uint, seq uint64) string {
+	return strconv.FormatUint(uint64(householdID), 10) + "-" + strconv.FormatUint(seq, 10)
+}
+
+// parseSyncEventID returns the Seq of an SSE ID, and false when the ID is
+// invalid or from another household
+func parseSyncEventID(id string, householdID uint) (uint64, bool) {
+	household, seq, found := strings.Cut(id, "-")
+	if !found || household != strconv.FormatUint(uint64(householdID), 10) {
+		return 0, false
+	}
+	parsed, err := strconv.ParseUint(seq, 10, 64)
+	return parsed, err == nil
+}
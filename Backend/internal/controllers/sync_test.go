@@ -0,0 +1,34 @@
+package controllers
+
+import "testing"
+
+func TestSyncEventID(t *testing.T) {
+	if id := syncEventID(12, 345); id != "12-345" {
+		t.Errorf("syncEventID = %q, want 12-345", id)
+	}
+
+	tests := []struct {
+		id      string
+		wantSeq uint64
+		wantOK  bool
+	}{
+		{"12-345", 345, true},
+		{"12-0", 0, true},
+		{"13-345", 0, false}, // Another household
+		{"112-345", 0, false},
+		{"345", 0, false},
+		{"12-", 0, false},
+		{"12--1", 0, false},
+		{"12-abc", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			seq, ok := parseSyncEventID(tt.id, 12)
+			if seq != tt.wantSeq || ok != tt.wantOK {
+				t.Errorf("parseSyncEventID(%q) = %d, %v, want %d, %v", tt.id, seq, ok, tt.wantSeq, tt.wantOK)
+			}
+		})
+	}
+}
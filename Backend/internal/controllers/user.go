@@ -1,10 +1,13 @@
 package controllers
 
 import (
+	"fmt"
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
+	"zero-waste-kitchen/internal/i18n"
 	"zero-waste-kitchen/internal/models"
 	"zero-waste-kitchen/pkg/database"
 	"zero-waste-kitchen/pkg/middleware"
@@ -48,6 +51,11 @@ func Register(c *gin.Context) {
 		return
 	}
 
+	if msg := normalizeLocale(&user); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
 	log.Printf("Registering user with email: %s", user.Email)
 
 	// Hash the password before storing
@@ -72,9 +80,11 @@ func Register(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "User registered successfully",
 		"user": gin.H{
-			"id":    user.ID,
-			"email": user.Email,
-			"name":  user.Name,
+			"id":        user.ID,
+			"email":     user.Email,
+			"name":      user.Name,
+			"locale":    user.Locale,
+			"time_zone": user.TimeZone,
 		},
 	})
 }
@@ -119,6 +129,11 @@ func Login(c *gin.Context) {
 
 	log.Printf("Authentication successful for user: %s", user.Email)
 
+	// Tells admins which users have gone inactive
+	if err := database.DB.Model(&user).Update("last_login_at", time.Now()).Error; err != nil {
+		log.Printf("Failed to record login: %v", err)
+	}
+
 	// Generate JWT token
 	token, err := middleware.GenerateToken(user.ID)
 	if err != nil {
@@ -133,9 +148,11 @@ func Login(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"token": token,
 		"user": gin.H{
-			"id":    user.ID,
-			"email": user.Email,
-			"name":  user.Name,
+			"id":        user.ID,
+			"email":     user.Email,
+			"name":      user.Name,
+			"locale":    user.Locale,
+			"time_zone": user.TimeZone,
 		},
 	})
 }
@@ -153,9 +170,11 @@ func GetCurrentUser(c *gin.Context) {
 	// Don't return password
 	user.Password = ""
 	c.JSON(http.StatusOK, gin.H{
-		"id":    user.ID,
-		"email": user.Email,
-		"name":  user.Name,
+		"id":        user.ID,
+		"email":     user.Email,
+		"name":      user.Name,
+		"locale":    user.Locale,
+		"time_zone": user.TimeZone,
 	})
 }
 
@@ -173,6 +192,8 @@ func UpdateUser(c *gin.Context) {
 		Name     string `json:"name"`
 		Email    string `json:"email"`
 		Password string `json:"password,omitempty"`
+		Locale   string `json:"locale"`
+		TimeZone string `json:"time_zone"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -187,6 +208,16 @@ func UpdateUser(c *gin.Context) {
 	if input.Email != "" {
 		user.Email = strings.TrimSpace(input.Email)
 	}
+	if input.Locale != "" {
+		user.Locale = input.Locale
+	}
+	if input.TimeZone != "" {
+		user.TimeZone = input.TimeZone
+	}
+	if msg := normalizeLocale(&user); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
 	if input.Password != "" {
 		user.Password = strings.TrimSpace(input.Password)
 		if err := user.HashPassword(); err != nil {
@@ -206,31 +237,35 @@ func UpdateUser(c *gin.Context) {
 	// Don't return password
 	user.Password = ""
 	c.JSON(http.StatusOK, gin.H{
-		"id":      user.ID,
-		"email":   user.Email,
-		"name":    user.Name,
-		"message": "User updated successfully",
+		"id":        user.ID,
+		"email":     user.Email,
+		"name":      user.Name,
+		"locale":    user.Locale,
+		"time_zone": user.TimeZone,
+		"message":   "User updated successfully",
 	})
 }
 
-// RegisterFCMToken allows users to register their FCM token for push notifications
-func RegisterFCMToken(c *gin.Context) {
-	userID := c.GetUint("userID")
+// normalizeLocale checks the user's locale and time zone, filling in English and
+// UTC when they're empty. It returns an error message for the client, or "".
+func normalizeLocale(user *models.User) string {
+	user.Locale = strings.TrimSpace(user.Locale)
+	user.TimeZone = strings.TrimSpace(user.TimeZone)
 
-	var input struct {
-		Token string `json:"token" binding:"required"`
+	if user.Locale == "" {
+		user.Locale = i18n.DefaultLocale
 	}
-
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	locale := i18n.Match(user.Locale)
+	if locale == "" {
+		return fmt.Sprintf("Unsupported locale, use one of %s", strings.Join(i18n.Locales(), ", "))
 	}
+	user.Locale = locale
 
-	if err := database.DB.Model(&models.User{}).Where("id = ?", userID).Update("fcm_token", strings.TrimSpace(input.Token)).Error; err != nil {
-		log.Printf("Failed to update FCM token: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update FCM token"})
-		return
+	if user.TimeZone == "" {
+		user.TimeZone = "UTC"
 	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "FCM token registered successfully"})
+	if _, err := time.LoadLocation(user.TimeZone); err != nil {
+		return "Unknown time zone " + user.TimeZone
+	}
+	return ""
 }
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookController struct {
+	webhookService services.WebhookService
+}
+
+func NewWebhookController(webhookService services.WebhookService) *WebhookController {
+	return &WebhookController{webhookService: webhookService}
+}
+
+type webhookRequest struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	Enabled     *bool    `json:"enabled"`
+}
+
+func (r webhookRequest) input() services.WebhookInput {
+	return services.WebhookInput{URL: r.URL, Events: r.Events, Description: r.Description, Enabled: r.Enabled}
+}
+
+// CreateWebhook registers an endpoint for the events of the user's household.
+// The signing secret is only in this response.
+func (c *WebhookController) CreateWebhook(ctx *gin.Context) {
+	householdID := ctx.GetUint("householdID")
+	c.createWebhook(ctx, &householdID)
+}
+
+// CreateGlobalWebhook lets an admin register an endpoint for the events of every household
+func (c *WebhookController) CreateGlobalWebhook(ctx *gin.Context) {
+	c.createWebhook(ctx, nil)
+}
+
+func (c *WebhookController) createWebhook(ctx *gin.Context, householdID *uint) {
+	var input webhookRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := c.webhookService.CreateWebhook(ctx.GetUint("userID"), householdID, input.input())
+	if err != nil {
+		respondWebhookError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"webhook": webhook, "secret": webhook.Secret})
+}
+
+// GetWebhooks lists the webhooks the user registered
+func (c *WebhookController) GetWebhooks(ctx *gin.Context) {
+	webhooks, err := c.webhookService.GetWebhooks(ctx.GetUint("userID"))
+	if err != nil {
+		respondWebhookError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"webhooks": webhooks})
+}
+
+func (c *WebhookController) GetWebhook(ctx *gin.Context) {
+	id, ok := parseIDParam(ctx, "id", "invalid webhook ID")
+	if !ok {
+		return
+	}
+
+	webhook, err := c.webhookService.GetWebhook(ctx.GetUint("userID"), id)
+	if err != nil {
+		respondWebhookError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"webhook": webhook})
+}
+
+// UpdateWebhook changes a webhook's URL, events, description or enabled state
+func (c *WebhookController) UpdateWebhook(ctx *gin.Context) {
+	id, ok := parseIDParam(ctx, "id", "invalid webhook ID")
+	if !ok {
+		return
+	}
+	var input webhookRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := c.webhookService.UpdateWebhook(ctx.GetUint("userID"), id, input.input())
+	if err != nil {
+		respondWebhookError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"webhook": webhook})
+}
+
+func (c *WebhookController) DeleteWebhook(ctx *gin.Context) {
+	id, ok := parseIDParam(ctx, "id", "invalid webhook ID")
+	if !ok {
+		return
+	}
+
+	if err := c.webhookService.DeleteWebhook(ctx.GetUint("userID"), id); err != nil {
+		respondWebhookError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Webhook deleted"})
+}
+
+// GetWebhookDeliveries returns a page of a webhook's delivery log, newest first
+func (c *WebhookController) GetWebhookDeliveries(ctx *gin.Context) {
+	id, ok := parseIDParam(ctx, "id", "invalid webhook ID")
+	if !ok {
+		return
+	}
+	limit, offset, ok := parsePage(ctx)
+	if !ok {
+		return
+	}
+
+	deliveries, total, err := c.webhookService.GetDeliveries(ctx.GetUint("userID"), id, limit, offset)
+	if err != nil {
+		respondWebhookError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"deliveries": deliveries, "total": total})
+}
+
+// TestWebhook sends a webhook.test event right away and returns the delivery,
+// so users can check their endpoint and signature verification
+func (c *WebhookController) TestWebhook(ctx *gin.Context) {
+	id, ok := parseIDParam(ctx, "id", "invalid webhook ID")
+	if !ok {
+		return
+	}
+
+	delivery, err := c.webhookService.TestWebhook(ctx.Request.Context(), ctx.GetUint("userID"), id)
+	if err != nil {
+		respondWebhookError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"delivery": delivery})
+}
+
+func respondWebhookError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrWebhookNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrInvalidWebhook):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
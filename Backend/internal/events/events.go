@@ -0,0 +1,92 @@
+// Package events carries things that happened in a household, such as a new
+// grocery item, from the code that made them happen to whoever listens, such as
+// outbound webhooks.
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+const (
+	ItemCreated      = "item.created"
+	ItemUpdated      = "item.updated"
+	ItemDeleted      = "item.deleted"
+	ItemExpiring     = "item.expiring"
+	ItemExpired      = "item.expired"
+	ItemConsumed     = "item.consumed"
+	InventoryChanged = "inventory.changed" // Several items changed at once, e.g. a cooked meal used them up
+	ReceiptProcessed = "receipt.processed"
+	RecipeGenerated  = "recipe.generated"
+
+	ShoppingListCreated = "shopping_list.created"
+	ShoppingListUpdated = "shopping_list.updated"
+	ShoppingListDeleted = "shopping_list.deleted"
+)
+
+// Event is something that happened in a household
+type Event struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	HouseholdID uint      `json:"household_id"`
+	UserID      uint      `json:"user_id,omitempty"` // Who made it happen, 0 for the system
+	Data        any       `json:"data"`
+	OccurredAt  time.Time `json:"occurred_at"`
+}
+
+// New returns an event with a random ID, happening now
+func New(eventType string, householdID, userID uint, data any) Event {
+	return Event{
+		ID:          NewID(),
+		Type:        eventType,
+		HouseholdID: householdID,
+		UserID:      userID,
+		Data:        data,
+		OccurredAt:  time.Now().UTC(),
+	}
+}
+
+// NewID returns a random event ID such as evt_3f9a...
+func NewID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		// crypto/rand doesn't fail on supported platforms
+		panic(err)
+	}
+	return "evt_" + hex.EncodeToString(id)
+}
+
+// Publisher takes events. Publish must not block for long, it runs on the
+// request that made the event happen.
+type Publisher interface {
+	Publish(event Event)
+}
+
+// Bus hands each published event to every subscriber, in the order they subscribed
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers []func(Event)
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe adds a function called with every event published from now on
+func (b *Bus) Subscribe(fn func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, fn)
+}
+
+func (b *Bus) Publish(event Event) {
+	b.mu.RLock()
+	subscribers := b.subscribers
+	b.mu.RUnlock()
+
+	for _, fn := range subscribers {
+		fn(event)
+	}
+}
@@ -0,0 +1,224 @@
+// Package i18n renders user-facing text, such as notifications, in the user's
+// language. Each locale file under locales/ holds text/template messages, plural
+// forms of the words they count and how dates are written.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+type localeFile struct {
+	Name     string                       `json:"name"`
+	And      string                       `json:"and"`    // Joins the last two entries of a list
+	Date     string                       `json:"date"`   // e.g. "{d}. {month}", see formatDate
+	Months   []string                     `json:"months"` // January first
+	Words    map[string]map[string]string `json:"words"`  // Plural forms by category, # stands for the number
+	Messages map[string]string            `json:"messages"`
+}
+
+type locale struct {
+	tag       string
+	file      localeFile
+	templates *template.Template
+}
+
+var locales = mustLoadLocales()
+
+// pluralRules pick the plural category of a number. Languages without a rule
+// use "one" for 1 and "other" for everything else.
+var pluralRules = map[string]func(n int) string{
+	"fr": func(n int) string {
+		if n == 0 || n == 1 {
+			return "one"
+		}
+		return "other"
+	},
+}
+
+func mustLoadLocales() map[string]*locale {
+	loaded, err := loadLocales()
+	if err != nil {
+		panic(fmt.Sprintf("i18n: %v", err))
+	}
+	return loaded
+}
+
+func loadLocales() (map[string]*locale, error) {
+	names, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := make(map[string]*locale, len(names))
+	for _, entry := range names {
+		data, err := localeFiles.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var file localeFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		if len(file.Months) != 12 {
+			return nil, fmt.Errorf("%s: need 12 months, got %d", entry.Name(), len(file.Months))
+		}
+
+		l := &locale{tag: strings.TrimSuffix(entry.Name(), ".json"), file: file}
+		// Functions are bound per Printer; these stand-ins only let the messages parse
+		l.templates = template.New(l.tag).Option("missingkey=error").Funcs(template.FuncMap{
+			"count": func(string, int) string { return "" },
+			"date":  func(time.Time) string { return "" },
+			"list":  func([]string) string { return "" },
+		})
+		for key, text := range file.Messages {
+			if _, err := l.templates.New(key).Parse(text); err != nil {
+				return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+			}
+		}
+		loaded[l.tag] = l
+	}
+
+	if loaded[DefaultLocale] == nil {
+		return nil, fmt.Errorf("default locale %s is missing", DefaultLocale)
+	}
+	return loaded, nil
+}
+
+// Locales returns the supported locale tags, sorted
+func Locales() []string {
+	tags := make([]string, 0, len(locales))
+	for tag := range locales {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Match returns the supported locale closest to a tag such as "de-AT" or
+// "fr_CA", or "" if its language isn't supported
+func Match(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	language, _, _ := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+	if locales[language] == nil {
+		return ""
+	}
+	return language
+}
+
+// Printer renders messages in one locale, writing dates in one time zone
+type Printer struct {
+	locale   *locale
+	location *time.Location
+}
+
+// NewPrinter returns a Printer for a locale and IANA time zone, falling back
+// to English and UTC for ones it doesn't know
+func NewPrinter(localeTag, timeZone string) *Printer {
+	l := locales[Match(localeTag)]
+	if l == nil {
+		l = locales[DefaultLocale]
+	}
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		location = time.UTC
+	}
+	return &Printer{locale: l, location: location}
+}
+
+// Locale returns the tag of the locale the Printer renders in
+func (p *Printer) Locale() string {
+	return p.locale.tag
+}
+
+// Location returns the time zone the Printer writes dates in
+func (p *Printer) Location() *time.Location {
+	return p.location
+}
+
+// Render executes the message template key with data. Messages missing from
+// the locale are rendered in English.
+func (p *Printer) Render(key string, data any) (string, error) {
+	l := p.locale
+	if l.templates.Lookup(key) == nil {
+		l = locales[DefaultLocale]
+	}
+	if l.templates.Lookup(key) == nil {
+		return "", fmt.Errorf("i18n: unknown message %q", key)
+	}
+
+	tmpl, err := l.templates.Clone()
+	if err != nil {
+		return "", err
+	}
+	tmpl.Funcs(template.FuncMap{
+		"count": func(word string, n int) string { return l.count(word, n) },
+		"date":  func(t time.Time) string { return l.formatDate(t.In(p.location)) },
+		"list":  l.list,
+	})
+
+	var b strings.Builder
+	if err := tmpl.ExecuteTemplate(&b, key, data); err != nil {
+		return "", fmt.Errorf("i18n: %s: %w", key, err)
+	}
+	return b.String(), nil
+}
+
+// count writes a number with the plural form of word that goes with it, e.g. "3 items"
+func (l *locale) count(word string, n int) string {
+	rule, ok := pluralRules[l.tag]
+	if !ok {
+		rule = func(n int) string {
+			if n == 1 {
+				return "one"
+			}
+			return "other"
+		}
+	}
+
+	forms := l.file.Words[word]
+	form, ok := forms[rule(n)]
+	if !ok {
+		form, ok = forms["other"]
+	}
+	if !ok {
+		return strconv.Itoa(n) + " " + word
+	}
+	return strings.ReplaceAll(form, "#", strconv.Itoa(n))
+}
+
+// formatDate writes a date following the locale's pattern, where {d} is the
+// day, {dd} the zero-padded day, {month} the month name, {mm} the month number
+// and {yyyy} the year
+func (l *locale) formatDate(t time.Time) string {
+	return strings.NewReplacer(
+		"{dd}", fmt.Sprintf("%02d", t.Day()),
+		"{d}", strconv.Itoa(t.Day()),
+		"{month}", l.file.Months[t.Month()-1],
+		"{mm}", fmt.Sprintf("%02d", int(t.Month())),
+		"{yyyy}", strconv.Itoa(t.Year()),
+	).Replace(l.file.Date)
+}
+
+// list joins entries like "a, b and c"
+func (l *locale) list(entries []string) string {
+	switch len(entries) {
+	case 0:
+		return ""
+	case 1:
+		return entries[0]
+	}
+	return strings.Join(entries[:len(entries)-1], ", ") + " " + l.file.And + " " + entries[len(entries)-1]
+}
@@ -0,0 +1,153 @@
+// Package ical writes iCalendar (RFC 5545) feeds of all-day events, which
+// calendar apps can subscribe to by URL.
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405Z"
+	maxLineOctets  = 75 // Longer content lines are folded
+)
+
+// Calendar is a feed of events
+type Calendar struct {
+	ProdID  string        // Identifies the product that wrote the feed, e.g. "-//Acme//Pantry//EN"
+	Name    string        // Shown by calendar apps that support X-WR-CALNAME
+	Refresh time.Duration // How often subscribers should fetch the feed again, 0 to leave it to them
+	Events  []Event
+}
+
+// Event is an all-day event on Date, whose time of day is ignored
+type Event struct {
+	UID         string // Stable across fetches, so apps update the event rather than duplicate it
+	Date        time.Time
+	Summary     string
+	Description string
+	Categories  []string
+	Stamp       time.Time // When the event last changed
+	Alarm       *Alarm
+}
+
+// Alarm reminds the user of an event. Trigger is relative to the start of the
+// event's day, e.g. -15h for 9:00 the day before.
+type Alarm struct {
+	Trigger     time.Duration
+	Description string
+}
+
+// Encode writes the calendar as an RFC 5545 document
+func (c *Calendar) Encode() []byte {
+	var w writer
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", c.ProdID)
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		w.line("X-WR-CALNAME", escape(c.Name))
+	}
+	if c.Refresh > 0 {
+		w.line("REFRESH-INTERVAL;VALUE=DURATION", duration(c.Refresh))
+		w.line("X-PUBLISHED-TTL", duration(c.Refresh))
+	}
+
+	for _, event := range c.Events {
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", escape(event.UID))
+		w.line("DTSTAMP", event.Stamp.UTC().Format(dateTimeLayout))
+		w.line("DTSTART;VALUE=DATE", event.Date.Format(dateLayout))
+		w.line("DTEND;VALUE=DATE", event.Date.AddDate(0, 0, 1).Format(dateLayout))
+		w.line("SUMMARY", escape(event.Summary))
+		if event.Description != "" {
+			w.line("DESCRIPTION", escape(event.Description))
+		}
+		if len(event.Categories) > 0 {
+			categories := make([]string, len(event.Categories))
+			for i, category := range event.Categories {
+				categories[i] = escape(category)
+			}
+			w.line("CATEGORIES", strings.Join(categories, ","))
+		}
+		w.line("TRANSP", "TRANSPARENT") // All-day reminders shouldn't block time
+		if event.Alarm != nil {
+			w.line("BEGIN", "VALARM")
+			w.line("ACTION", "DISPLAY")
+			w.line("DESCRIPTION", escape(event.Alarm.Description))
+			w.line("TRIGGER", duration(event.Alarm.Trigger))
+			w.line("END", "VALARM")
+		}
+		w.line("END", "VEVENT")
+	}
+
+	w.line("END", "VCALENDAR")
+	return w.buf.Bytes()
+}
+
+type writer struct {
+	buf bytes.Buffer
+}
+
+// line writes a content line, folding it so no line is longer than 75 octets
+// and no UTF-8 sequence is split
+func (w *writer) line(name, value string) {
+	line := name + ":" + value
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.buf.WriteString(line[:cut])
+		w.buf.WriteString("\r\n ")
+		line = line[cut:]
+		limit = maxLineOctets - 1 // The leading space counts
+	}
+	w.buf.WriteString(line)
+	w.buf.WriteString("\r\n")
+}
+
+// escape escapes a TEXT value
+func escape(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(text)
+}
+
+// duration writes a DURATION value such as -PT15H or P1DT30M, to the minute
+func duration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	minutes := int(d / time.Minute)
+	days, hours, minutes := minutes/(24*60), minutes/60%24, minutes%60
+
+	var b strings.Builder
+	b.WriteString(sign + "P")
+	if days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+	if hours > 0 || minutes > 0 || days == 0 {
+		b.WriteString("T")
+		if hours > 0 || minutes == 0 {
+			fmt.Fprintf(&b, "%dH", hours)
+		}
+		if minutes > 0 {
+			fmt.Fprintf(&b, "%dM", minutes)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultFakeResponse is a single well-formed recipe array
+const defaultFakeResponse = `[
+  {
+    "title": "Pantry Fried Rice",
+    "ingredients": "2 cups cooked rice\n2 eggs\n1 cup mixed vegetables\n2 tbsp soy sauce",
+    "instructions": "Step 1: Scramble the eggs\nStep 2: Stir-fry the vegetables\nStep 3: Add rice and soy sauce and toss",
+    "prep_time": 10,
+    "cook_time": 10,
+    "servings": 2,
+    "difficulty": "Easy",
+    "cuisine": "Asian"
+  }
+]`
+
+// FakeClient is a deterministic Client for tests and offline development.
+// It replies with its responses in order, repeating the last one, and records
+// the messages it received.
+type FakeClient struct {
+	Responses []string
+
+	mu    sync.Mutex
+	calls [][]Message
+}
+
+// NewFakeClient returns a FakeClient replying with responses, or a canned recipe when none are given
+func NewFakeClient(responses ...string) *FakeClient {
+	if len(responses) == 0 {
+		responses = []string{defaultFakeResponse}
+	}
+	return &FakeClient{Responses: responses}
+}
+
+func (c *FakeClient) Complete(ctx context.Context, messages []Message) (*Completion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	response := c.Responses[len(c.Responses)-1]
+	if len(c.calls) < len(c.Responses) {
+		response = c.Responses[len(c.calls)]
+	}
+	c.calls = append(c.calls, messages)
+
+	return &Completion{Content: response}, nil
+}
+
+// CompleteStream replies like Complete, delivering the content in small chunks
+func (c *FakeClient) CompleteStream(ctx context.Context, messages []Message, onDelta func(delta string)) (*Completion, error) {
+	completion, err := c.Complete(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	const chunkSize = 32
+	for content := completion.Content; content != ""; {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n := min(chunkSize, len(content))
+		onDelta(content[:n])
+		content = content[n:]
+	}
+	return completion, nil
+}
+
+// Calls returns the messages of every Complete call so far
+func (c *FakeClient) Calls() [][]Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][]Message(nil), c.calls...)
+}
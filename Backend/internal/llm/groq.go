@@ -0,0 +1,17 @@
+package llm
+
+// groqBaseURL is Groq's OpenAI-compatible API
+const groqBaseURL = "https://api.groq.com/openai/v1"
+
+// GroqClient talks to the Groq API through its OpenAI-compatible endpoint,
+// which supports request contexts and streaming
+type GroqClient struct {
+	*OpenAIClient
+}
+
+func NewGroqClient(cfg Config) *GroqClient {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = groqBaseURL
+	}
+	return &GroqClient{OpenAIClient: NewOpenAIClient(cfg)}
+}
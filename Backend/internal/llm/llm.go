@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	ProviderGroq   = "groq"
+	ProviderOpenAI = "openai" // Any OpenAI-compatible endpoint, e.g. Ollama or llama.cpp
+	ProviderFake   = "fake"
+)
+
+var (
+	ErrEmptyCompletion = errors.New("llm returned no choices")
+	ErrNotConfigured   = errors.New("llm provider is not configured")
+)
+
+// Message is a single chat message sent to the model
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Completion is the model's reply together with its token usage
+type Completion struct {
+	Content          string
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Client generates chat completions. Implementations must honour ctx cancellation.
+type Client interface {
+	Complete(ctx context.Context, messages []Message) (*Completion, error)
+}
+
+// StreamingClient is a Client that can also deliver its reply incrementally.
+// onDelta is called with each new piece of content as it arrives.
+type StreamingClient interface {
+	Client
+	CompleteStream(ctx context.Context, messages []Message, onDelta func(delta string)) (*Completion, error)
+}
+
+// Config selects a provider and the generation settings shared by all of them
+type Config struct {
+	Provider    string
+	BaseURL     string
+	APIKey      string
+	Model       string
+	Temperature float64
+	MaxTokens   int
+}
+
+// NewClient returns the Client for the configured provider
+func NewClient(cfg Config) (Client, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case ProviderGroq, "":
+		if cfg.APIKey == "" {
+			return nil, ErrNotConfigured
+		}
+		return NewGroqClient(cfg), nil
+	case ProviderOpenAI:
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("LLM_BASE_URL is required for the %s provider", ProviderOpenAI)
+		}
+		return NewOpenAIClient(cfg), nil
+	case ProviderFake:
+		return NewFakeClient(), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", cfg.Provider)
+	}
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Segments an admin broadcast can go to
+const (
+	SegmentAll       = "all"
+	SegmentInactive  = "inactive"  // No login or device activity for InactiveDays
+	SegmentExpiring  = "expiring"  // Household has items expiring within ExpiringDays
+	SegmentHousehold = "household" // Members of HouseholdID
+)
+
+const (
+	BroadcastScheduled = "scheduled"
+	BroadcastSending   = "sending"
+	BroadcastSent      = "sent"
+	BroadcastCancelled = "cancelled"
+)
+
+// Per-recipient results of a broadcast
+const (
+	DeliveryDelivered = "delivered"  // Reached at least one of the user's channels
+	DeliveryInboxOnly = "inbox_only" // Only in the in-app inbox, no channel took it
+	DeliveryFailed    = "failed"
+)
+
+// Broadcast is a notification an admin sends to a segment of users, now or at ScheduledAt
+type Broadcast struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Title          string     `gorm:"size:255;not null" json:"title"`
+	Body           string     `gorm:"type:text;not null" json:"body"`
+	Segment        string     `gorm:"size:20;not null" json:"segment"`
+	InactiveDays   int        `json:"inactive_days,omitempty"`
+	ExpiringDays   int        `json:"expiring_days,omitempty"`
+	HouseholdID    uint       `json:"household_id,omitempty"`
+	Status         string     `gorm:"size:20;index;not null" json:"status"`
+	ScheduledAt    time.Time  `gorm:"not null" json:"scheduled_at"`
+	StartedAt      *time.Time `json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at"`
+	RecipientCount int        `json:"recipient_count"`
+	DeliveredCount int        `json:"delivered_count"`
+	InboxOnlyCount int        `json:"inbox_only_count"`
+	FailedCount    int        `json:"failed_count"`
+	CreatedBy      uint       `json:"created_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// BroadcastDelivery reports how a broadcast reached one recipient
+type BroadcastDelivery struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	BroadcastID uint      `gorm:"uniqueIndex:idx_broadcast_deliveries_recipient;not null" json:"broadcast_id"`
+	UserID      uint      `gorm:"uniqueIndex:idx_broadcast_deliveries_recipient;not null" json:"user_id"`
+	Status      string    `gorm:"size:20;not null" json:"status"`
+	Channels    []string  `gorm:"serializer:json;type:text" json:"channels"` // Channels that took it, besides the inbox
+	Error       string    `gorm:"type:text" json:"error,omitempty"`
+	SentAt      time.Time `gorm:"not null" json:"sent_at"`
+}
+
+var (
+	ErrBroadcastNotFound   = errors.New("broadcast not found")
+	ErrInvalidBroadcast    = errors.New("invalid broadcast")
+	ErrBroadcastNotPending = errors.New("broadcast has already started")
+)
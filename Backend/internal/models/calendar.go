@@ -0,0 +1,19 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// CalendarFeed is a user's secret calendar subscription URL. Anyone with the
+// token can read the feed, so users can replace or revoke it.
+type CalendarFeed struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	UserID        uint       `gorm:"uniqueIndex;not null" json:"user_id"`
+	Token         string     `gorm:"size:64;uniqueIndex;not null" json:"token"`
+	LastFetchedAt *time.Time `json:"last_fetched_at"` // Last time a calendar app read the feed
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+var ErrCalendarFeedNotFound = errors.New("calendar feed not found")
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	PlatformAndroid = "android"
+	PlatformIOS     = "ios"
+	PlatformWeb     = "web"
+	PlatformUnknown = "unknown" // Registered through the old single-token endpoint
+)
+
+// Device is one of a user's installations registered for push notifications.
+// A token belongs to the last user who registered it.
+type Device struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     uint      `gorm:"index;not null" json:"user_id"`
+	Token      string    `gorm:"size:512;uniqueIndex;not null" json:"token"`
+	Platform   string    `gorm:"size:20;not null" json:"platform"`
+	AppVersion string    `gorm:"size:50" json:"app_version"`
+	LastSeenAt time.Time `gorm:"not null" json:"last_seen_at"` // Last registration, apps re-register on launch
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+var (
+	ErrDeviceNotFound = errors.New("device not found")
+	ErrInvalidDevice  = errors.New("invalid device")
+)
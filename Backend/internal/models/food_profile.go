@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+type Diet string
+
+const (
+	DietNone       Diet = ""
+	DietVegetarian Diet = "vegetarian"
+	DietVegan      Diet = "vegan"
+	DietHalal      Diet = "halal"
+	DietKosher     Diet = "kosher"
+)
+
+// FoodProfile holds a user's dietary restrictions and kitchen constraints for recipe generation
+type FoodProfile struct {
+	ID                  uint      `gorm:"primaryKey" json:"id"`
+	UserID              uint      `gorm:"uniqueIndex;not null" json:"user_id"`
+	Diet                string    `json:"diet"`
+	Allergens           []string  `gorm:"serializer:json;type:text" json:"allergens"`
+	DislikedIngredients []string  `gorm:"serializer:json;type:text" json:"disliked_ingredients"`
+	HouseholdSize       int       `json:"household_size"`
+	Equipment           []string  `gorm:"serializer:json;type:text" json:"equipment"` // e.g. oven, microwave, air fryer
+	MaxCookTime         int       `json:"max_cook_time"`                              // Prep plus cook time in minutes, 0 for no limit
+	Staples             []string  `gorm:"serializer:json;type:text" json:"staples"`   // Always on hand, e.g. salt, oil, pepper
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
@@ -14,8 +14,9 @@ const (
 
 type GroceryItem struct {
 	ID              uint      `gorm:"primaryKey" json:"id"`
-	UserID          uint      `json:"user_id"`
-	ReceiptID       *uint     `json:"receipt_id,omitempty"` // Make this a pointer to allow null values
+	UserID          uint      `json:"user_id"`                   // Who added the item
+	HouseholdID     uint      `gorm:"index" json:"household_id"` // Whose pantry it is in
+	ReceiptID       *uint     `json:"receipt_id,omitempty"`      // Make this a pointer to allow null values
 	Name            string    `gorm:"not null" json:"name"`
 	Quantity        float64   `gorm:"not null" json:"quantity"`
 	Unit            string    `json:"unit"`
@@ -24,6 +25,7 @@ type GroceryItem struct {
 	ManufactureDate time.Time `json:"manufacture_date"`
 	ExpiryDate      time.Time `json:"expiry_date"`
 	StorageLocation string    `gorm:"not null" json:"storageLocation"`
+	NutritionFoodID *uint     `json:"nutrition_food_id,omitempty"` // Explicit nutrition database link, otherwise matched by name
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 }
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+type HouseholdRole string
+
+// Members can change the household's pantry, viewers can only look at it.
+// The owner also manages who belongs to the household.
+const (
+	RoleOwner  HouseholdRole = "owner"
+	RoleMember HouseholdRole = "member"
+	RoleViewer HouseholdRole = "viewer"
+)
+
+// Household shares one pantry, its receipts, recipes, meal plan and shopping
+// lists between its members. Every user belongs to exactly one household.
+type Household struct {
+	ID        uint              `gorm:"primaryKey" json:"id"`
+	Name      string            `gorm:"not null" json:"name"`
+	OwnerID   uint              `gorm:"index;not null" json:"owner_id"`
+	Members   []HouseholdMember `gorm:"foreignKey:HouseholdID;constraint:OnDelete:CASCADE" json:"members,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+type HouseholdMember struct {
+	ID          uint          `gorm:"primaryKey" json:"id"`
+	HouseholdID uint          `gorm:"index;not null" json:"household_id"`
+	UserID      uint          `gorm:"uniqueIndex;not null" json:"user_id"`
+	User        *User         `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Role        HouseholdRole `gorm:"size:20;not null" json:"role"`
+	Name        string        `gorm:"-" json:"name"`
+	Email       string        `gorm:"-" json:"email"`
+	CreatedAt   time.Time     `json:"joined_at"`
+}
+
+// HouseholdInvite lets someone join a household by entering its code, without
+// needing their email address
+type HouseholdInvite struct {
+	ID          uint          `gorm:"primaryKey" json:"id"`
+	HouseholdID uint          `gorm:"index;not null" json:"household_id"`
+	Household   *Household    `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Code        string        `gorm:"uniqueIndex;size:16;not null" json:"code"`
+	Role        HouseholdRole `gorm:"size:20;not null" json:"role"` // Role given to whoever joins
+	CreatedBy   uint          `json:"created_by"`
+	MaxUses     int           `gorm:"not null;default:1" json:"max_uses"`
+	Uses        int           `gorm:"not null;default:0" json:"uses"`
+	ExpiresAt   time.Time     `json:"expires_at"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+// CanWrite reports whether the role may change the household's data
+func (r HouseholdRole) CanWrite() bool {
+	return r == RoleOwner || r == RoleMember
+}
+
+var (
+	ErrHouseholdNotFound    = errors.New("household not found")
+	ErrNotHouseholdMember   = errors.New("user is not a member of this household")
+	ErrNotHouseholdOwner    = errors.New("only the household owner can do this")
+	ErrInvalidHouseholdRole = errors.New("role must be one of member or viewer")
+	ErrInvalidHouseholdName = errors.New("household name is required")
+	ErrInvalidInvite        = errors.New("invalid invite")
+	ErrInviteInvalid        = errors.New("invite code is invalid or has expired")
+	ErrAlreadyInHousehold   = errors.New("you are already a member of this household")
+	ErrOwnerMustTransfer    = errors.New("transfer ownership to another member before leaving")
+	ErrSoleHouseholdMember  = errors.New("you are the only member of this household")
+)
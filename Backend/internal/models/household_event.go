@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// HouseholdEvent is a change in a household, such as an updated grocery item,
+// kept for a day so live sync clients that reconnect can catch up on what they
+// missed. Seq numbers the household's events without gaps, in the order they
+// were committed, and is what clients resume from.
+type HouseholdEvent struct {
+	ID          uint64    `gorm:"primaryKey" json:"id"`
+	HouseholdID uint      `gorm:"uniqueIndex:idx_household_events_seq,priority:1;not null" json:"household_id"`
+	Seq         uint64    `gorm:"uniqueIndex:idx_household_events_seq,priority:2;not null" json:"seq"`
+	Type        string    `gorm:"size:50;not null" json:"type"`
+	Payload     string    `gorm:"type:text;not null" json:"payload"` // The event as JSON
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`
+}
+
+// HouseholdEventSequence holds the last Seq given to a household's events. It
+// outlives the events themselves, so numbers are never reused.
+type HouseholdEventSequence struct {
+	HouseholdID uint   `gorm:"primaryKey;autoIncrement:false"`
+	Seq         uint64 `gorm:"not null"`
+}
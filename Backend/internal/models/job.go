@@ -0,0 +1,41 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	JobRunRunning     = "running"
+	JobRunSucceeded   = "succeeded"
+	JobRunFailed      = "failed"
+	JobRunInterrupted = "interrupted" // The instance running it stopped before it finished
+)
+
+// ScheduledJob is a background job run by the scheduler. The schedule can be
+// changed at runtime and is shared by all instances of the API.
+type ScheduledJob struct {
+	Name      string     `gorm:"primaryKey;size:100" json:"name"`
+	Schedule  string     `gorm:"size:100;not null" json:"schedule"` // Cron expression, evaluated in UTC
+	Enabled   bool       `gorm:"not null" json:"enabled"`
+	NextRunAt time.Time  `gorm:"not null" json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// JobRun is one run of a scheduled job, kept as run history
+type JobRun struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Job        string     `gorm:"size:100;index:idx_job_runs_job_started;not null" json:"job"`
+	Instance   string     `gorm:"size:255" json:"instance"` // Host and process that ran it
+	Status     string     `gorm:"size:20;not null" json:"status"`
+	Error      string     `gorm:"type:text" json:"error,omitempty"`
+	StartedAt  time.Time  `gorm:"index:idx_job_runs_job_started;not null" json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrInvalidJob  = errors.New("invalid job")
+)
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+type MealType string
+
+const (
+	MealBreakfast MealType = "breakfast"
+	MealLunch     MealType = "lunch"
+	MealDinner    MealType = "dinner"
+	MealSnack     MealType = "snack"
+)
+
+// MealOrder ranks meals within a day
+var MealOrder = map[MealType]int{MealBreakfast: 0, MealLunch: 1, MealDinner: 2, MealSnack: 3}
+
+const (
+	MealPlanPlanned = "planned"
+	MealPlanCooked  = "cooked"
+)
+
+// MealPlanEntry assigns a saved recipe to a date and meal
+type MealPlanEntry struct {
+	ID           uint                `gorm:"primaryKey" json:"id"`
+	UserID       uint                `json:"user_id"` // Who planned the meal
+	HouseholdID  uint                `gorm:"index" json:"household_id"`
+	RecipeID     uint                `gorm:"not null" json:"recipe_id"`
+	Recipe       *Recipe             `gorm:"constraint:OnDelete:CASCADE" json:"recipe,omitempty"`
+	Date         time.Time           `gorm:"type:date;index;not null" json:"date"`
+	Meal         string              `gorm:"not null" json:"meal"` // breakfast, lunch, dinner or snack
+	Servings     int                 `json:"servings"`
+	Status       string              `gorm:"default:planned" json:"status"`               // planned or cooked
+	Shortfalls   []string            `gorm:"serializer:json;type:text" json:"shortfalls"` // Ingredients the pantry couldn't cover when reserving
+	Reservations []PantryReservation `gorm:"foreignKey:MealPlanEntryID;constraint:OnDelete:CASCADE" json:"reservations"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+
+	// Issues with the plan, such as reserved items that expire before the meal
+	Warnings []string `gorm:"-" json:"warnings,omitempty"`
+}
+
+// PantryReservation holds back part of a grocery item for a planned meal
+type PantryReservation struct {
+	ID              uint         `gorm:"primaryKey" json:"id"`
+	UserID          uint         `json:"user_id"`
+	HouseholdID     uint         `gorm:"index" json:"household_id"`
+	MealPlanEntryID uint         `gorm:"index;not null" json:"meal_plan_entry_id"`
+	GroceryItemID   uint         `gorm:"index;not null" json:"grocery_item_id"`
+	GroceryItem     *GroceryItem `gorm:"constraint:OnDelete:CASCADE" json:"grocery_item,omitempty"`
+	Ingredient      string       `json:"ingredient"` // The recipe ingredient the stock is reserved for
+	Quantity        float64      `json:"quantity"`   // In the grocery item's unit
+	CreatedAt       time.Time    `json:"created_at"`
+}
+
+var (
+	ErrMealPlanEntryNotFound = errors.New("meal plan entry not found")
+	ErrInvalidMeal           = errors.New("meal must be one of breakfast, lunch, dinner or snack")
+	ErrMealAlreadyCooked     = errors.New("meal has already been cooked")
+)
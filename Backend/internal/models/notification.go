@@ -0,0 +1,77 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Instant alerts go out as soon as an item reaches one of the lead times, a
+// digest lists everything expiring soon once a day
+const (
+	DeliveryInstant = "instant"
+	DeliveryDigest  = "digest"
+)
+
+// Channels a user can be notified on
+const (
+	ChannelPush    = "push"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+
+	// Every notification lands in the in-app inbox, whatever the user's channels
+	ChannelInbox = "inbox"
+)
+
+// NotificationPreferences control when and how a user hears about expiring items.
+// Times of day are written as HH:MM in the user's time zone.
+type NotificationPreferences struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	UserID          uint       `gorm:"uniqueIndex;not null" json:"user_id"`
+	LeadDays        []int      `gorm:"serializer:json;type:text" json:"lead_days"` // Days before expiry to alert, e.g. 5 and 1
+	Delivery        string     `gorm:"size:20;not null" json:"delivery"`           // instant or digest
+	DigestTime      string     `gorm:"size:5" json:"digest_time"`                  // When the daily digest goes out
+	QuietHoursStart string     `gorm:"size:5" json:"quiet_hours_start"`            // Empty for no quiet hours
+	QuietHoursEnd   string     `gorm:"size:5" json:"quiet_hours_end"`
+	Channels        []string   `gorm:"serializer:json;type:text" json:"channels"` // Enabled channels, empty to mute
+	WebhookURL      string     `gorm:"size:500" json:"webhook_url"`               // Where the webhook channel posts to
+	LastDigestAt    *time.Time `json:"-"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// SentNotification is a ledger entry for an expiry alert delivered to a user on
+// one channel. Each item is alerted at most once per lead time and channel.
+type SentNotification struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	UserID        uint      `gorm:"uniqueIndex:idx_sent_notifications_dedup;not null" json:"user_id"`
+	GroceryItemID uint      `gorm:"uniqueIndex:idx_sent_notifications_dedup;not null" json:"grocery_item_id"`
+	Threshold     int       `gorm:"uniqueIndex:idx_sent_notifications_dedup;not null" json:"threshold"` // Lead time in days the alert was for
+	Channel       string    `gorm:"uniqueIndex:idx_sent_notifications_dedup;size:20;not null" json:"channel"`
+	Kind          string    `gorm:"size:30;not null" json:"kind"` // expiry_alert or expiry_digest
+	ItemName      string    `gorm:"size:255" json:"item_name"`    // Kept for the ledger after the item is gone
+	SentAt        time.Time `gorm:"index;not null" json:"sent_at"`
+}
+
+// InboxNotification is a notification kept for the in-app inbox, so it can be read
+// even when push delivery failed or was never allowed
+type InboxNotification struct {
+	ID        uint              `gorm:"primaryKey" json:"id"`
+	UserID    uint              `gorm:"index:idx_inbox_notifications_user_created;not null" json:"user_id"`
+	Title     string            `gorm:"size:255;not null" json:"title"`
+	Body      string            `gorm:"type:text" json:"body"`
+	Kind      string            `gorm:"size:30;not null" json:"kind"`
+	Data      map[string]string `gorm:"serializer:json;type:text" json:"data"` // Deep-link IDs, e.g. item_ids or recipe_id
+	ReadAt    *time.Time        `json:"read_at"`
+	CreatedAt time.Time         `gorm:"index:idx_inbox_notifications_user_created" json:"created_at"`
+}
+
+// SentNotificationFilter narrows down the ledger; zero values match everything
+type SentNotificationFilter struct {
+	UserID        uint
+	GroceryItemID uint
+	Channel       string
+	Limit         int
+	Offset        int
+}
+
+var ErrInvalidNotificationPreferences = errors.New("invalid notification preferences")
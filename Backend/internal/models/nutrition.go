@@ -0,0 +1,90 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Nutrition holds calories and macronutrients. In the nutrition database it is
+// per 100 g of food; elsewhere it is for the stated amount.
+type Nutrition struct {
+	Calories      float64 `json:"calories"`      // kcal
+	Protein       float64 `json:"protein"`       // g
+	Fat           float64 `json:"fat"`           // g
+	Carbohydrates float64 `json:"carbohydrates"` // g
+	Fiber         float64 `json:"fiber"`         // g
+	Sugar         float64 `json:"sugar"`         // g
+	Sodium        float64 `json:"sodium"`        // mg
+}
+
+// Add returns the sum of two nutrition values
+func (n Nutrition) Add(other Nutrition) Nutrition {
+	return Nutrition{
+		Calories:      n.Calories + other.Calories,
+		Protein:       n.Protein + other.Protein,
+		Fat:           n.Fat + other.Fat,
+		Carbohydrates: n.Carbohydrates + other.Carbohydrates,
+		Fiber:         n.Fiber + other.Fiber,
+		Sugar:         n.Sugar + other.Sugar,
+		Sodium:        n.Sodium + other.Sodium,
+	}
+}
+
+// Scale returns the nutrition multiplied by factor
+func (n Nutrition) Scale(factor float64) Nutrition {
+	return Nutrition{
+		Calories:      n.Calories * factor,
+		Protein:       n.Protein * factor,
+		Fat:           n.Fat * factor,
+		Carbohydrates: n.Carbohydrates * factor,
+		Fiber:         n.Fiber * factor,
+		Sugar:         n.Sugar * factor,
+		Sodium:        n.Sodium * factor,
+	}
+}
+
+// Rounded returns the nutrition rounded to one decimal place for display
+func (n Nutrition) Rounded() Nutrition {
+	round := func(v float64) float64 { return math.Round(v*10) / 10 }
+	return Nutrition{
+		Calories:      round(n.Calories),
+		Protein:       round(n.Protein),
+		Fat:           round(n.Fat),
+		Carbohydrates: round(n.Carbohydrates),
+		Fiber:         round(n.Fiber),
+		Sugar:         round(n.Sugar),
+		Sodium:        round(n.Sodium),
+	}
+}
+
+// NutritionFood is an entry of the offline nutrition database, with nutrition per 100 g
+type NutritionFood struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	Name          string    `gorm:"uniqueIndex;not null" json:"name"` // Lowercase, e.g. "chicken breast"
+	Category      string    `json:"category"`
+	Source        string    `json:"source"`    // e.g. usda-sr-legacy
+	SourceID      string    `json:"source_id"` // ID in the source database, if known
+	Nutrition     Nutrition `gorm:"embedded" json:"nutrition"`
+	GramsPerPiece float64   `json:"grams_per_piece"` // Weight of one piece, clove or slice, 0 when unknown
+	Density       float64   `json:"density"`         // Grams per milliliter, 0 for water-like density
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ConsumptionEntry records pantry stock the user ate, with its nutrition at the time
+type ConsumptionEntry struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	UserID          uint      `gorm:"index;not null" json:"user_id"`
+	GroceryItemID   *uint     `json:"grocery_item_id,omitempty"`
+	NutritionFoodID *uint     `json:"nutrition_food_id,omitempty"` // Nil when the item matched no food
+	Name            string    `gorm:"not null" json:"name"`
+	Quantity        float64   `json:"quantity"`
+	Unit            string    `json:"unit"`
+	Grams           float64   `json:"grams"` // 0 when the quantity couldn't be converted to a weight
+	Nutrition       Nutrition `gorm:"embedded" json:"nutrition"`
+	ConsumedAt      time.Time `gorm:"index" json:"consumed_at"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+var ErrInvalidConsumption = errors.New("quantity must be positive and no more than the item's remaining stock")
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Where a purchase was recorded
+const (
+	PurchaseSourceReceipt      = "receipt"
+	PurchaseSourceShoppingList = "shopping_list"
+)
+
+// PurchaseRecord is one product bought, and whether the pantry already held
+// enough of it at the time
+type PurchaseRecord struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	UserID         uint      `json:"user_id"` // Who bought it
+	HouseholdID    uint      `gorm:"index:idx_purchase_records_household_product" json:"household_id"`
+	Product        string    `gorm:"index:idx_purchase_records_household_product;not null" json:"product"` // Lower-case product name
+	Quantity       float64   `json:"quantity"`
+	Unit           string    `json:"unit"`
+	Source         string    `gorm:"size:20;not null" json:"source"` // receipt or shopping_list
+	ReceiptID      *uint     `json:"receipt_id,omitempty"`
+	OverPurchase   bool      `gorm:"not null;default:false" json:"over_purchase"`
+	PantryQuantity float64   `json:"pantry_quantity"` // Usable stock already held, in Unit
+	CreatedAt      time.Time `gorm:"index" json:"created_at"`
+}
+
+// OverPurchaseWarning flags a product the pantry already holds enough of
+type OverPurchaseWarning struct {
+	Name           string    `json:"name"`
+	Quantity       float64   `json:"quantity"`
+	Unit           string    `json:"unit"`
+	PantryQuantity float64   `json:"pantry_quantity"`
+	PantryUnit     string    `json:"pantry_unit"`
+	ExpiresOn      time.Time `json:"expires_on,omitempty"` // Soonest expiry of the stock held
+	Message        string    `json:"message"`
+}
+
+// OverPurchaseRate sums up how often a product was bought while already in stock
+type OverPurchaseRate struct {
+	Product        string    `json:"product"`
+	Purchases      int64     `json:"purchases"`
+	OverPurchases  int64     `json:"over_purchases"`
+	Rate           float64   `json:"rate"` // Over-purchases per purchase, 0 to 1
+	LastPurchaseAt time.Time `json:"last_purchase_at"`
+}
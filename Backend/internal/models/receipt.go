@@ -7,6 +7,7 @@ import (
 type Receipt struct {
 	ID           uint          `gorm:"primaryKey" json:"id"`
 	UserID       uint          `json:"user_id"`
+	HouseholdID  uint          `gorm:"index" json:"household_id"`
 	ImagePath    string        `gorm:"not null" json:"image_path"`
 	TotalAmount  float64       `json:"total_amount"`
 	StoreName    string        `json:"store_name"`
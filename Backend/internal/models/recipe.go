@@ -1,10 +1,14 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 type Recipe struct {
 	ID           uint   `json:"id" gorm:"primaryKey"`
-	UserID       uint   `json:"user_id"`
+	UserID       *uint  `json:"user_id"`                   // Nil for library recipes and once the user deleted their account
+	HouseholdID  *uint  `gorm:"index" json:"household_id"` // Nil for library recipes
 	Title        string `json:"title"`
 	Ingredients  string `json:"ingredients"`  // Each ingredient on a new line
 	Instructions string `json:"instructions"` // Step-by-step instructions
@@ -13,6 +17,38 @@ type Recipe struct {
 	Servings     int    `json:"servings"`
 	Difficulty   string `json:"difficulty"`
 	Cuisine      string `json:"cuisine"`
+
+	// Zero-waste fields, computed by the service after generation
+	WasteRescuedScore   float64 `json:"waste_rescued_score"`  // 0-100, share of soon-expiring stock the recipe uses
+	ExpiringIngredients string  `json:"expiring_ingredients"` // Soon-expiring pantry items used, one per line
+
+	// User feedback
+	Favorite bool   `gorm:"default:false" json:"favorite"`
+	Rating   int    `gorm:"default:0" json:"rating"` // 1-5, 0 when unrated
+	Notes    string `json:"notes"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Issues found when validating the recipe against the user's pantry and food profile
+	Warnings []string `gorm:"-" json:"warnings,omitempty"`
+
+	// Structured ingredients, filled in for library recipes
+	IngredientList []RecipeIngredient `gorm:"foreignKey:RecipeID" json:"ingredient_list,omitempty"`
+}
+
+type RecipeIngredient struct {
+	ID       uint    `gorm:"primaryKey" json:"id"`
+	RecipeID uint    `gorm:"index" json:"recipe_id"`
+	Name     string  `gorm:"not null" json:"name"`
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+	Optional bool    `gorm:"default:false" json:"optional"` // Not required for a pantry match
+
+	NutritionFoodID *uint `json:"nutrition_food_id,omitempty"` // Explicit nutrition database link, otherwise matched by name
 }
 
-var ErrRecipeNotFound = errors.New("recipe not found")
+var (
+	ErrRecipeNotFound = errors.New("recipe not found")
+	ErrInvalidRating  = errors.New("rating must be between 1 and 5, or 0 to clear it")
+)
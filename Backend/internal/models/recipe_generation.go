@@ -0,0 +1,39 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Sources of a recipe generation
+const (
+	GenerationSourceModel = "model" // The model was called and its tokens count towards the quota
+	GenerationSourceCache = "cache" // Recipes from an earlier generation with the same fingerprint were reused
+)
+
+// RecipeGeneration records one recipe generation request, for caching, quotas and token accounting
+type RecipeGeneration struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	UserID           uint      `gorm:"index;not null" json:"user_id"`
+	Fingerprint      string    `gorm:"index;size:64;not null" json:"fingerprint"` // SHA-256 of the pantry, food profile and cuisine
+	Source           string    `gorm:"size:20;not null" json:"source"`
+	RecipeIDs        []uint    `gorm:"serializer:json;type:text" json:"recipe_ids"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	CreatedAt        time.Time `gorm:"index" json:"created_at"`
+}
+
+// GenerationUsage sums up a user's recipe generations over a period
+type GenerationUsage struct {
+	UserID           uint      `json:"user_id"`
+	Name             string    `json:"name"`
+	Email            string    `json:"email"`
+	Generations      int64     `json:"generations"` // Model calls
+	CacheHits        int64     `json:"cache_hits"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	TotalTokens      int64     `json:"total_tokens"`
+	LastGeneratedAt  time.Time `json:"last_generated_at"`
+}
+
+var ErrGenerationQuotaExceeded = errors.New("daily recipe generation quota exceeded")
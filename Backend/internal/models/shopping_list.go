@@ -0,0 +1,62 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Where a shopping list item came from
+const (
+	ShoppingSourceManual   = "manual"
+	ShoppingSourceRecipe   = "recipe"
+	ShoppingSourceMealPlan = "meal_plan"
+	ShoppingSourceParLevel = "par_level"
+)
+
+type ShoppingList struct {
+	ID          uint               `gorm:"primaryKey" json:"id"`
+	UserID      uint               `json:"user_id"` // Who created the list
+	HouseholdID uint               `gorm:"index" json:"household_id"`
+	Name        string             `gorm:"not null" json:"name"`
+	Items       []ShoppingListItem `gorm:"foreignKey:ShoppingListID;constraint:OnDelete:CASCADE" json:"items"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+type ShoppingListItem struct {
+	ID             uint                 `gorm:"primaryKey" json:"id"`
+	ShoppingListID uint                 `gorm:"index;not null" json:"shopping_list_id"`
+	Name           string               `gorm:"not null" json:"name"`
+	Quantity       float64              `json:"quantity"` // 0 when no amount is known
+	Unit           string               `json:"unit"`
+	Sources        []string             `gorm:"serializer:json;type:text" json:"sources"` // manual, recipe, meal_plan or par_level
+	Checked        bool                 `gorm:"default:false" json:"checked"`
+	CheckedAt      *time.Time           `json:"checked_at,omitempty"`
+	GroceryItemID  *uint                `json:"grocery_item_id,omitempty"`  // Pantry item created when the item was checked off
+	Warning        *OverPurchaseWarning `gorm:"-" json:"warning,omitempty"` // Set when the pantry already holds enough
+	CreatedAt      time.Time            `json:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+}
+
+// ParLevel is the minimum stock the user wants to keep of a product
+type ParLevel struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	UserID          uint      `json:"user_id"` // Who set the par level
+	HouseholdID     uint      `gorm:"uniqueIndex:idx_par_levels_household_name" json:"household_id"`
+	Name            string    `gorm:"uniqueIndex:idx_par_levels_household_name;not null" json:"name"`
+	Quantity        float64   `gorm:"not null" json:"quantity"`
+	Unit            string    `json:"unit"`
+	StorageLocation string    `json:"storage_location"` // Where restocked items go, guessed when empty
+	ShelfLifeDays   int       `json:"shelf_life_days"`  // Used to estimate expiry dates, guessed when 0
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+var (
+	ErrShoppingListNotFound     = errors.New("shopping list not found")
+	ErrShoppingListItemNotFound = errors.New("shopping list item not found")
+	ErrParLevelNotFound         = errors.New("par level not found")
+	ErrInvalidShoppingItem      = errors.New("shopping list items need a name and a quantity that isn't negative")
+	ErrInvalidParLevel          = errors.New("par levels need a name and a positive quantity")
+	ErrInvalidStorageLocation   = errors.New("storage location must be one of deep_freeze, refrigerator or dry_pantry")
+)
@@ -21,14 +21,16 @@ const (
 )
 
 type User struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Name      string    `gorm:"not null" json:"name"`
-	Email     string    `gorm:"unique;not null" json:"email"`
-	Password  string    `gorm:"not null" json:"password"`
-	FCMToken  string    `json:"fcm_token"`
-	IsAdmin   bool      `gorm:"default:false" json:"is_admin"` // New field
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Name        string     `gorm:"not null" json:"name"`
+	Email       string     `gorm:"unique;not null" json:"email"`
+	Password    string     `gorm:"not null" json:"password"`
+	IsAdmin     bool       `gorm:"default:false" json:"is_admin"` // New field
+	LastLoginAt *time.Time `json:"last_login_at"`
+	Locale      string     `gorm:"size:10;not null;default:en" json:"locale"`     // Language of notifications, e.g. de
+	TimeZone    string     `gorm:"size:64;not null;default:UTC" json:"time_zone"` // IANA name, e.g. Europe/Berlin
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
 // HashPassword hashes the user's password using Argon2
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	WebhookDeliveryPending   = "pending" // Waiting for its first or next attempt
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed" // Gave up after the last retry
+)
+
+// Webhook is an endpoint that receives a household's events as signed JSON
+// POSTs. Each request carries an X-Webhook-Signature header of the form
+// t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<body>" keyed with the secret>.
+type Webhook struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"index;not null" json:"user_id"`           // Who registered it
+	HouseholdID *uint     `gorm:"index" json:"household_id"`               // Whose events it gets, nil for every household (admins only)
+	URL         string    `gorm:"size:500;not null" json:"url"`            // http or https
+	Secret      string    `gorm:"size:100;not null" json:"-"`              // Shown once, when the webhook is created
+	Events      []string  `gorm:"serializer:json;type:text" json:"events"` // Event types it subscribes to
+	Description string    `gorm:"size:255" json:"description"`
+	Enabled     bool      `gorm:"not null;default:true" json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery is one event sent, or being retried, to one webhook. The
+// payload is kept so retries send the same body.
+type WebhookDelivery struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	WebhookID      uint       `gorm:"uniqueIndex:idx_webhook_deliveries_event;not null" json:"webhook_id"`
+	EventID        string     `gorm:"size:100;uniqueIndex:idx_webhook_deliveries_event;not null" json:"event_id"`
+	Event          string     `gorm:"size:50;not null" json:"event"`
+	Payload        string     `gorm:"type:text;not null" json:"payload"`
+	Status         string     `gorm:"size:20;index;not null" json:"status"` // pending, succeeded or failed
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	ResponseStatus int        `json:"response_status"` // HTTP status of the last attempt, 0 if there was no response
+	Error          string     `gorm:"type:text" json:"error"`
+	NextAttemptAt  *time.Time `gorm:"index" json:"next_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+	ErrInvalidWebhook  = errors.New("invalid webhook")
+)
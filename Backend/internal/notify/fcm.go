@@ -0,0 +1,113 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/messaging"
+	"google.golang.org/api/option"
+)
+
+// fcmMulticastLimit is the most tokens FCM accepts in one multicast
+const fcmMulticastLimit = 500
+
+// FCMNotifier sends push notifications through Firebase Cloud Messaging to all
+// of a recipient's devices at once
+type FCMNotifier struct {
+	client *messaging.Client
+	prune  func(tokens []string)
+}
+
+// NewFCMNotifier connects to Firebase with the service account key in credentialsFile.
+// prune is called with the tokens FCM reports as unregistered or invalid.
+func NewFCMNotifier(ctx context.Context, projectID, credentialsFile string, prune func(tokens []string)) (*FCMNotifier, error) {
+	if _, err := os.Stat(credentialsFile); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s file not found", credentialsFile)
+	}
+
+	conf := &firebase.Config{ProjectID: projectID}
+	app, err := firebase.NewApp(ctx, conf, option.WithCredentialsFile(credentialsFile))
+	if err != nil {
+		return nil, fmt.Errorf("error initializing Firebase app: %w", err)
+	}
+
+	client, err := app.Messaging(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting Firebase messaging client: %w", err)
+	}
+	return &FCMNotifier{client: client, prune: prune}, nil
+}
+
+// Send succeeds if the message reached at least one device
+func (n *FCMNotifier) Send(ctx context.Context, to Recipient, msg Message) error {
+	if len(to.FCMTokens) == 0 {
+		return ErrNoAddress
+	}
+
+	data := map[string]string{"type": msg.Kind}
+	for key, value := range msg.Data {
+		data[key] = value
+	}
+
+	var delivered int
+	var stale []string
+	var lastErr error
+	for start := 0; start < len(to.FCMTokens); start += fcmMulticastLimit {
+		tokens := to.FCMTokens[start:min(start+fcmMulticastLimit, len(to.FCMTokens))]
+		message := &messaging.MulticastMessage{
+			Tokens: tokens,
+			Notification: &messaging.Notification{
+				Title: msg.Title,
+				Body:  msg.Body,
+			},
+			Data: data,
+			Android: &messaging.AndroidConfig{
+				Priority: "high",
+			},
+			APNS: &messaging.APNSConfig{
+				Headers: map[string]string{
+					"apns-priority": "10",
+				},
+			},
+		}
+
+		response, err := n.client.SendEachForMulticast(ctx, message)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		delivered += response.SuccessCount
+
+		var invalid []string
+		for i, result := range response.Responses {
+			switch {
+			case result.Success:
+			case messaging.IsUnregistered(result.Error), messaging.IsSenderIDMismatch(result.Error):
+				stale = append(stale, tokens[i])
+			case messaging.IsInvalidArgument(result.Error):
+				invalid = append(invalid, tokens[i])
+				lastErr = result.Error
+			default:
+				lastErr = result.Error
+			}
+		}
+		// An invalid argument can also mean a bad message, so only blame the
+		// tokens when the same message reached other devices
+		if response.SuccessCount > 0 {
+			stale = append(stale, invalid...)
+		}
+	}
+
+	if len(stale) > 0 && n.prune != nil {
+		n.prune(stale)
+	}
+	if delivered == 0 {
+		if lastErr == nil {
+			return ErrNoAddress // Every token turned out to be stale
+		}
+		return fmt.Errorf("FCM send failed: %w", lastErr)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package notify
+
+import (
+	"context"
+	"log"
+)
+
+// LogNotifier only logs the messages it is given. It stands in for channels
+// that aren't configured, such as push without Firebase credentials.
+type LogNotifier struct {
+	Channel string
+}
+
+func NewLogNotifier(channel string) *LogNotifier {
+	return &LogNotifier{Channel: channel}
+}
+
+func (n *LogNotifier) Send(ctx context.Context, to Recipient, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	log.Printf("[%s notification, not delivered] user %d: %s - %s", n.Channel, to.UserID, msg.Title, msg.Body)
+	return nil
+}
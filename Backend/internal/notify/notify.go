@@ -0,0 +1,32 @@
+package notify
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoAddress means the recipient has no address on the notifier's channel,
+// e.g. no registered devices for push or no URL for webhooks
+var ErrNoAddress = errors.New("recipient has no address for this channel")
+
+// Message is a notification, independent of the channel it is delivered on
+type Message struct {
+	Title string
+	Body  string
+	Kind  string            // What the notification is about, e.g. expiry_alert
+	Data  map[string]string // Extra key/values for apps and webhooks
+}
+
+// Recipient holds the addresses a user can be reached at
+type Recipient struct {
+	UserID     uint
+	Name       string
+	Email      string
+	FCMTokens  []string // One per registered device
+	WebhookURL string
+}
+
+// Notifier delivers messages on one channel. Implementations must honour ctx cancellation.
+type Notifier interface {
+	Send(ctx context.Context, to Recipient, msg Message) error
+}
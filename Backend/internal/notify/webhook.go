@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrAddressBlocked is returned for webhook URLs on loopback, private or
+// link-local addresses, so webhooks can't be used to reach internal services
+var ErrAddressBlocked = errors.New("webhook address is not public")
+
+// WebhookNotifier POSTs notifications as JSON to the recipient's own URL
+type WebhookNotifier struct {
+	httpClient *http.Client
+}
+
+func NewWebhookNotifier() *WebhookNotifier {
+	return &WebhookNotifier{httpClient: NewWebhookHTTPClient(10 * time.Second)}
+}
+
+// NewWebhookHTTPClient returns a client for user-supplied URLs. It only connects
+// to public addresses, checked after DNS resolution so rebinding can't get
+// around it, ignores proxy settings and doesn't follow redirects.
+func NewWebhookHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !IsPublicAddr(addrPort.Addr()) {
+				return ErrAddressBlocked
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// IsPublicAddr reports whether a webhook may connect to addr
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() && addr.IsGlobalUnicast() && !addr.IsPrivate() && !addr.IsLoopback() && !addr.IsLinkLocalUnicast()
+}
+
+// IsPublicHost reports whether a URL's host may be public. IP literals must be
+// public addresses and localhost is refused; other hostnames are checked again
+// on every request, once resolved.
+func IsPublicHost(u *url.URL) bool {
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return IsPublicAddr(addr)
+	}
+	return host != "" && host != "localhost" && !strings.HasSuffix(host, ".localhost")
+}
+
+type webhookPayload struct {
+	Type   string            `json:"type"`
+	Title  string            `json:"title"`
+	Body   string            `json:"body"`
+	Data   map[string]string `json:"data,omitempty"`
+	UserID uint              `json:"user_id"`
+	SentAt time.Time         `json:"sent_at"`
+}
+
+func (n *WebhookNotifier) Send(ctx context.Context, to Recipient, msg Message) error {
+	if to.WebhookURL == "" {
+		return ErrNoAddress
+	}
+
+	body, err := json.Marshal(webhookPayload{
+		Type:   msg.Kind,
+		Title:  msg.Title,
+		Body:   msg.Body,
+		Data:   msg.Data,
+		UserID: to.UserID,
+		SentAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, to.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if errors.Is(err, ErrAddressBlocked) {
+		return ErrAddressBlocked
+	}
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096)) // Lets the connection be reused
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
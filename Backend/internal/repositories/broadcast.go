@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"zero-waste-kitchen/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BroadcastRepository interface {
+	Create(broadcast *models.Broadcast) error
+	FindAll(limit, offset int) ([]models.Broadcast, int64, error)
+	FindByID(id uint) (*models.Broadcast, error)
+	FindDue(now time.Time) ([]models.Broadcast, error)
+	UpdateStatus(broadcast *models.Broadcast, from ...string) error
+	FindRecipients(broadcast *models.Broadcast, now time.Time) ([]models.User, error)
+	FindDeliveredUserIDs(broadcastID uint) ([]uint, error)
+	CreateDelivery(delivery *models.BroadcastDelivery) error
+	FindDeliveries(broadcastID uint, status string, limit, offset int) ([]models.BroadcastDelivery, int64, error)
+	CountDeliveries(broadcastID uint) (map[string]int, error)
+}
+
+type broadcastRepository struct {
+	db *gorm.DB
+}
+
+func NewBroadcastRepository(db *gorm.DB) BroadcastRepository {
+	return &broadcastRepository{db: db}
+}
+
+func (r *broadcastRepository) Create(broadcast *models.Broadcast) error {
+	return r.db.Create(broadcast).Error
+}
+
+// FindAll retrieves a page of broadcasts, newest first, with the total number of broadcasts
+func (r *broadcastRepository) FindAll(limit, offset int) ([]models.Broadcast, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.Broadcast{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var broadcasts []models.Broadcast
+	if err := r.db.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&broadcasts).Error; err != nil {
+		return nil, 0, err
+	}
+	return broadcasts, total, nil
+}
+
+func (r *broadcastRepository) FindByID(id uint) (*models.Broadcast, error) {
+	var broadcast models.Broadcast
+	if err := r.db.First(&broadcast, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &broadcast, nil
+}
+
+// FindDue retrieves the broadcasts that should be sending: scheduled ones whose
+// time has come and ones interrupted while sending
+func (r *broadcastRepository) FindDue(now time.Time) ([]models.Broadcast, error) {
+	var broadcasts []models.Broadcast
+	err := r.db.Where("status IN ? AND scheduled_at <= ?", []string{models.BroadcastScheduled, models.BroadcastSending}, now).
+		Order("scheduled_at, id").Find(&broadcasts).Error
+	if err != nil {
+		return nil, err
+	}
+	return broadcasts, nil
+}
+
+// UpdateStatus stores a broadcast's status and progress if it is still in one of
+// the from statuses, so a cancel and a send can't overwrite each other. It
+// returns ErrRecordNotFound when the status changed in the meantime.
+func (r *broadcastRepository) UpdateStatus(broadcast *models.Broadcast, from ...string) error {
+	result := r.db.Model(broadcast).Where("status IN ?", from).
+		Select("status", "started_at", "finished_at", "recipient_count", "delivered_count", "inbox_only_count", "failed_count", "updated_at").
+		Updates(broadcast)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// FindRecipients retrieves the users in a broadcast's segment, ordered by ID
+func (r *broadcastRepository) FindRecipients(broadcast *models.Broadcast, now time.Time) ([]models.User, error) {
+	query := r.db.Model(&models.User{})
+
+	switch broadcast.Segment {
+	case models.SegmentInactive:
+		cutoff := now.AddDate(0, 0, -broadcast.InactiveDays)
+		query = query.Where("COALESCE(last_login_at, created_at) < ?", cutoff).
+			Where("NOT EXISTS (?)", r.db.Model(&models.Device{}).Select("1").
+				Where("devices.user_id = users.id AND devices.last_seen_at >= ?", cutoff))
+	case models.SegmentExpiring:
+		households := r.db.Model(&models.GroceryItem{}).Select("household_id").
+			Where("expiry_date > ? AND expiry_date <= ?", now, now.AddDate(0, 0, broadcast.ExpiringDays))
+		query = query.Where("id IN (?)", r.db.Model(&models.HouseholdMember{}).Select("user_id").
+			Where("household_id IN (?)", households))
+	case models.SegmentHousehold:
+		query = query.Where("id IN (?)", r.db.Model(&models.HouseholdMember{}).Select("user_id").
+			Where("household_id = ?", broadcast.HouseholdID))
+	}
+
+	var users []models.User
+	if err := query.Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// FindDeliveredUserIDs retrieves the users a broadcast has already been sent to
+func (r *broadcastRepository) FindDeliveredUserIDs(broadcastID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.BroadcastDelivery{}).Where("broadcast_id = ?", broadcastID).Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// CreateDelivery records how a broadcast reached a recipient, once per recipient
+func (r *broadcastRepository) CreateDelivery(delivery *models.BroadcastDelivery) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(delivery).Error
+}
+
+// FindDeliveries retrieves a page of a broadcast's delivery report, optionally only one status
+func (r *broadcastRepository) FindDeliveries(broadcastID uint, status string, limit, offset int) ([]models.BroadcastDelivery, int64, error) {
+	query := r.db.Model(&models.BroadcastDelivery{}).Where("broadcast_id = ?", broadcastID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []models.BroadcastDelivery
+	if err := query.Order("id").Limit(limit).Offset(offset).Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+// CountDeliveries counts a broadcast's deliveries by status
+func (r *broadcastRepository) CountDeliveries(broadcastID uint) (map[string]int, error) {
+	var rows []struct {
+		Status string
+		Count  int
+	}
+	err := r.db.Model(&models.BroadcastDelivery{}).Select("status, COUNT(*) AS count").
+		Where("broadcast_id = ?", broadcastID).Group("status").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"zero-waste-kitchen/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type CalendarRepository interface {
+	FindByUser(userID uint) (*models.CalendarFeed, error)
+	FindByToken(token string) (*models.CalendarFeed, error)
+	Save(feed *models.CalendarFeed) error
+	MarkFetched(id uint, at time.Time) error
+	Delete(userID uint) error
+}
+
+type calendarRepository struct {
+	db *gorm.DB
+}
+
+func NewCalendarRepository(db *gorm.DB) CalendarRepository {
+	return &calendarRepository{db: db}
+}
+
+func (r *calendarRepository) FindByUser(userID uint) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	if err := r.db.Where("user_id = ?", userID).First(&feed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &feed, nil
+}
+
+func (r *calendarRepository) FindByToken(token string) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	if err := r.db.Where("token = ?", token).First(&feed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &feed, nil
+}
+
+func (r *calendarRepository) Save(feed *models.CalendarFeed) error {
+	return r.db.Save(feed).Error
+}
+
+// MarkFetched records when a calendar app last read the feed, without touching updated_at
+func (r *calendarRepository) MarkFetched(id uint, at time.Time) error {
+	return r.db.Model(&models.CalendarFeed{}).Where("id = ?", id).UpdateColumn("last_fetched_at", at).Error
+}
+
+// Delete revokes the user's feed
+func (r *calendarRepository) Delete(userID uint) error {
+	result := r.db.Where("user_id = ?", userID).Delete(&models.CalendarFeed{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
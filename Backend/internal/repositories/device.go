@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"zero-waste-kitchen/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DeviceRepository interface {
+	Register(device *models.Device) error
+	FindByUser(userID uint) ([]models.Device, error)
+	FindTokens(userID uint) ([]string, error)
+	Delete(userID, id uint) error
+	DeleteTokens(tokens []string) (int64, error)
+}
+
+type deviceRepository struct {
+	db *gorm.DB
+}
+
+func NewDeviceRepository(db *gorm.DB) DeviceRepository {
+	return &deviceRepository{db: db}
+}
+
+// Register inserts a device, or updates the one with the same token and hands it to this user
+func (r *deviceRepository) Register(device *models.Device) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "platform", "app_version", "last_seen_at", "updated_at"}),
+	}).Create(device).Error
+}
+
+// FindByUser retrieves a user's devices, most recently seen first
+func (r *deviceRepository) FindByUser(userID uint) ([]models.Device, error) {
+	var devices []models.Device
+	if err := r.db.Where("user_id = ?", userID).Order("last_seen_at DESC").Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// FindTokens retrieves the push tokens of all of a user's devices
+func (r *deviceRepository) FindTokens(userID uint) ([]string, error) {
+	var tokens []string
+	err := r.db.Model(&models.Device{}).Where("user_id = ?", userID).Pluck("token", &tokens).Error
+	return tokens, err
+}
+
+// Delete removes one of a user's devices
+func (r *deviceRepository) Delete(userID, id uint) error {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Device{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteTokens removes the devices with the given tokens, whoever they belong to
+func (r *deviceRepository) DeleteTokens(tokens []string) (int64, error) {
+	if len(tokens) == 0 {
+		return 0, nil
+	}
+	result := r.db.Where("token IN ?", tokens).Delete(&models.Device{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"errors"
+	"zero-waste-kitchen/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type FoodProfileRepository interface {
+	FindByUserID(userID uint) (*models.FoodProfile, error)
+	Save(profile *models.FoodProfile) error
+}
+
+type foodProfileRepository struct {
+	db *gorm.DB
+}
+
+func NewFoodProfileRepository(db *gorm.DB) FoodProfileRepository {
+	return &foodProfileRepository{db: db}
+}
+
+// FindByUserID retrieves the food profile of a user
+func (r *foodProfileRepository) FindByUserID(userID uint) (*models.FoodProfile, error) {
+	var profile models.FoodProfile
+	if err := r.db.Where("user_id = ?", userID).First(&profile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// Save inserts or updates a food profile
+func (r *foodProfileRepository) Save(profile *models.FoodProfile) error {
+	return r.db.Save(profile).Error
+}
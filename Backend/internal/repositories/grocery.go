@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"errors"
 	"time"
 	"zero-waste-kitchen/internal/models"
 
@@ -9,11 +10,12 @@ import (
 
 type GroceryRepository interface {
 	Create(grocery *models.GroceryItem) error
-	FindByID(id uint, userID uint) (*models.GroceryItem, error)
-	FindAll(userID uint) ([]models.GroceryItem, error)
+	FindByID(id uint, householdID uint) (*models.GroceryItem, error)
+	FindAll(householdID uint) ([]models.GroceryItem, error)
 	Update(grocery *models.GroceryItem) error
 	Delete(id uint) error
-	FindExpiring(userID uint, threshold time.Time) ([]models.GroceryItem, error)
+	FindExpiring(householdID uint, threshold time.Time) ([]models.GroceryItem, error)
+	FindExpiringBetween(from, to time.Time) ([]models.GroceryItem, error)
 }
 
 type groceryRepository struct {
@@ -28,15 +30,20 @@ func (r *groceryRepository) Create(grocery *models.GroceryItem) error {
 	return r.db.Create(grocery).Error
 }
 
-func (r *groceryRepository) FindByID(id uint, userID uint) (*models.GroceryItem, error) {
+func (r *groceryRepository) FindByID(id uint, householdID uint) (*models.GroceryItem, error) {
 	var grocery models.GroceryItem
-	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&grocery).Error
-	return &grocery, err
+	if err := r.db.Where("id = ? AND household_id = ?", id, householdID).First(&grocery).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &grocery, nil
 }
 
-func (r *groceryRepository) FindAll(userID uint) ([]models.GroceryItem, error) {
+func (r *groceryRepository) FindAll(householdID uint) ([]models.GroceryItem, error) {
 	var groceries []models.GroceryItem
-	err := r.db.Where("user_id = ?", userID).Find(&groceries).Error
+	err := r.db.Where("household_id = ?", householdID).Find(&groceries).Error
 	return groceries, err
 }
 
@@ -48,13 +55,30 @@ func (r *groceryRepository) Delete(id uint) error {
 	return r.db.Delete(&models.GroceryItem{}, id).Error
 }
 
-func (r *groceryRepository) FindExpiring(userID uint, threshold time.Time) ([]models.GroceryItem, error) {
+// takeStock takes quantity out of a pantry item within tx, deleting the item once it is used up
+func takeStock(tx *gorm.DB, id uint, quantity float64) error {
+	err := tx.Model(&models.GroceryItem{}).Where("id = ?", id).
+		Update("quantity", gorm.Expr("quantity - ?", quantity)).Error
+	if err != nil {
+		return err
+	}
+	return tx.Where("id = ? AND quantity <= ?", id, 1e-9).Delete(&models.GroceryItem{}).Error
+}
+
+func (r *groceryRepository) FindExpiring(householdID uint, threshold time.Time) ([]models.GroceryItem, error) {
 	var groceries []models.GroceryItem
 	err := r.db.Where(
-		"user_id = ? AND expiry_date <= ? AND expiry_date > ?",
-		userID,
+		"household_id = ? AND expiry_date <= ? AND expiry_date > ?",
+		householdID,
 		threshold,
 		time.Now(),
 	).Find(&groceries).Error
 	return groceries, err
 }
+
+// FindExpiringBetween retrieves the items of every household that expire after from and up to to
+func (r *groceryRepository) FindExpiringBetween(from, to time.Time) ([]models.GroceryItem, error) {
+	var groceries []models.GroceryItem
+	err := r.db.Where("expiry_date > ? AND expiry_date <= ? AND quantity > 0", from, to).Order("expiry_date ASC").Find(&groceries).Error
+	return groceries, err
+}
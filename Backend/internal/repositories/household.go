@@ -0,0 +1,240 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"zero-waste-kitchen/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// householdTables hold data that belongs to a household through household_id
+var householdTables = []string{
+	"grocery_items",
+	"receipts",
+	"recipes",
+	"shopping_lists",
+	"par_levels",
+	"meal_plan_entries",
+	"pantry_reservations",
+	"purchase_records",
+}
+
+type HouseholdRepository interface {
+	FindMembership(userID uint) (*models.HouseholdMember, error)
+	FindByID(id uint) (*models.Household, error)
+	Save(household *models.Household) error
+	SaveMember(member *models.HouseholdMember) error
+	TransferOwnership(householdID uint, fromUserID uint, toUserID uint) error
+	CreateForUser(userID uint, name string) (*models.Household, error)
+	JoinWithInvite(userID uint, invite *models.HouseholdInvite, emptiedHouseholdID uint) error
+	MoveToNewHousehold(userID uint, name string) (*models.Household, error)
+
+	CreateInvite(invite *models.HouseholdInvite) error
+	FindInvites(householdID uint) ([]models.HouseholdInvite, error)
+	FindInviteByCode(code string) (*models.HouseholdInvite, error)
+	DeleteInvite(householdID uint, id uint) error
+}
+
+type householdRepository struct {
+	db *gorm.DB
+}
+
+func NewHouseholdRepository(db *gorm.DB) HouseholdRepository {
+	return &householdRepository{db: db}
+}
+
+// FindMembership retrieves the household membership of a user
+func (r *householdRepository) FindMembership(userID uint) (*models.HouseholdMember, error) {
+	var member models.HouseholdMember
+	if err := r.db.Where("user_id = ?", userID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// FindByID retrieves a household with its members, owner first
+func (r *householdRepository) FindByID(id uint) (*models.Household, error) {
+	var household models.Household
+	err := r.db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("CASE role WHEN 'owner' THEN 0 WHEN 'member' THEN 1 ELSE 2 END, created_at ASC")
+	}).Preload("Members.User").First(&household, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	for i := range household.Members {
+		if user := household.Members[i].User; user != nil {
+			household.Members[i].Name = user.Name
+			household.Members[i].Email = user.Email
+		}
+	}
+	return &household, nil
+}
+
+// Save updates a household, without its members
+func (r *householdRepository) Save(household *models.Household) error {
+	return r.db.Omit("Members").Save(household).Error
+}
+
+// SaveMember updates a household membership
+func (r *householdRepository) SaveMember(member *models.HouseholdMember) error {
+	return r.db.Omit("User").Save(member).Error
+}
+
+// TransferOwnership hands a household from its owner to another member, who
+// becomes its owner, in one transaction. It returns ErrRecordNotFound when
+// either user's membership changed in the meantime.
+func (r *householdRepository) TransferOwnership(householdID uint, fromUserID uint, toUserID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.HouseholdMember{}).
+			Where("household_id = ? AND user_id = ? AND role = ?", householdID, fromUserID, models.RoleOwner).
+			Update("role", models.RoleMember)
+		if result.Error == nil && result.RowsAffected == 1 {
+			result = tx.Model(&models.HouseholdMember{}).
+				Where("household_id = ? AND user_id = ?", householdID, toUserID).
+				Update("role", models.RoleOwner)
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRecordNotFound
+		}
+		return tx.Model(&models.Household{}).Where("id = ?", householdID).Update("owner_id", toUserID).Error
+	})
+}
+
+// CreateForUser creates a household owned by a user who has none yet. Data the
+// user created before households existed is moved into it.
+func (r *householdRepository) CreateForUser(userID uint, name string) (*models.Household, error) {
+	household := &models.Household{Name: name, OwnerID: userID}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Members").Create(household).Error; err != nil {
+			return err
+		}
+		member := &models.HouseholdMember{HouseholdID: household.ID, UserID: userID, Role: models.RoleOwner}
+		if err := tx.Omit("User").Create(member).Error; err != nil {
+			return err
+		}
+		for _, table := range householdTables {
+			if err := tx.Table(table).Where("user_id = ? AND household_id IS NULL", userID).Update("household_id", household.ID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return household, nil
+}
+
+// JoinWithInvite uses up one use of an invite and moves a user into its household
+// with the invite's role, in one transaction. When emptiedHouseholdID is set, the
+// user was its last member, so its data moves along and the household is deleted.
+// It returns ErrRecordNotFound when the invite was used up or expired in the meantime.
+func (r *householdRepository) JoinWithInvite(userID uint, invite *models.HouseholdInvite, emptiedHouseholdID uint) error {
+	toHouseholdID := invite.HouseholdID
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.HouseholdInvite{}).
+			Where("id = ? AND uses < max_uses AND expires_at > ?", invite.ID, time.Now()).
+			Update("uses", gorm.Expr("uses + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRecordNotFound
+		}
+
+		err := tx.Model(&models.HouseholdMember{}).Where("user_id = ?", userID).
+			Updates(map[string]interface{}{"household_id": toHouseholdID, "role": invite.Role, "created_at": time.Now()}).Error
+		if err != nil {
+			return err
+		}
+		if emptiedHouseholdID == 0 {
+			return nil
+		}
+
+		// Par levels are unique per product, so the joined household's win
+		err = tx.Where("household_id = ? AND name IN (?)", emptiedHouseholdID,
+			tx.Model(&models.ParLevel{}).Select("name").Where("household_id = ?", toHouseholdID),
+		).Delete(&models.ParLevel{}).Error
+		if err != nil {
+			return err
+		}
+		for _, table := range householdTables {
+			if err := tx.Table(table).Where("household_id = ?", emptiedHouseholdID).Update("household_id", toHouseholdID).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("household_id = ?", emptiedHouseholdID).Delete(&models.HouseholdInvite{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Household{}, emptiedHouseholdID).Error
+	})
+}
+
+// MoveToNewHousehold takes a user out of their household into a new, empty one they own
+func (r *householdRepository) MoveToNewHousehold(userID uint, name string) (*models.Household, error) {
+	household := &models.Household{Name: name, OwnerID: userID}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Members").Create(household).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.HouseholdMember{}).Where("user_id = ?", userID).
+			Updates(map[string]interface{}{"household_id": household.ID, "role": models.RoleOwner, "created_at": time.Now()}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return household, nil
+}
+
+// CreateInvite saves a new invite code
+func (r *householdRepository) CreateInvite(invite *models.HouseholdInvite) error {
+	return r.db.Omit("Household").Create(invite).Error
+}
+
+// FindInvites retrieves a household's invites that can still be used, newest first
+func (r *householdRepository) FindInvites(householdID uint) ([]models.HouseholdInvite, error) {
+	var invites []models.HouseholdInvite
+	err := r.db.Where("household_id = ? AND uses < max_uses AND expires_at > ?", householdID, time.Now()).
+		Order("created_at DESC").
+		Find(&invites).Error
+	if err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+// FindInviteByCode retrieves an invite that can still be used by its code
+func (r *householdRepository) FindInviteByCode(code string) (*models.HouseholdInvite, error) {
+	var invite models.HouseholdInvite
+	err := r.db.Where("code = ? AND uses < max_uses AND expires_at > ?", code, time.Now()).First(&invite).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// DeleteInvite revokes one of the household's invites
+func (r *householdRepository) DeleteInvite(householdID uint, id uint) error {
+	result := r.db.Where("id = ? AND household_id = ?", id, householdID).Delete(&models.HouseholdInvite{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
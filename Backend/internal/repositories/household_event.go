@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"time"
+	"zero-waste-kitchen/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type HouseholdEventRepository interface {
+	Create(event *models.HouseholdEvent) error
+	FindAfter(householdID uint, afterSeq uint64, limit int) ([]models.HouseholdEvent, error)
+	Exists(householdID uint, seq uint64) (bool, error)
+	LatestSeq(householdID uint) (uint64, error)
+	DeleteBefore(before time.Time) (int64, error)
+}
+
+type householdEventRepository struct {
+	db *gorm.DB
+}
+
+func NewHouseholdEventRepository(db *gorm.DB) HouseholdEventRepository {
+	return &householdEventRepository{db: db}
+}
+
+// Create saves an event with the household's next Seq. The household's sequence
+// row stays locked until the event is committed, so events become visible in
+// Seq order and a reader never skips one that commits late.
+func (r *householdEventRepository) Create(event *models.HouseholdEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Raw(`INSERT INTO household_event_sequences (household_id, seq) VALUES (?, 1)
+			ON CONFLICT (household_id) DO UPDATE SET seq = household_event_sequences.seq + 1
+			RETURNING seq`, event.HouseholdID).Scan(&event.Seq).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(event).Error
+	})
+}
+
+// FindAfter retrieves a household's events that came after afterSeq, oldest first
+func (r *householdEventRepository) FindAfter(householdID uint, afterSeq uint64, limit int) ([]models.HouseholdEvent, error) {
+	var householdEvents []models.HouseholdEvent
+	err := r.db.Where("household_id = ? AND seq > ?", householdID, afterSeq).Order("seq ASC").Limit(limit).Find(&householdEvents).Error
+	return householdEvents, err
+}
+
+// Exists reports whether an event of the household is still kept
+func (r *householdEventRepository) Exists(householdID uint, seq uint64) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.HouseholdEvent{}).Where("household_id = ? AND seq = ?", householdID, seq).Count(&count).Error
+	return count > 0, err
+}
+
+// LatestSeq returns the Seq of the household's newest event, 0 if it never had one
+func (r *householdEventRepository) LatestSeq(householdID uint) (uint64, error) {
+	var seq uint64
+	err := r.db.Model(&models.HouseholdEventSequence{}).Where("household_id = ?", householdID).Select("COALESCE(MAX(seq), 0)").Scan(&seq).Error
+	return seq, err
+}
+
+// DeleteBefore removes events created before a time
+func (r *householdEventRepository) DeleteBefore(before time.Time) (int64, error) {
+	result := r.db.Where("created_at < ?", before).Delete(&models.HouseholdEvent{})
+	return result.RowsAffected, result.Error
+}
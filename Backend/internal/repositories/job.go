@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"zero-waste-kitchen/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobRepository interface {
+	EnsureJob(job *models.ScheduledJob) error
+	FindJobs() ([]models.ScheduledJob, error)
+	FindJob(name string) (*models.ScheduledJob, error)
+	FindDueJobs(now time.Time) ([]models.ScheduledJob, error)
+	SaveJob(job *models.ScheduledJob) error
+	CreateRun(run *models.JobRun) error
+	SaveRun(run *models.JobRun) error
+	InterruptRuns(job string, at time.Time) error
+	FindRuns(job string, limit int) ([]models.JobRun, error)
+	DeleteRunsBefore(before time.Time) (int64, error)
+	TryLock(ctx context.Context, key int64) (unlock func(), locked bool, err error)
+}
+
+type jobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+// EnsureJob inserts a job unless it already exists, keeping any schedule set at runtime
+func (r *jobRepository) EnsureJob(job *models.ScheduledJob) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(job).Error
+}
+
+func (r *jobRepository) FindJobs() ([]models.ScheduledJob, error) {
+	var jobs []models.ScheduledJob
+	if err := r.db.Order("name").Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *jobRepository) FindJob(name string) (*models.ScheduledJob, error) {
+	var job models.ScheduledJob
+	if err := r.db.Where("name = ?", name).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FindDueJobs retrieves the enabled jobs whose next run is at or before now
+func (r *jobRepository) FindDueJobs(now time.Time) ([]models.ScheduledJob, error) {
+	var jobs []models.ScheduledJob
+	if err := r.db.Where("enabled AND next_run_at <= ?", now).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *jobRepository) SaveJob(job *models.ScheduledJob) error {
+	return r.db.Save(job).Error
+}
+
+func (r *jobRepository) CreateRun(run *models.JobRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *jobRepository) SaveRun(run *models.JobRun) error {
+	return r.db.Save(run).Error
+}
+
+// InterruptRuns marks a job's runs that are still running as interrupted
+func (r *jobRepository) InterruptRuns(job string, at time.Time) error {
+	return r.db.Model(&models.JobRun{}).
+		Where("job = ? AND status = ?", job, models.JobRunRunning).
+		Updates(map[string]interface{}{"status": models.JobRunInterrupted, "finished_at": at}).Error
+}
+
+// FindRuns retrieves a job's most recent runs, newest first
+func (r *jobRepository) FindRuns(job string, limit int) ([]models.JobRun, error) {
+	var runs []models.JobRun
+	if err := r.db.Where("job = ?", job).Order("started_at DESC, id DESC").Limit(limit).Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// DeleteRunsBefore removes finished runs that started before the given time
+func (r *jobRepository) DeleteRunsBefore(before time.Time) (int64, error) {
+	result := r.db.Where("started_at < ? AND status <> ?", before, models.JobRunRunning).Delete(&models.JobRun{})
+	return result.RowsAffected, result.Error
+}
+
+// TryLock takes a Postgres session advisory lock without waiting. The lock is
+// held on a connection of its own until unlock is called, and is released by
+// Postgres if this process dies.
+func (r *jobRepository) TryLock(ctx context.Context, key int64) (func(), bool, error) {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return nil, false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked); err != nil || !locked {
+		conn.Close()
+		return nil, false, err
+	}
+
+	unlock := func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key); err != nil {
+			log.Printf("Failed to release advisory lock %d: %v", key, err)
+		}
+		conn.Close()
+	}
+	return unlock, true, nil
+}
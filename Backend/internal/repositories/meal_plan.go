@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"zero-waste-kitchen/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StockAllocator picks the reservations of a meal plan entry from the household's
+// pantry and the stock already reserved for its other entries, and describes
+// what the pantry can't cover
+type StockAllocator func(groceries []models.GroceryItem, reserved map[uint]float64) ([]models.PantryReservation, []string)
+
+type MealPlanRepository interface {
+	Save(entry *models.MealPlanEntry) error
+	FindByID(householdID uint, id uint) (*models.MealPlanEntry, error)
+	FindRange(householdID uint, from, to time.Time) ([]models.MealPlanEntry, error)
+	Delete(id uint) error
+	Reserve(entry *models.MealPlanEntry, allocate StockAllocator) error
+	Cook(entry *models.MealPlanEntry) error
+	ReservedQuantities(householdID uint, excludeEntryID uint) (map[uint]float64, error)
+}
+
+type mealPlanRepository struct {
+	db *gorm.DB
+}
+
+func NewMealPlanRepository(db *gorm.DB) MealPlanRepository {
+	return &mealPlanRepository{db: db}
+}
+
+// Save inserts or updates a meal plan entry, without its reservations
+func (r *mealPlanRepository) Save(entry *models.MealPlanEntry) error {
+	return r.db.Omit("Recipe", "Reservations").Save(entry).Error
+}
+
+// FindByID retrieves a meal plan entry of the household with its recipe and reserved stock
+func (r *mealPlanRepository) FindByID(householdID uint, id uint) (*models.MealPlanEntry, error) {
+	var entry models.MealPlanEntry
+	err := r.db.Preload("Recipe.IngredientList").Preload("Reservations.GroceryItem").
+		Where("id = ? AND household_id = ?", id, householdID).
+		First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// FindRange retrieves the household's meal plan entries between from and to inclusive, by date
+func (r *mealPlanRepository) FindRange(householdID uint, from, to time.Time) ([]models.MealPlanEntry, error) {
+	var entries []models.MealPlanEntry
+	err := r.db.Preload("Recipe.IngredientList").Preload("Reservations.GroceryItem").
+		Where("household_id = ? AND date >= ? AND date <= ?", householdID, from, to).
+		Order("date ASC, id ASC").
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Delete deletes a meal plan entry and its reservations
+func (r *mealPlanRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("meal_plan_entry_id = ?", id).Delete(&models.PantryReservation{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.MealPlanEntry{}, id).Error
+	})
+}
+
+// Reserve swaps the stock reserved for a planned entry and saves its date, meal,
+// servings and shortfalls, in one transaction. The household's grocery rows are
+// locked while allocating, so concurrent reservations can't hand out the same
+// stock twice. It returns ErrRecordNotFound when the entry was cooked or deleted
+// in the meantime.
+func (r *mealPlanRepository) Reserve(entry *models.MealPlanEntry, allocate StockAllocator) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var groceries []models.GroceryItem
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("household_id = ?", entry.HouseholdID).
+			Order("id ASC").
+			Find(&groceries).Error
+		if err != nil {
+			return err
+		}
+		reserved, err := reservedQuantities(tx, entry.HouseholdID, entry.ID)
+		if err != nil {
+			return err
+		}
+
+		reservations, shortfalls := allocate(groceries, reserved)
+		entry.Shortfalls = shortfalls
+
+		result := tx.Model(entry).Where("status <> ?", models.MealPlanCooked).
+			Select("date", "meal", "servings", "shortfalls", "updated_at").
+			Updates(entry)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRecordNotFound
+		}
+
+		if err := tx.Where("meal_plan_entry_id = ?", entry.ID).Delete(&models.PantryReservation{}).Error; err != nil {
+			return err
+		}
+		if len(reservations) == 0 {
+			return nil
+		}
+		return tx.Omit("GroceryItem").Create(&reservations).Error
+	})
+}
+
+// Cook marks a planned entry as cooked, takes its reserved stock out of the pantry
+// and releases the reservations, in one transaction. It returns ErrRecordNotFound
+// when the entry was cooked in the meantime.
+func (r *mealPlanRepository) Cook(entry *models.MealPlanEntry) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.MealPlanEntry{}).
+			Where("id = ? AND status <> ?", entry.ID, models.MealPlanCooked).
+			Updates(map[string]interface{}{"status": models.MealPlanCooked, "shortfalls": "[]"})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRecordNotFound
+		}
+
+		for _, reservation := range entry.Reservations {
+			// Items removed from the pantry since are skipped
+			if err := takeStock(tx, reservation.GroceryItemID, reservation.Quantity); err != nil {
+				return err
+			}
+		}
+		return tx.Where("meal_plan_entry_id = ?", entry.ID).Delete(&models.PantryReservation{}).Error
+	})
+}
+
+// ReservedQuantities sums the household's reserved stock per grocery item, leaving out one entry's own reservations
+func (r *mealPlanRepository) ReservedQuantities(householdID uint, excludeEntryID uint) (map[uint]float64, error) {
+	return reservedQuantities(r.db, householdID, excludeEntryID)
+}
+
+func reservedQuantities(tx *gorm.DB, householdID uint, excludeEntryID uint) (map[uint]float64, error) {
+	var rows []struct {
+		GroceryItemID uint
+		Quantity      float64
+	}
+	err := tx.Model(&models.PantryReservation{}).
+		Select("grocery_item_id, SUM(quantity) AS quantity").
+		Where("household_id = ? AND meal_plan_entry_id <> ?", householdID, excludeEntryID).
+		Group("grocery_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	reserved := make(map[uint]float64, len(rows))
+	for _, row := range rows {
+		reserved[row.GroceryItemID] = row.Quantity
+	}
+	return reserved, nil
+}
@@ -0,0 +1,144 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"zero-waste-kitchen/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository interface {
+	FindPreferences(userID uint) (*models.NotificationPreferences, error)
+	FindAllPreferences() ([]models.NotificationPreferences, error)
+	SavePreferences(prefs *models.NotificationPreferences) error
+	FindSentForItems(userID uint, itemIDs []uint) ([]models.SentNotification, error)
+	RecordSent(entries []models.SentNotification) error
+	FindSent(filter models.SentNotificationFilter) ([]models.SentNotification, int64, error)
+	CreateInbox(notification *models.InboxNotification) error
+	FindInbox(userID uint, unreadOnly bool, limit, offset int) ([]models.InboxNotification, int64, error)
+	CountUnread(userID uint) (int64, error)
+	MarkRead(userID uint, ids []uint, at time.Time) (int64, error)
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// FindPreferences retrieves the notification preferences of a user
+func (r *notificationRepository) FindPreferences(userID uint) (*models.NotificationPreferences, error) {
+	var prefs models.NotificationPreferences
+	if err := r.db.Where("user_id = ?", userID).First(&prefs).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+// FindAllPreferences retrieves every user's saved notification preferences
+func (r *notificationRepository) FindAllPreferences() ([]models.NotificationPreferences, error) {
+	var prefs []models.NotificationPreferences
+	if err := r.db.Find(&prefs).Error; err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+// SavePreferences inserts or updates notification preferences
+func (r *notificationRepository) SavePreferences(prefs *models.NotificationPreferences) error {
+	return r.db.Save(prefs).Error
+}
+
+// FindSentForItems retrieves the ledger entries of a user for the given items
+func (r *notificationRepository) FindSentForItems(userID uint, itemIDs []uint) ([]models.SentNotification, error) {
+	var sent []models.SentNotification
+	if len(itemIDs) == 0 {
+		return sent, nil
+	}
+	if err := r.db.Where("user_id = ? AND grocery_item_id IN ?", userID, itemIDs).Find(&sent).Error; err != nil {
+		return nil, err
+	}
+	return sent, nil
+}
+
+// RecordSent adds entries to the ledger, skipping any that are already there
+func (r *notificationRepository) RecordSent(entries []models.SentNotification) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&entries).Error
+}
+
+// FindSent retrieves a page of the ledger, newest first, with the total number of matching entries
+func (r *notificationRepository) FindSent(filter models.SentNotificationFilter) ([]models.SentNotification, int64, error) {
+	query := r.db.Model(&models.SentNotification{})
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.GroceryItemID != 0 {
+		query = query.Where("grocery_item_id = ?", filter.GroceryItemID)
+	}
+	if filter.Channel != "" {
+		query = query.Where("channel = ?", filter.Channel)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var sent []models.SentNotification
+	if err := query.Order("sent_at DESC, id DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&sent).Error; err != nil {
+		return nil, 0, err
+	}
+	return sent, total, nil
+}
+
+// CreateInbox adds a notification to a user's inbox
+func (r *notificationRepository) CreateInbox(notification *models.InboxNotification) error {
+	return r.db.Create(notification).Error
+}
+
+// FindInbox retrieves a page of a user's inbox, newest first, with the total number of matching notifications
+func (r *notificationRepository) FindInbox(userID uint, unreadOnly bool, limit, offset int) ([]models.InboxNotification, int64, error) {
+	query := r.db.Model(&models.InboxNotification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var notifications []models.InboxNotification
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&notifications).Error; err != nil {
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
+
+// CountUnread counts the notifications a user hasn't read yet
+func (r *notificationRepository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.InboxNotification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// MarkRead marks the given unread notifications of a user as read, or all of them
+// when ids is empty, and returns how many changed
+func (r *notificationRepository) MarkRead(userID uint, ids []uint, at time.Time) (int64, error) {
+	query := r.db.Model(&models.InboxNotification{}).Where("user_id = ? AND read_at IS NULL", userID)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	result := query.Update("read_at", at)
+	return result.RowsAffected, result.Error
+}
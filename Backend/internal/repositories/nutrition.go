@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"zero-waste-kitchen/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NutritionRepository interface {
+	FindAll() ([]models.NutritionFood, error)
+	FindByID(id uint) (*models.NutritionFood, error)
+	Search(query string, limit int) ([]models.NutritionFood, error)
+	Count() (int64, error)
+	Upsert(foods []models.NutritionFood) error
+	Consume(entry *models.ConsumptionEntry) error
+	FindConsumptionSince(userID uint, since time.Time) ([]models.ConsumptionEntry, error)
+}
+
+type nutritionRepository struct {
+	db *gorm.DB
+}
+
+func NewNutritionRepository(db *gorm.DB) NutritionRepository {
+	return &nutritionRepository{db: db}
+}
+
+// FindAll retrieves the whole nutrition database
+func (r *nutritionRepository) FindAll() ([]models.NutritionFood, error) {
+	var foods []models.NutritionFood
+	if err := r.db.Order("name ASC").Find(&foods).Error; err != nil {
+		return nil, err
+	}
+	return foods, nil
+}
+
+// FindByID retrieves a nutrition database entry
+func (r *nutritionRepository) FindByID(id uint) (*models.NutritionFood, error) {
+	var food models.NutritionFood
+	if err := r.db.First(&food, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &food, nil
+}
+
+// Search finds foods whose name contains the query, shortest names first
+func (r *nutritionRepository) Search(query string, limit int) ([]models.NutritionFood, error) {
+	var foods []models.NutritionFood
+	pattern := "%" + strings.ToLower(strings.TrimSpace(query)) + "%"
+	if err := r.db.Where("name LIKE ?", pattern).Order("LENGTH(name), name").Limit(limit).Find(&foods).Error; err != nil {
+		return nil, err
+	}
+	return foods, nil
+}
+
+// Count returns the number of foods in the nutrition database
+func (r *nutritionRepository) Count() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.NutritionFood{}).Count(&count).Error
+	return count, err
+}
+
+// Upsert inserts foods, replacing the values of existing foods with the same name
+func (r *nutritionRepository) Upsert(foods []models.NutritionFood) error {
+	if len(foods) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"category", "source", "source_id", "calories", "protein", "fat", "carbohydrates",
+			"fiber", "sugar", "sodium", "grams_per_piece", "density", "updated_at",
+		}),
+	}).CreateInBatches(foods, 500).Error
+}
+
+// Consume records consumed pantry stock and takes it out of the pantry item, in one transaction
+func (r *nutritionRepository) Consume(entry *models.ConsumptionEntry) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		if entry.GroceryItemID == nil {
+			return nil
+		}
+		return takeStock(tx, *entry.GroceryItemID, entry.Quantity)
+	})
+}
+
+// FindConsumptionSince retrieves the user's consumption after since, oldest first
+func (r *nutritionRepository) FindConsumptionSince(userID uint, since time.Time) ([]models.ConsumptionEntry, error) {
+	var entries []models.ConsumptionEntry
+	if err := r.db.Where("user_id = ? AND consumed_at >= ?", userID, since).Order("consumed_at ASC").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
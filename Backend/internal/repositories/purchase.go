@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"time"
+	"zero-waste-kitchen/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type PurchaseRepository interface {
+	CreateRecords(records []models.PurchaseRecord) error
+	RatesSince(householdID uint, since time.Time) ([]models.OverPurchaseRate, error)
+}
+
+type purchaseRepository struct {
+	db *gorm.DB
+}
+
+func NewPurchaseRepository(db *gorm.DB) PurchaseRepository {
+	return &purchaseRepository{db: db}
+}
+
+// CreateRecords saves purchase records
+func (r *purchaseRepository) CreateRecords(records []models.PurchaseRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	return r.db.Create(&records).Error
+}
+
+// RatesSince sums up the household's purchases per product after since, most
+// over-purchased first
+func (r *purchaseRepository) RatesSince(householdID uint, since time.Time) ([]models.OverPurchaseRate, error) {
+	var rates []models.OverPurchaseRate
+	err := r.db.Model(&models.PurchaseRecord{}).
+		Select(`product,
+			COUNT(*) AS purchases,
+			COUNT(*) FILTER (WHERE over_purchase) AS over_purchases,
+			COUNT(*) FILTER (WHERE over_purchase)::float / COUNT(*) AS rate,
+			MAX(created_at) AS last_purchase_at`).
+		Where("household_id = ? AND created_at >= ?", householdID, since).
+		Group("product").
+		Order("over_purchases DESC, rate DESC, product").
+		Scan(&rates).Error
+	if err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
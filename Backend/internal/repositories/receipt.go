@@ -8,8 +8,8 @@ import (
 
 type ReceiptRepository interface {
 	Create(receipt *models.Receipt) error
-	FindByID(id uint, userID uint) (*models.Receipt, error)
-	FindAll(userID uint) ([]models.Receipt, error)
+	FindByID(id uint, householdID uint) (*models.Receipt, error)
+	FindAll(householdID uint) ([]models.Receipt, error)
 }
 
 type receiptRepository struct {
@@ -24,14 +24,14 @@ func (r *receiptRepository) Create(receipt *models.Receipt) error {
 	return r.db.Create(receipt).Error
 }
 
-func (r *receiptRepository) FindByID(id uint, userID uint) (*models.Receipt, error) {
+func (r *receiptRepository) FindByID(id uint, householdID uint) (*models.Receipt, error) {
 	var receipt models.Receipt
-	err := r.db.Preload("Items").Where("id = ? AND user_id = ?", id, userID).First(&receipt).Error
+	err := r.db.Preload("Items").Where("id = ? AND household_id = ?", id, householdID).First(&receipt).Error
 	return &receipt, err
 }
 
-func (r *receiptRepository) FindAll(userID uint) ([]models.Receipt, error) {
+func (r *receiptRepository) FindAll(householdID uint) ([]models.Receipt, error) {
 	var receipts []models.Receipt
-	err := r.db.Preload("Items").Where("user_id = ?", userID).Find(&receipts).Error
+	err := r.db.Preload("Items").Where("household_id = ?", householdID).Find(&receipts).Error
 	return receipts, err
 }
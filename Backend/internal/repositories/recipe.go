@@ -11,8 +11,9 @@ var ErrRecordNotFound = errors.New("record not found")
 
 type RecipeRepository interface {
 	Save(recipe *models.Recipe) error
-	FindByUserID(userID uint) ([]models.Recipe, error)
-	FindByID(userID uint, recipeID uint) (*models.Recipe, error)
+	FindByHouseholdID(householdID uint) ([]models.Recipe, error)
+	FindFeedbackByHouseholdID(householdID uint) ([]models.Recipe, error)
+	FindByID(householdID uint, recipeID uint) (*models.Recipe, error)
 	FindAll() ([]models.Recipe, error)
 	DeleteByID(id uint) error
 }
@@ -33,10 +34,10 @@ func (r *recipeRepository) Save(recipe *models.Recipe) error {
 	return nil
 }
 
-// FindByID retrieves a recipe by its ID and user ID
-func (r *recipeRepository) FindByID(userID uint, recipeID uint) (*models.Recipe, error) {
+// FindByID retrieves a recipe by its ID and household ID, with its structured ingredients
+func (r *recipeRepository) FindByID(householdID uint, recipeID uint) (*models.Recipe, error) {
 	var recipe models.Recipe
-	if err := r.db.Where("id = ? AND user_id = ?", recipeID, userID).First(&recipe).Error; err != nil {
+	if err := r.db.Preload("IngredientList").Where("id = ? AND household_id = ?", recipeID, householdID).First(&recipe).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrRecordNotFound
 		}
@@ -45,19 +46,28 @@ func (r *recipeRepository) FindByID(userID uint, recipeID uint) (*models.Recipe,
 	return &recipe, nil
 }
 
-// FindByUserID retrieves all recipes for a specific user
-func (r *recipeRepository) FindByUserID(userID uint) ([]models.Recipe, error) {
+// FindByHouseholdID retrieves all recipes saved by a household
+func (r *recipeRepository) FindByHouseholdID(householdID uint) ([]models.Recipe, error) {
 	var recipes []models.Recipe
-	if err := r.db.Where("user_id = ?", userID).Find(&recipes).Error; err != nil {
+	if err := r.db.Where("household_id = ?", householdID).Find(&recipes).Error; err != nil {
 		return nil, err
 	}
 	return recipes, nil
 }
 
-// FindAll retrieves all recipes from the database
+// FindFeedbackByHouseholdID retrieves a household's favorite or rated recipes, most recently updated first
+func (r *recipeRepository) FindFeedbackByHouseholdID(householdID uint) ([]models.Recipe, error) {
+	var recipes []models.Recipe
+	if err := r.db.Where("household_id = ? AND (favorite OR rating > 0)", householdID).Order("updated_at DESC").Find(&recipes).Error; err != nil {
+		return nil, err
+	}
+	return recipes, nil
+}
+
+// FindAll retrieves all library recipes, which belong to no household, with their structured ingredients
 func (r *recipeRepository) FindAll() ([]models.Recipe, error) {
 	var recipes []models.Recipe
-	if err := r.db.Find(&recipes).Error; err != nil {
+	if err := r.db.Preload("IngredientList").Where("household_id IS NULL").Find(&recipes).Error; err != nil {
 		return nil, err
 	}
 	return recipes, nil
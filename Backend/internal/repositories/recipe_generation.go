@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"zero-waste-kitchen/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type RecipeGenerationRepository interface {
+	Save(generation *models.RecipeGeneration) error
+	FindLatestByFingerprint(userID uint, fingerprint string, since time.Time) (*models.RecipeGeneration, error)
+	CountModelCallsSince(userID uint, since time.Time) (int64, error)
+	UsageSince(since time.Time) ([]models.GenerationUsage, error)
+}
+
+type recipeGenerationRepository struct {
+	db *gorm.DB
+}
+
+func NewRecipeGenerationRepository(db *gorm.DB) RecipeGenerationRepository {
+	return &recipeGenerationRepository{db: db}
+}
+
+// Save records a recipe generation
+func (r *recipeGenerationRepository) Save(generation *models.RecipeGeneration) error {
+	return r.db.Save(generation).Error
+}
+
+// FindLatestByFingerprint retrieves the user's most recent model generation with
+// the given fingerprint that was made after since
+func (r *recipeGenerationRepository) FindLatestByFingerprint(userID uint, fingerprint string, since time.Time) (*models.RecipeGeneration, error) {
+	var generation models.RecipeGeneration
+	err := r.db.Where("user_id = ? AND fingerprint = ? AND source = ? AND created_at >= ?", userID, fingerprint, models.GenerationSourceModel, since).
+		Order("created_at DESC").
+		First(&generation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &generation, nil
+}
+
+// CountModelCallsSince counts the user's generations that called the model after since
+func (r *recipeGenerationRepository) CountModelCallsSince(userID uint, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.RecipeGeneration{}).
+		Where("user_id = ? AND source = ? AND created_at >= ?", userID, models.GenerationSourceModel, since).
+		Count(&count).Error
+	return count, err
+}
+
+// UsageSince sums up generations and token usage per user after since, heaviest users first
+func (r *recipeGenerationRepository) UsageSince(since time.Time) ([]models.GenerationUsage, error) {
+	var usage []models.GenerationUsage
+	err := r.db.Table("recipe_generations AS g").
+		Select(`g.user_id, u.name, u.email,
+			COUNT(*) FILTER (WHERE g.source = ?) AS generations,
+			COUNT(*) FILTER (WHERE g.source = ?) AS cache_hits,
+			COALESCE(SUM(g.prompt_tokens), 0) AS prompt_tokens,
+			COALESCE(SUM(g.completion_tokens), 0) AS completion_tokens,
+			COALESCE(SUM(g.prompt_tokens + g.completion_tokens), 0) AS total_tokens,
+			MAX(g.created_at) AS last_generated_at`, models.GenerationSourceModel, models.GenerationSourceCache).
+		Joins("JOIN users u ON u.id = g.user_id").
+		Where("g.created_at >= ?", since).
+		Group("g.user_id, u.name, u.email").
+		Order("total_tokens DESC, g.user_id").
+		Scan(&usage).Error
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"errors"
+	"zero-waste-kitchen/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type ShoppingListRepository interface {
+	FindAll(householdID uint) ([]models.ShoppingList, error)
+	FindByID(householdID uint, id uint) (*models.ShoppingList, error)
+	Save(list *models.ShoppingList) error
+	Delete(id uint) error
+	SaveItem(item *models.ShoppingListItem) error
+	DeleteItem(id uint) error
+
+	FindParLevels(householdID uint) ([]models.ParLevel, error)
+	FindParLevelByName(householdID uint, name string) (*models.ParLevel, error)
+	SaveParLevel(level *models.ParLevel) error
+	DeleteParLevel(householdID uint, id uint) error
+}
+
+type shoppingListRepository struct {
+	db *gorm.DB
+}
+
+func NewShoppingListRepository(db *gorm.DB) ShoppingListRepository {
+	return &shoppingListRepository{db: db}
+}
+
+// FindAll retrieves the household's shopping lists with their items, newest first
+func (r *shoppingListRepository) FindAll(householdID uint) ([]models.ShoppingList, error) {
+	var lists []models.ShoppingList
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("checked ASC, id ASC") }).
+		Where("household_id = ?", householdID).
+		Order("created_at DESC").
+		Find(&lists).Error
+	if err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+// FindByID retrieves one of the household's shopping lists with its items
+func (r *shoppingListRepository) FindByID(householdID uint, id uint) (*models.ShoppingList, error) {
+	var list models.ShoppingList
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("checked ASC, id ASC") }).
+		Where("id = ? AND household_id = ?", id, householdID).
+		First(&list).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &list, nil
+}
+
+// Save inserts or updates a shopping list. New lists are created with their items.
+func (r *shoppingListRepository) Save(list *models.ShoppingList) error {
+	if list.ID == 0 {
+		return r.db.Create(list).Error
+	}
+	return r.db.Omit("Items").Save(list).Error
+}
+
+// Delete deletes a shopping list and its items
+func (r *shoppingListRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("shopping_list_id = ?", id).Delete(&models.ShoppingListItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.ShoppingList{}, id).Error
+	})
+}
+
+// SaveItem inserts or updates a shopping list item
+func (r *shoppingListRepository) SaveItem(item *models.ShoppingListItem) error {
+	return r.db.Save(item).Error
+}
+
+// DeleteItem deletes a shopping list item
+func (r *shoppingListRepository) DeleteItem(id uint) error {
+	return r.db.Delete(&models.ShoppingListItem{}, id).Error
+}
+
+// FindParLevels retrieves the household's par levels by name
+func (r *shoppingListRepository) FindParLevels(householdID uint) ([]models.ParLevel, error) {
+	var levels []models.ParLevel
+	if err := r.db.Where("household_id = ?", householdID).Order("name ASC").Find(&levels).Error; err != nil {
+		return nil, err
+	}
+	return levels, nil
+}
+
+// FindParLevelByName retrieves the household's par level for a product
+func (r *shoppingListRepository) FindParLevelByName(householdID uint, name string) (*models.ParLevel, error) {
+	var level models.ParLevel
+	if err := r.db.Where("household_id = ? AND name = ?", householdID, name).First(&level).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &level, nil
+}
+
+// SaveParLevel inserts or updates a par level
+func (r *shoppingListRepository) SaveParLevel(level *models.ParLevel) error {
+	return r.db.Save(level).Error
+}
+
+// DeleteParLevel deletes one of the household's par levels
+func (r *shoppingListRepository) DeleteParLevel(householdID uint, id uint) error {
+	result := r.db.Where("id = ? AND household_id = ?", id, householdID).Delete(&models.ParLevel{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
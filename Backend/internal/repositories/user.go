@@ -9,9 +9,9 @@ import (
 type UserRepository interface {
 	Create(user *models.User) error
 	FindByID(id uint) (*models.User, error)
+	FindAll() ([]models.User, error)
 	FindByEmail(email string) (*models.User, error)
 	Update(user *models.User) error
-	UpdateFCMToken(userID uint, token string) error
 }
 
 type userRepository struct {
@@ -32,6 +32,12 @@ func (r *userRepository) FindByID(id uint) (*models.User, error) {
 	return &user, err
 }
 
+func (r *userRepository) FindAll() ([]models.User, error) {
+	var users []models.User
+	err := r.db.Find(&users).Error
+	return users, err
+}
+
 func (r *userRepository) FindByEmail(email string) (*models.User, error) {
 	var user models.User
 	err := r.db.Where("email = ?", email).First(&user).Error
@@ -41,7 +47,3 @@ func (r *userRepository) FindByEmail(email string) (*models.User, error) {
 func (r *userRepository) Update(user *models.User) error {
 	return r.db.Save(user).Error
 }
-
-func (r *userRepository) UpdateFCMToken(userID uint, token string) error {
-	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("fcm_token", token).Error
-}
@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"zero-waste-kitchen/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository interface {
+	Create(webhook *models.Webhook) error
+	Save(webhook *models.Webhook) error
+	FindByUser(userID uint) ([]models.Webhook, error)
+	FindByID(id uint) (*models.Webhook, error)
+	FindSubscribers(householdID uint) ([]models.Webhook, error)
+	Delete(userID, id uint) error
+
+	CreateDelivery(delivery *models.WebhookDelivery) (bool, error)
+	FindDelivery(id uint) (*models.WebhookDelivery, error)
+	FindDueDeliveries(now time.Time, limit int) ([]uint, error)
+	ClaimDelivery(id uint, now, lease time.Time) (bool, error)
+	SaveDelivery(delivery *models.WebhookDelivery) error
+	FindDeliveries(webhookID uint, limit, offset int) ([]models.WebhookDelivery, int64, error)
+	DeleteDeliveriesBefore(before time.Time) (int64, error)
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) Create(webhook *models.Webhook) error {
+	return r.db.Create(webhook).Error
+}
+
+func (r *webhookRepository) Save(webhook *models.Webhook) error {
+	return r.db.Save(webhook).Error
+}
+
+// FindByUser retrieves the webhooks a user registered, oldest first
+func (r *webhookRepository) FindByUser(userID uint) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	if err := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *webhookRepository) FindByID(id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := r.db.First(&webhook, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// FindSubscribers retrieves the enabled webhooks that get a household's events,
+// including those that get every household's. A household's webhook only counts
+// while the user who registered it is still a member.
+func (r *webhookRepository) FindSubscribers(householdID uint) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	members := r.db.Model(&models.HouseholdMember{}).Select("user_id").Where("household_id = ?", householdID)
+	err := r.db.Where("enabled = ?", true).
+		Where(r.db.Where("household_id = ? AND user_id IN (?)", householdID, members).Or("household_id IS NULL")).
+		Find(&webhooks).Error
+	return webhooks, err
+}
+
+// Delete removes one of a user's webhooks along with its delivery log
+func (r *webhookRepository) Delete(userID, id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Webhook{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRecordNotFound
+		}
+		return tx.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error
+	})
+}
+
+// CreateDelivery queues an event for a webhook. It reports false, and creates
+// nothing, if the webhook already has that event.
+func (r *webhookRepository) CreateDelivery(delivery *models.WebhookDelivery) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(delivery)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *webhookRepository) FindDelivery(id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := r.db.First(&delivery, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// FindDueDeliveries retrieves the IDs of pending deliveries whose next attempt is due, oldest first
+func (r *webhookRepository) FindDueDeliveries(now time.Time, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.WebhookDelivery{}).
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ClaimDelivery takes a due delivery for an attempt by pushing its next attempt
+// out to lease, so no one else attempts it meanwhile. It reports false if the
+// delivery isn't due anymore.
+func (r *webhookRepository) ClaimDelivery(id uint, now, lease time.Time) (bool, error) {
+	result := r.db.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", id, models.WebhookDeliveryPending, now).
+		Update("next_attempt_at", lease)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *webhookRepository) SaveDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Save(delivery).Error
+}
+
+// FindDeliveries retrieves a page of a webhook's delivery log, newest first, and the total count
+func (r *webhookRepository) FindDeliveries(webhookID uint, limit, offset int) ([]models.WebhookDelivery, int64, error) {
+	query := r.db.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+// DeleteDeliveriesBefore removes finished deliveries created before a time
+func (r *webhookRepository) DeleteDeliveriesBefore(before time.Time) (int64, error) {
+	result := r.db.Where("created_at < ? AND status <> ?", before, models.WebhookDeliveryPending).Delete(&models.WebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC) // A Thursday
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, 1, 1, 0, 15, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2026, 1, 1, 0, 5, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 1, 1, 1, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1-5", time.Date(2026, 1, 1, 9, 30, 0, 0, time.UTC)},
+		{"0 8,20 * * *", time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 2 *", time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either may match, so the Friday comes first
+		{"0 0 13 * 5", time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+		// A day field starting with * isn't restricted, so both must match:
+		// an odd day that is a Monday
+		{"0 0 */2 * 1", time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)},
+		// The 1st on a Sunday, Tuesday, Thursday or Saturday
+		{"0 0 1 * */2", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q) failed: %v", tt.expr, err)
+			}
+			if got := schedule.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+		"0 0 30 2 *", // Never runs
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := ParseSchedule(expr); !errors.Is(err, ErrInvalidSchedule) {
+				t.Errorf("ParseSchedule(%q) = %v, want ErrInvalidSchedule", expr, err)
+			}
+		})
+	}
+}
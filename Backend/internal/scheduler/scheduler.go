@@ -0,0 +1,234 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/repositories"
+)
+
+const (
+	pollInterval = 30 * time.Second
+	// stopGrace is how long cancelled jobs get to record their result on shutdown
+	stopGrace = 5 * time.Second
+)
+
+// Job is work the scheduler runs on a cron schedule. Run should return soon
+// after ctx is cancelled.
+type Job struct {
+	Name     string
+	Schedule string // Default schedule, used until it is changed at runtime
+	Run      func(ctx context.Context, now time.Time) error
+}
+
+// Scheduler runs jobs on the schedules stored in the database. Every instance
+// of the API runs one; a Postgres advisory lock per job makes sure only one of
+// them runs each due job, and that instance moves the job's next run forward.
+type Scheduler struct {
+	repo     repositories.JobRepository
+	jobs     map[string]Job
+	instance string
+
+	cancel  context.CancelFunc
+	stop    chan struct{}
+	done    chan struct{}
+	running sync.WaitGroup
+}
+
+func New(repo repositories.JobRepository, jobs ...Job) *Scheduler {
+	byName := make(map[string]Job, len(jobs))
+	for _, job := range jobs {
+		byName[job.Name] = job
+	}
+
+	host, _ := os.Hostname()
+	return &Scheduler{
+		repo:     repo,
+		jobs:     byName,
+		instance: fmt.Sprintf("%s:%d", host, os.Getpid()),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start registers the jobs that aren't in the database yet and starts running
+// due jobs in the background until Stop is called
+func (s *Scheduler) Start() error {
+	now := time.Now()
+	for _, job := range s.jobs {
+		schedule, err := ParseSchedule(job.Schedule)
+		if err != nil {
+			return fmt.Errorf("job %s: %w", job.Name, err)
+		}
+		err = s.repo.EnsureJob(&models.ScheduledJob{
+			Name:      job.Name,
+			Schedule:  job.Schedule,
+			Enabled:   true,
+			NextRunAt: schedule.Next(now),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to register job %s: %w", job.Name, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	go s.loop(ctx)
+
+	log.Printf("Scheduler started with %d jobs on %s", len(s.jobs), s.instance)
+	return nil
+}
+
+// Stop stops starting new runs and waits for running jobs to finish. If ctx
+// ends first, running jobs are cancelled and get a moment to record their result.
+func (s *Scheduler) Stop(ctx context.Context) {
+	close(s.stop)
+	<-s.done
+
+	finished := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		log.Println("Scheduler shutdown timed out, cancelling running jobs")
+		s.cancel()
+		select {
+		case <-finished:
+		case <-time.After(stopGrace):
+			log.Println("Scheduler stopped with jobs still running")
+		}
+	}
+	s.cancel()
+}
+
+func (s *Scheduler) loop(ctx context.Context) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.startDueJobs(ctx)
+
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) startDueJobs(ctx context.Context) {
+	due, err := s.repo.FindDueJobs(time.Now())
+	if err != nil {
+		log.Printf("Scheduler failed to find due jobs: %v", err)
+		return
+	}
+
+	for _, scheduled := range due {
+		job, ok := s.jobs[scheduled.Name]
+		if !ok {
+			continue // Registered by another version of the API
+		}
+		s.running.Add(1)
+		go func() {
+			defer s.running.Done()
+			s.runJob(ctx, job)
+		}()
+	}
+}
+
+// runJob runs a due job if no other instance, or earlier run here, holds its lock
+func (s *Scheduler) runJob(ctx context.Context, job Job) {
+	unlock, locked, err := s.repo.TryLock(ctx, lockKey(job.Name))
+	if err != nil {
+		log.Printf("Scheduler failed to lock job %s: %v", job.Name, err)
+		return
+	}
+	if !locked {
+		return
+	}
+	defer unlock()
+
+	// Another instance may have run it between finding it due and taking the lock
+	scheduled, err := s.repo.FindJob(job.Name)
+	if err != nil {
+		log.Printf("Scheduler failed to get job %s: %v", job.Name, err)
+		return
+	}
+	now := time.Now()
+	if !scheduled.Enabled || scheduled.NextRunAt.After(now) {
+		return
+	}
+	schedule, err := ParseSchedule(scheduled.Schedule)
+	if err != nil {
+		log.Printf("Job %s has an invalid schedule: %v", job.Name, err)
+		return
+	}
+
+	// Holding the lock means no earlier run is still going
+	if err := s.repo.InterruptRuns(job.Name, now); err != nil {
+		log.Printf("Scheduler failed to close earlier runs of job %s: %v", job.Name, err)
+	}
+
+	// Missed runs, e.g. while every instance was down, are not made up for
+	scheduled.NextRunAt = schedule.Next(now)
+	scheduled.LastRunAt = &now
+	if err := s.repo.SaveJob(scheduled); err != nil {
+		log.Printf("Scheduler failed to update job %s: %v", job.Name, err)
+		return
+	}
+
+	run := &models.JobRun{Job: job.Name, Instance: s.instance, Status: models.JobRunRunning, StartedAt: now}
+	if err := s.repo.CreateRun(run); err != nil {
+		log.Printf("Scheduler failed to record run of job %s: %v", job.Name, err)
+	}
+
+	err = safeRun(ctx, job, now)
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	switch {
+	case err == nil:
+		run.Status = models.JobRunSucceeded
+	case errors.Is(err, context.Canceled):
+		run.Status = models.JobRunInterrupted
+		run.Error = err.Error()
+	default:
+		run.Status = models.JobRunFailed
+		run.Error = err.Error()
+		log.Printf("Job %s failed: %v", job.Name, err)
+	}
+	if err := s.repo.SaveRun(run); err != nil {
+		log.Printf("Scheduler failed to record result of job %s: %v", job.Name, err)
+	}
+}
+
+// safeRun runs a job, turning a panic into an error so the scheduler keeps going
+func safeRun(ctx context.Context, job Job, now time.Time) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job %s panicked: %v\n%s", job.Name, r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run(ctx, now)
+}
+
+// lockKey maps a job name to the advisory lock key shared by all instances
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("scheduler:" + name))
+	return int64(h.Sum64())
+}
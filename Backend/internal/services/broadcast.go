@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/notify"
+	"zero-waste-kitchen/internal/repositories"
+)
+
+const (
+	maxBroadcastTitle    = 255
+	maxBroadcastBody     = 2000
+	defaultInactiveDays  = 30
+	maxInactiveDays      = 365
+	defaultExpiringDays  = 3
+	maxBroadcastLeadTime = 365 * 24 * time.Hour
+)
+
+var broadcastSegments = []string{models.SegmentAll, models.SegmentInactive, models.SegmentExpiring, models.SegmentHousehold}
+
+// BroadcastInput describes a new broadcast. Only the day count or household of
+// the chosen segment is used; a nil ScheduledAt sends it right away.
+type BroadcastInput struct {
+	Title        string
+	Body         string
+	Segment      string
+	InactiveDays int
+	ExpiringDays int
+	HouseholdID  uint
+	ScheduledAt  *time.Time
+}
+
+type BroadcastService interface {
+	CreateBroadcast(adminID uint, input BroadcastInput) (*models.Broadcast, error)
+	GetBroadcasts(limit, offset int) ([]models.Broadcast, int64, error)
+	GetBroadcast(id uint) (*models.Broadcast, error)
+	CancelBroadcast(id uint) (*models.Broadcast, error)
+	GetDeliveries(id uint, status string, limit, offset int) ([]models.BroadcastDelivery, int64, error)
+	SendDueBroadcasts(ctx context.Context, now time.Time) error
+}
+
+type broadcastService struct {
+	repo                repositories.BroadcastRepository
+	householdRepo       repositories.HouseholdRepository
+	notificationService NotificationService
+	sendInterval        time.Duration
+}
+
+// NewBroadcastService sends broadcasts to at most ratePerMinute recipients a minute
+func NewBroadcastService(repo repositories.BroadcastRepository, householdRepo repositories.HouseholdRepository, notificationService NotificationService, ratePerMinute int) BroadcastService {
+	if ratePerMinute <= 0 {
+		ratePerMinute = 1
+	}
+	return &broadcastService{
+		repo:                repo,
+		householdRepo:       householdRepo,
+		notificationService: notificationService,
+		sendInterval:        time.Minute / time.Duration(ratePerMinute),
+	}
+}
+
+// CreateBroadcast validates a broadcast and schedules it. The scheduler picks it
+// up within a minute of its time.
+func (s *broadcastService) CreateBroadcast(adminID uint, input BroadcastInput) (*models.Broadcast, error) {
+	broadcast := &models.Broadcast{
+		Title:       strings.TrimSpace(input.Title),
+		Body:        strings.TrimSpace(input.Body),
+		Segment:     strings.ToLower(strings.TrimSpace(input.Segment)),
+		Status:      models.BroadcastScheduled,
+		ScheduledAt: time.Now(),
+		CreatedBy:   adminID,
+	}
+	if broadcast.Title == "" || len(broadcast.Title) > maxBroadcastTitle {
+		return nil, fmt.Errorf("%w: title must be 1 to %d characters", models.ErrInvalidBroadcast, maxBroadcastTitle)
+	}
+	if broadcast.Body == "" || len(broadcast.Body) > maxBroadcastBody {
+		return nil, fmt.Errorf("%w: body must be 1 to %d characters", models.ErrInvalidBroadcast, maxBroadcastBody)
+	}
+
+	switch broadcast.Segment {
+	case models.SegmentAll:
+	case models.SegmentInactive:
+		broadcast.InactiveDays = input.InactiveDays
+		if broadcast.InactiveDays == 0 {
+			broadcast.InactiveDays = defaultInactiveDays
+		}
+		if broadcast.InactiveDays < 1 || broadcast.InactiveDays > maxInactiveDays {
+			return nil, fmt.Errorf("%w: inactive_days must be between 1 and %d", models.ErrInvalidBroadcast, maxInactiveDays)
+		}
+	case models.SegmentExpiring:
+		broadcast.ExpiringDays = input.ExpiringDays
+		if broadcast.ExpiringDays == 0 {
+			broadcast.ExpiringDays = defaultExpiringDays
+		}
+		if broadcast.ExpiringDays < 1 || broadcast.ExpiringDays > maxLeadDays {
+			return nil, fmt.Errorf("%w: expiring_days must be between 1 and %d", models.ErrInvalidBroadcast, maxLeadDays)
+		}
+	case models.SegmentHousehold:
+		if _, err := s.householdRepo.FindByID(input.HouseholdID); err != nil {
+			if errors.Is(err, repositories.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: household %d not found", models.ErrInvalidBroadcast, input.HouseholdID)
+			}
+			return nil, fmt.Errorf("failed to get household: %w", err)
+		}
+		broadcast.HouseholdID = input.HouseholdID
+	default:
+		return nil, fmt.Errorf("%w: segment must be one of %s", models.ErrInvalidBroadcast, strings.Join(broadcastSegments, ", "))
+	}
+
+	if input.ScheduledAt != nil && input.ScheduledAt.After(broadcast.ScheduledAt) {
+		if input.ScheduledAt.Sub(broadcast.ScheduledAt) > maxBroadcastLeadTime {
+			return nil, fmt.Errorf("%w: scheduled_at must be within a year", models.ErrInvalidBroadcast)
+		}
+		broadcast.ScheduledAt = *input.ScheduledAt
+	}
+
+	if err := s.repo.Create(broadcast); err != nil {
+		return nil, fmt.Errorf("failed to create broadcast: %w", err)
+	}
+	return broadcast, nil
+}
+
+func (s *broadcastService) GetBroadcasts(limit, offset int) ([]models.Broadcast, int64, error) {
+	broadcasts, total, err := s.repo.FindAll(limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get broadcasts: %w", err)
+	}
+	return broadcasts, total, nil
+}
+
+// GetBroadcast returns a broadcast, with live counts while it is sending
+func (s *broadcastService) GetBroadcast(id uint) (*models.Broadcast, error) {
+	broadcast, err := s.getBroadcast(id)
+	if err != nil {
+		return nil, err
+	}
+	if broadcast.Status == models.BroadcastSending {
+		if err := s.countDeliveries(broadcast); err != nil {
+			return nil, err
+		}
+	}
+	return broadcast, nil
+}
+
+// CancelBroadcast stops a broadcast that hasn't started sending yet
+func (s *broadcastService) CancelBroadcast(id uint) (*models.Broadcast, error) {
+	broadcast, err := s.getBroadcast(id)
+	if err != nil {
+		return nil, err
+	}
+	if broadcast.Status != models.BroadcastScheduled {
+		return nil, models.ErrBroadcastNotPending
+	}
+
+	broadcast.Status = models.BroadcastCancelled
+	err = s.repo.UpdateStatus(broadcast, models.BroadcastScheduled)
+	if errors.Is(err, repositories.ErrRecordNotFound) {
+		return nil, models.ErrBroadcastNotPending // Started sending in the meantime
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel broadcast: %w", err)
+	}
+	return broadcast, nil
+}
+
+// GetDeliveries returns a page of a broadcast's per-recipient delivery report
+func (s *broadcastService) GetDeliveries(id uint, status string, limit, offset int) ([]models.BroadcastDelivery, int64, error) {
+	if _, err := s.getBroadcast(id); err != nil {
+		return nil, 0, err
+	}
+	deliveries, total, err := s.repo.FindDeliveries(id, status, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get broadcast deliveries: %w", err)
+	}
+	return deliveries, total, nil
+}
+
+// SendDueBroadcasts sends the broadcasts whose time has come. It runs as a
+// scheduled job; a broadcast cut short by shutdown resumes with the recipients
+// it hadn't reached.
+func (s *broadcastService) SendDueBroadcasts(ctx context.Context, now time.Time) error {
+	due, err := s.repo.FindDue(now)
+	if err != nil {
+		return fmt.Errorf("failed to get due broadcasts: %w", err)
+	}
+
+	for i := range due {
+		if err := s.send(ctx, &due[i]); err != nil {
+			return fmt.Errorf("broadcast %d: %w", due[i].ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *broadcastService) send(ctx context.Context, broadcast *models.Broadcast) error {
+	if broadcast.StartedAt == nil {
+		started := time.Now()
+		broadcast.StartedAt = &started
+		broadcast.Status = models.BroadcastSending
+	}
+
+	// The segment is taken as of the start, so a resumed broadcast keeps its audience
+	recipients, err := s.repo.FindRecipients(broadcast, *broadcast.StartedAt)
+	if err != nil {
+		return fmt.Errorf("failed to get recipients: %w", err)
+	}
+	broadcast.RecipientCount = len(recipients)
+	err = s.repo.UpdateStatus(broadcast, models.BroadcastScheduled, models.BroadcastSending)
+	if errors.Is(err, repositories.ErrRecordNotFound) {
+		return nil // Cancelled in the meantime
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save broadcast: %w", err)
+	}
+
+	sentIDs, err := s.repo.FindDeliveredUserIDs(broadcast.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get broadcast deliveries: %w", err)
+	}
+	sent := make(map[uint]bool, len(sentIDs))
+	for _, id := range sentIDs {
+		sent[id] = true
+	}
+
+	msg := notify.Message{
+		Title: broadcast.Title,
+		Body:  broadcast.Body,
+		Kind:  "broadcast",
+		Data:  map[string]string{"broadcast_id": strconv.FormatUint(uint64(broadcast.ID), 10)},
+	}
+
+	limiter := time.NewTicker(s.sendInterval)
+	defer limiter.Stop()
+	for _, user := range recipients {
+		if sent[user.ID] {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-limiter.C:
+		}
+
+		delivery := &models.BroadcastDelivery{BroadcastID: broadcast.ID, UserID: user.ID, Status: models.DeliveryInboxOnly, SentAt: time.Now()}
+		channels, err := s.notificationService.Notify(user.ID, msg)
+		if err != nil {
+			delivery.Status = models.DeliveryFailed
+			delivery.Error = err.Error()
+		}
+		for _, channel := range channels {
+			if channel != models.ChannelInbox {
+				delivery.Channels = append(delivery.Channels, channel)
+				delivery.Status = models.DeliveryDelivered
+			}
+		}
+		if err := s.repo.CreateDelivery(delivery); err != nil {
+			log.Printf("Failed to record broadcast %d delivery to user %d: %v", broadcast.ID, user.ID, err)
+		}
+	}
+
+	if err := s.countDeliveries(broadcast); err != nil {
+		return err
+	}
+	finished := time.Now()
+	broadcast.FinishedAt = &finished
+	broadcast.Status = models.BroadcastSent
+	// ErrRecordNotFound means another run already finished it
+	if err := s.repo.UpdateStatus(broadcast, models.BroadcastSending); err != nil && !errors.Is(err, repositories.ErrRecordNotFound) {
+		return fmt.Errorf("failed to save broadcast: %w", err)
+	}
+	return nil
+}
+
+func (s *broadcastService) countDeliveries(broadcast *models.Broadcast) error {
+	counts, err := s.repo.CountDeliveries(broadcast.ID)
+	if err != nil {
+		return fmt.Errorf("failed to count broadcast deliveries: %w", err)
+	}
+	broadcast.DeliveredCount = counts[models.DeliveryDelivered]
+	broadcast.InboxOnlyCount = counts[models.DeliveryInboxOnly]
+	broadcast.FailedCount = counts[models.DeliveryFailed]
+	return nil
+}
+
+func (s *broadcastService) getBroadcast(id uint) (*models.Broadcast, error) {
+	broadcast, err := s.repo.FindByID(id)
+	if errors.Is(err, repositories.ErrRecordNotFound) {
+		return nil, models.ErrBroadcastNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get broadcast: %w", err)
+	}
+	return broadcast, nil
+}
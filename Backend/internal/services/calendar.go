@@ -0,0 +1,208 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"zero-waste-kitchen/internal/i18n"
+	"zero-waste-kitchen/internal/ical"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/repositories"
+)
+
+const (
+	calendarTokenBytes = 32
+	calendarProdID     = "-//Zero Waste Kitchen//Pantry Calendar//EN"
+	calendarRefresh    = time.Hour
+
+	// Planned meals this far back and ahead are in the feed
+	calendarPastDays   = 30
+	calendarFutureDays = 90
+
+	// Alarms are relative to the start of the event's day
+	expiryAlarm = -15 * time.Hour // 9:00 the day before
+	mealAlarm   = -15 * time.Hour // 9:00 the day before, in time to thaw or shop
+)
+
+type CalendarService interface {
+	GetFeed(userID uint) (*models.CalendarFeed, error)
+	RotateFeed(userID uint) (*models.CalendarFeed, error)
+	DeleteFeed(userID uint) error
+	RenderFeed(token string, now time.Time) ([]byte, error)
+}
+
+type calendarService struct {
+	repo             repositories.CalendarRepository
+	userRepo         repositories.UserRepository
+	groceryRepo      repositories.GroceryRepository
+	mealPlanRepo     repositories.MealPlanRepository
+	householdService HouseholdService
+}
+
+func NewCalendarService(repo repositories.CalendarRepository, userRepo repositories.UserRepository, groceryRepo repositories.GroceryRepository, mealPlanRepo repositories.MealPlanRepository, householdService HouseholdService) CalendarService {
+	return &calendarService{
+		repo:             repo,
+		userRepo:         userRepo,
+		groceryRepo:      groceryRepo,
+		mealPlanRepo:     mealPlanRepo,
+		householdService: householdService,
+	}
+}
+
+// GetFeed returns the user's calendar feed, creating it the first time
+func (s *calendarService) GetFeed(userID uint) (*models.CalendarFeed, error) {
+	feed, err := s.repo.FindByUser(userID)
+	if errors.Is(err, repositories.ErrRecordNotFound) {
+		return s.RotateFeed(userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+	return feed, nil
+}
+
+// RotateFeed gives the user's feed a new token, so the old URL stops working
+func (s *calendarService) RotateFeed(userID uint) (*models.CalendarFeed, error) {
+	feed, err := s.repo.FindByUser(userID)
+	if errors.Is(err, repositories.ErrRecordNotFound) {
+		feed = &models.CalendarFeed{UserID: userID}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+
+	feed.Token, err = newCalendarToken()
+	if err != nil {
+		return nil, err
+	}
+	feed.LastFetchedAt = nil
+	if err := s.repo.Save(feed); err != nil {
+		return nil, fmt.Errorf("failed to save calendar feed: %w", err)
+	}
+	return feed, nil
+}
+
+// DeleteFeed revokes the user's feed
+func (s *calendarService) DeleteFeed(userID uint) error {
+	if err := s.repo.Delete(userID); err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return models.ErrCalendarFeedNotFound
+		}
+		return fmt.Errorf("failed to delete calendar feed: %w", err)
+	}
+	return nil
+}
+
+// RenderFeed writes the iCalendar feed behind a token: an all-day event for
+// each grocery expiry in the user's household and each meal planned around now,
+// in the user's language and time zone
+func (s *calendarService) RenderFeed(token string, now time.Time) ([]byte, error) {
+	feed, err := s.repo.FindByToken(token)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return nil, models.ErrCalendarFeedNotFound
+		}
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+	user, err := s.userRepo.FindByID(feed.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	householdID, _, err := s.householdService.Membership(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	groceries, err := s.groceryRepo.FindAll(householdID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get household groceries: %w", err)
+	}
+	printer := userPrinter(user)
+	today := calendarDate(now, printer.Location())
+	meals, err := s.mealPlanRepo.FindRange(householdID, today.AddDate(0, 0, -calendarPastDays), today.AddDate(0, 0, calendarFutureDays))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get meal plan: %w", err)
+	}
+
+	calendar, err := buildCalendar(printer, groceries, meals)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.MarkFetched(feed.ID, now); err != nil {
+		log.Printf("Failed to record calendar fetch for user %d: %v", user.ID, err)
+	}
+	return calendar.Encode(), nil
+}
+
+func buildCalendar(p *i18n.Printer, groceries []models.GroceryItem, meals []models.MealPlanEntry) (*ical.Calendar, error) {
+	name, err := p.Render("calendar.name", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render calendar: %w", err)
+	}
+	calendar := &ical.Calendar{ProdID: calendarProdID, Name: name, Refresh: calendarRefresh}
+
+	for _, item := range groceries {
+		if item.ExpiryDate.IsZero() || item.Quantity <= 0 {
+			continue
+		}
+		summary, err := p.Render("calendar.expiry", item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render calendar: %w", err)
+		}
+		alarm, err := p.Render("calendar.expiry_alarm", item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render calendar: %w", err)
+		}
+		calendar.Events = append(calendar.Events, ical.Event{
+			UID:         fmt.Sprintf("grocery-%d@zero-waste-kitchen", item.ID),
+			Date:        calendarDate(item.ExpiryDate, p.Location()),
+			Summary:     summary,
+			Description: strings.TrimSpace(formatQuantity(item.Quantity) + " " + item.Unit),
+			Categories:  []string{"expiry"},
+			Stamp:       item.UpdatedAt,
+			Alarm:       &ical.Alarm{Trigger: expiryAlarm, Description: alarm},
+		})
+	}
+
+	for _, entry := range meals {
+		if entry.Status != models.MealPlanPlanned || entry.Recipe == nil {
+			continue
+		}
+		data := struct {
+			Meal     string
+			Recipe   string
+			Servings int
+		}{entry.Meal, entry.Recipe.Title, entry.Servings}
+		summary, err := p.Render("calendar.meal", data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render calendar: %w", err)
+		}
+		description, err := p.Render("calendar.meal_description", data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render calendar: %w", err)
+		}
+		calendar.Events = append(calendar.Events, ical.Event{
+			UID:         fmt.Sprintf("meal-%d@zero-waste-kitchen", entry.ID),
+			Date:        calendarDate(entry.Date, time.UTC), // Stored as a plain date
+			Summary:     summary,
+			Description: description,
+			Categories:  []string{"meal", entry.Meal},
+			Stamp:       entry.UpdatedAt,
+			Alarm:       &ical.Alarm{Trigger: mealAlarm, Description: summary},
+		})
+	}
+	return calendar, nil
+}
+
+// newCalendarToken returns a random token for a feed URL
+func newCalendarToken() (string, error) {
+	token := make([]byte, calendarTokenBytes)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate calendar token: %w", err)
+	}
+	return hex.EncodeToString(token), nil
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/repositories"
+)
+
+const maxDeviceTokenLength = 512
+
+var devicePlatforms = []string{models.PlatformAndroid, models.PlatformIOS, models.PlatformWeb, models.PlatformUnknown}
+
+type DeviceService interface {
+	RegisterDevice(device *models.Device) error
+	GetDevices(userID uint) ([]models.Device, error)
+	RemoveDevice(userID, id uint) error
+	PruneTokens(tokens []string)
+}
+
+type deviceService struct {
+	repo repositories.DeviceRepository
+}
+
+func NewDeviceService(repo repositories.DeviceRepository) DeviceService {
+	return &deviceService{repo: repo}
+}
+
+// RegisterDevice adds a device for push notifications or, if its token is
+// already known, marks it as seen now and moves it to this user
+func (s *deviceService) RegisterDevice(device *models.Device) error {
+	device.Token = strings.TrimSpace(device.Token)
+	if device.Token == "" || len(device.Token) > maxDeviceTokenLength {
+		return fmt.Errorf("%w: token must be 1 to %d characters", models.ErrInvalidDevice, maxDeviceTokenLength)
+	}
+	device.Platform = strings.ToLower(strings.TrimSpace(device.Platform))
+	if device.Platform == "" {
+		device.Platform = models.PlatformUnknown
+	}
+	if !containsString(devicePlatforms, device.Platform) {
+		return fmt.Errorf("%w: platform must be android, ios or web", models.ErrInvalidDevice)
+	}
+	device.AppVersion = strings.TrimSpace(device.AppVersion)
+	device.LastSeenAt = time.Now()
+
+	if err := s.repo.Register(device); err != nil {
+		return fmt.Errorf("failed to register device: %w", err)
+	}
+	return nil
+}
+
+func (s *deviceService) GetDevices(userID uint) ([]models.Device, error) {
+	devices, err := s.repo.FindByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get devices: %w", err)
+	}
+	return devices, nil
+}
+
+// RemoveDevice unregisters one of the user's devices, e.g. on logout
+func (s *deviceService) RemoveDevice(userID, id uint) error {
+	if err := s.repo.Delete(userID, id); err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return models.ErrDeviceNotFound
+		}
+		return fmt.Errorf("failed to remove device: %w", err)
+	}
+	return nil
+}
+
+// PruneTokens removes devices whose tokens the push service no longer accepts
+func (s *deviceService) PruneTokens(tokens []string) {
+	count, err := s.repo.DeleteTokens(tokens)
+	if err != nil {
+		log.Printf("Failed to prune %d device tokens: %v", len(tokens), err)
+		return
+	}
+	if count > 0 {
+		log.Printf("Pruned %d unregistered device tokens", count)
+	}
+}
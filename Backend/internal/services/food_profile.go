@@ -0,0 +1,276 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/repositories"
+)
+
+var ErrInvalidDiet = errors.New("diet must be one of vegetarian, vegan, halal or kosher")
+
+type FoodProfileService interface {
+	GetProfile(userID uint) (*models.FoodProfile, error)
+	UpdateProfile(profile *models.FoodProfile) error
+}
+
+type foodProfileService struct {
+	repo repositories.FoodProfileRepository
+}
+
+func NewFoodProfileService(repo repositories.FoodProfileRepository) FoodProfileService {
+	return &foodProfileService{repo: repo}
+}
+
+// GetProfile returns the user's food profile, or an empty one if none was saved yet
+func (s *foodProfileService) GetProfile(userID uint) (*models.FoodProfile, error) {
+	return loadFoodProfile(s.repo, userID)
+}
+
+// UpdateProfile validates and stores the user's food profile
+func (s *foodProfileService) UpdateProfile(profile *models.FoodProfile) error {
+	profile.Diet = strings.ToLower(strings.TrimSpace(profile.Diet))
+	switch models.Diet(profile.Diet) {
+	case models.DietNone, models.DietVegetarian, models.DietVegan, models.DietHalal, models.DietKosher:
+	default:
+		return ErrInvalidDiet
+	}
+
+	if profile.HouseholdSize < 0 {
+		return errors.New("household size cannot be negative")
+	}
+	if profile.MaxCookTime < 0 {
+		return errors.New("max cook time cannot be negative")
+	}
+
+	profile.Allergens = cleanTerms(profile.Allergens)
+	profile.DislikedIngredients = cleanTerms(profile.DislikedIngredients)
+	profile.Equipment = cleanTerms(profile.Equipment)
+	profile.Staples = cleanTerms(profile.Staples)
+
+	existing, err := s.repo.FindByUserID(profile.UserID)
+	if err != nil && !errors.Is(err, repositories.ErrRecordNotFound) {
+		return err
+	}
+	if existing != nil {
+		profile.ID = existing.ID
+		profile.CreatedAt = existing.CreatedAt
+	}
+
+	return s.repo.Save(profile)
+}
+
+// loadFoodProfile returns the saved profile or an empty one
+func loadFoodProfile(repo repositories.FoodProfileRepository, userID uint) (*models.FoodProfile, error) {
+	profile, err := repo.FindByUserID(userID)
+	if errors.Is(err, repositories.ErrRecordNotFound) {
+		return &models.FoodProfile{
+			UserID:              userID,
+			Allergens:           []string{},
+			DislikedIngredients: []string{},
+			Equipment:           []string{},
+			Staples:             []string{},
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get food profile: %w", err)
+	}
+	return profile, nil
+}
+
+// cleanTerms lowercases, trims and de-duplicates a list of ingredient or equipment names
+func cleanTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	cleaned := make([]string, 0, len(terms))
+	for _, term := range terms {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if term == "" || seen[term] {
+			continue
+		}
+		seen[term] = true
+		cleaned = append(cleaned, term)
+	}
+	return cleaned
+}
+
+// allergenTerms expands common allergen groups into the ingredients that contain them
+var allergenTerms = map[string][]string{
+	"dairy":     {"milk", "cheese", "butter", "cream", "yogurt", "yoghurt", "ghee", "paneer", "whey"},
+	"milk":      {"milk", "cheese", "butter", "cream", "yogurt", "yoghurt", "ghee", "paneer", "whey"},
+	"lactose":   {"milk", "cheese", "butter", "cream", "yogurt", "yoghurt", "whey"},
+	"gluten":    {"wheat", "flour", "bread", "pasta", "noodle", "barley", "rye", "couscous", "semolina", "breadcrumb"},
+	"wheat":     {"wheat", "flour", "bread", "pasta", "couscous", "semolina", "breadcrumb"},
+	"egg":       {"egg", "mayonnaise", "meringue"},
+	"eggs":      {"egg", "mayonnaise", "meringue"},
+	"peanut":    {"peanut"},
+	"peanuts":   {"peanut"},
+	"tree nuts": {"almond", "walnut", "cashew", "pecan", "pistachio", "hazelnut", "macadamia", "brazil nut", "pine nut"},
+	"nuts":      {"almond", "walnut", "cashew", "pecan", "pistachio", "hazelnut", "macadamia", "brazil nut", "pine nut", "peanut"},
+	"shellfish": {"shrimp", "prawn", "crab", "lobster", "crayfish", "scallop", "clam", "mussel", "oyster"},
+	"fish":      {"fish", "salmon", "tuna", "cod", "anchovy", "sardine", "trout", "mackerel", "tilapia"},
+	"soy":       {"soy", "tofu", "edamame", "tempeh", "miso"},
+	"sesame":    {"sesame", "tahini"},
+}
+
+var (
+	meatTerms    = []string{"chicken", "beef", "pork", "lamb", "mutton", "bacon", "ham", "turkey", "sausage", "veal", "duck", "gelatin", "lard", "prosciutto", "salami", "pepperoni", "chorizo"}
+	seafoodTerms = append(append([]string{}, allergenTerms["fish"]...), allergenTerms["shellfish"]...)
+	animalTerms  = []string{"egg", "honey", "milk", "cheese", "butter", "cream", "yogurt", "yoghurt", "ghee", "paneer", "whey", "mayonnaise"}
+	porkTerms    = []string{"pork", "bacon", "ham", "lard", "prosciutto", "salami", "pepperoni", "chorizo", "gelatin"}
+	alcoholTerms = []string{"wine", "beer", "rum", "vodka", "whiskey", "brandy", "sake", "mirin"}
+	dairyTerms   = allergenTerms["dairy"]
+)
+
+// plantQualifiers mark plant-based substitutes, e.g. "almond milk" or "vegan cheese"
+var plantQualifiers = []string{"almond", "soy", "oat", "coconut", "rice", "cashew", "peanut", "vegan", "plant-based", "vegetable"}
+
+// profileViolations returns why a recipe breaks the hard constraints of a food
+// profile: declared allergens and the chosen diet. An empty result means the recipe is safe.
+func profileViolations(recipe models.Recipe, profile *models.FoodProfile) []string {
+	if profile == nil {
+		return nil
+	}
+
+	text := strings.ToLower(recipe.Title + "\n" + recipe.Ingredients + "\n" + recipe.Instructions)
+	for _, ingredient := range recipe.IngredientList {
+		text += "\n" + strings.ToLower(ingredient.Name)
+	}
+
+	var violations []string
+	for _, allergen := range profile.Allergens {
+		terms, ok := allergenTerms[allergen]
+		if !ok {
+			terms = []string{allergen}
+		}
+		if term := findTerm(text, terms); term != "" {
+			violations = append(violations, fmt.Sprintf("contains allergen %s (%s)", allergen, term))
+		}
+	}
+
+	var forbidden []string
+	switch models.Diet(profile.Diet) {
+	case models.DietVegetarian:
+		forbidden = append(append(forbidden, meatTerms...), seafoodTerms...)
+	case models.DietVegan:
+		forbidden = append(append(append(forbidden, meatTerms...), seafoodTerms...), animalTerms...)
+	case models.DietHalal:
+		forbidden = append(append(forbidden, porkTerms...), alcoholTerms...)
+	case models.DietKosher:
+		forbidden = append(append(forbidden, porkTerms...), allergenTerms["shellfish"]...)
+		if meat, dairy := findTerm(text, meatTerms), findTerm(text, dairyTerms); meat != "" && dairy != "" {
+			violations = append(violations, fmt.Sprintf("not kosher: mixes %s and %s", meat, dairy))
+		}
+	}
+	if term := findTerm(text, forbidden); term != "" {
+		violations = append(violations, fmt.Sprintf("not %s (%s)", profile.Diet, term))
+	}
+
+	return violations
+}
+
+// findTerm returns the first term that appears in text as a whole word, allowing
+// plural endings, or "" if none does. Plant-based substitutes are ignored.
+func findTerm(text string, terms []string) string {
+	for _, term := range terms {
+		if term == "" {
+			continue
+		}
+		for start := 0; ; {
+			i := strings.Index(text[start:], term)
+			if i < 0 {
+				break
+			}
+			i += start
+			end := i + len(term)
+			start = end
+
+			if i > 0 && isWordRune(rune(text[i-1])) {
+				continue
+			}
+			rest := text[end:]
+			rest = strings.TrimPrefix(strings.TrimPrefix(rest, "e"), "s")
+			if rest != "" && isWordRune(rune(rest[0])) {
+				continue
+			}
+			if hasPlantQualifier(text[:i]) {
+				continue
+			}
+			return term
+		}
+	}
+	return ""
+}
+
+// hasPlantQualifier reports whether the word right before a match, on the same
+// line, marks a plant-based substitute
+func hasPlantQualifier(before string) bool {
+	if i := strings.LastIndexByte(before, '\n'); i >= 0 {
+		before = before[i+1:]
+	}
+	trimmed := strings.TrimRight(before, " \t")
+	if len(trimmed) == len(before) {
+		return false // the term is glued to whatever precedes it
+	}
+	for _, qualifier := range plantQualifiers {
+		if !strings.HasSuffix(trimmed, qualifier) {
+			continue
+		}
+		if rest := trimmed[:len(trimmed)-len(qualifier)]; rest == "" || !isWordRune(rune(rest[len(rest)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// profilePromptRules turns a food profile into instructions for the model
+func profilePromptRules(profile *models.FoodProfile) []string {
+	if profile == nil {
+		return nil
+	}
+
+	var rules []string
+	if profile.Diet != "" {
+		rules = append(rules, fmt.Sprintf("Every recipe must be strictly %s", profile.Diet))
+	}
+	if len(profile.Allergens) > 0 {
+		rules = append(rules, fmt.Sprintf("Never use these allergens or anything containing them: %s", strings.Join(profile.Allergens, ", ")))
+	}
+	if len(profile.DislikedIngredients) > 0 {
+		rules = append(rules, fmt.Sprintf("Avoid these disliked ingredients: %s", strings.Join(profile.DislikedIngredients, ", ")))
+	}
+	if profile.HouseholdSize > 0 {
+		rules = append(rules, fmt.Sprintf("Each recipe should serve %d people", profile.HouseholdSize))
+	}
+	if len(profile.Equipment) > 0 {
+		rules = append(rules, fmt.Sprintf("Only use this kitchen equipment: %s", strings.Join(profile.Equipment, ", ")))
+	}
+	if profile.MaxCookTime > 0 {
+		rules = append(rules, fmt.Sprintf("Prep time plus cook time must not exceed %d minutes", profile.MaxCookTime))
+	}
+	return rules
+}
+
+// profileWarnings returns soft food profile issues that don't disqualify a recipe
+func profileWarnings(recipe models.Recipe, profile *models.FoodProfile) []string {
+	if profile == nil {
+		return nil
+	}
+
+	var warnings []string
+	text := strings.ToLower(recipe.Title + "\n" + recipe.Ingredients)
+	for _, disliked := range profile.DislikedIngredients {
+		if findTerm(text, []string{disliked}) != "" {
+			warnings = append(warnings, fmt.Sprintf("uses disliked ingredient %s", disliked))
+		}
+	}
+	if total := recipe.PrepTime + recipe.CookTime; profile.MaxCookTime > 0 && total > profile.MaxCookTime {
+		warnings = append(warnings, fmt.Sprintf("takes %d minutes, over the %d minute limit", total, profile.MaxCookTime))
+	}
+	return warnings
+}
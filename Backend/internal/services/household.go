@@ -0,0 +1,394 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/repositories"
+)
+
+const (
+	// inviteCodeAlphabet leaves out characters that are easily confused, like 0 and O
+	inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	inviteCodeLength   = 8
+
+	defaultInviteDays = 7
+	maxInviteDays     = 30
+	maxInviteUses     = 20
+)
+
+// InviteInput describes a new household invite. Zero values use the defaults:
+// the member role, a single use and a week of validity.
+type InviteInput struct {
+	Role      models.HouseholdRole
+	MaxUses   int
+	ValidDays int
+}
+
+type HouseholdService interface {
+	Membership(userID uint) (uint, models.HouseholdRole, error)
+	GetHousehold(userID uint) (*models.Household, error)
+	RenameHousehold(userID uint, name string) (*models.Household, error)
+	CreateInvite(userID uint, input InviteInput) (*models.HouseholdInvite, error)
+	GetInvites(userID uint) ([]models.HouseholdInvite, error)
+	RevokeInvite(userID uint, id uint) error
+	Join(userID uint, code string) (*models.Household, error)
+	Leave(userID uint) (*models.Household, error)
+	TransferOwnership(userID uint, newOwnerID uint) (*models.Household, error)
+	UpdateMemberRole(userID uint, memberID uint, role models.HouseholdRole) (*models.Household, error)
+	RemoveMember(userID uint, memberID uint) (*models.Household, error)
+}
+
+type householdService struct {
+	repo     repositories.HouseholdRepository
+	userRepo repositories.UserRepository
+}
+
+func NewHouseholdService(repo repositories.HouseholdRepository, userRepo repositories.UserRepository) HouseholdService {
+	return &householdService{repo: repo, userRepo: userRepo}
+}
+
+// Membership returns the household a user belongs to and their role in it.
+// Users without a household, such as those who signed up before households
+// existed, get one of their own.
+func (s *householdService) Membership(userID uint) (uint, models.HouseholdRole, error) {
+	member, err := s.membership(userID)
+	if err != nil {
+		return 0, "", err
+	}
+	return member.HouseholdID, member.Role, nil
+}
+
+// GetHousehold returns the user's household with its members
+func (s *householdService) GetHousehold(userID uint) (*models.Household, error) {
+	member, err := s.membership(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.findHousehold(member.HouseholdID)
+}
+
+// RenameHousehold renames the owner's household
+func (s *householdService) RenameHousehold(userID uint, name string) (*models.Household, error) {
+	member, err := s.ownerMembership(userID)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, models.ErrInvalidHouseholdName
+	}
+
+	household, err := s.findHousehold(member.HouseholdID)
+	if err != nil {
+		return nil, err
+	}
+	household.Name = name
+	if err := s.repo.Save(household); err != nil {
+		return nil, fmt.Errorf("failed to save household: %w", err)
+	}
+	return household, nil
+}
+
+// CreateInvite creates a code others can enter to join the owner's household
+func (s *householdService) CreateInvite(userID uint, input InviteInput) (*models.HouseholdInvite, error) {
+	member, err := s.ownerMembership(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Role == "" {
+		input.Role = models.RoleMember
+	}
+	if input.Role != models.RoleMember && input.Role != models.RoleViewer {
+		return nil, models.ErrInvalidHouseholdRole
+	}
+	if input.MaxUses == 0 {
+		input.MaxUses = 1
+	}
+	if input.MaxUses < 1 || input.MaxUses > maxInviteUses {
+		return nil, fmt.Errorf("%w: it can be used between 1 and %d times", models.ErrInvalidInvite, maxInviteUses)
+	}
+	if input.ValidDays == 0 {
+		input.ValidDays = defaultInviteDays
+	}
+	if input.ValidDays < 1 || input.ValidDays > maxInviteDays {
+		return nil, fmt.Errorf("%w: it can be valid for 1 to %d days", models.ErrInvalidInvite, maxInviteDays)
+	}
+
+	code, err := newInviteCode()
+	if err != nil {
+		return nil, err
+	}
+	invite := &models.HouseholdInvite{
+		HouseholdID: member.HouseholdID,
+		Code:        code,
+		Role:        input.Role,
+		CreatedBy:   userID,
+		MaxUses:     input.MaxUses,
+		ExpiresAt:   time.Now().AddDate(0, 0, input.ValidDays),
+	}
+	if err := s.repo.CreateInvite(invite); err != nil {
+		return nil, fmt.Errorf("failed to create invite: %w", err)
+	}
+	return invite, nil
+}
+
+// GetInvites returns the owner's invites that can still be used
+func (s *householdService) GetInvites(userID uint) ([]models.HouseholdInvite, error) {
+	member, err := s.ownerMembership(userID)
+	if err != nil {
+		return nil, err
+	}
+	invites, err := s.repo.FindInvites(member.HouseholdID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invites: %w", err)
+	}
+	return invites, nil
+}
+
+// RevokeInvite deletes one of the owner's invites
+func (s *householdService) RevokeInvite(userID uint, id uint) error {
+	member, err := s.ownerMembership(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteInvite(member.HouseholdID, id); err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return models.ErrInviteInvalid
+		}
+		return fmt.Errorf("failed to revoke invite: %w", err)
+	}
+	return nil
+}
+
+// Join moves the user into the household of an invite code. A user who was the
+// only member of their old household brings its pantry and everything else along;
+// otherwise it stays with the remaining members.
+func (s *householdService) Join(userID uint, code string) (*models.Household, error) {
+	member, err := s.membership(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	invite, err := s.repo.FindInviteByCode(strings.ToUpper(strings.TrimSpace(code)))
+	if errors.Is(err, repositories.ErrRecordNotFound) {
+		return nil, models.ErrInviteInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invite: %w", err)
+	}
+	if invite.HouseholdID == member.HouseholdID {
+		return nil, models.ErrAlreadyInHousehold
+	}
+
+	current, err := s.findHousehold(member.HouseholdID)
+	if err != nil {
+		return nil, err
+	}
+	var emptied uint
+	switch {
+	case len(current.Members) == 1:
+		emptied = current.ID
+	case member.Role == models.RoleOwner:
+		return nil, models.ErrOwnerMustTransfer
+	}
+
+	if err := s.repo.JoinWithInvite(userID, invite, emptied); err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return nil, models.ErrInviteInvalid
+		}
+		return nil, fmt.Errorf("failed to join household: %w", err)
+	}
+	return s.findHousehold(invite.HouseholdID)
+}
+
+// Leave takes the user out of their household into a new, empty one of their
+// own. The household's data stays with the remaining members.
+func (s *householdService) Leave(userID uint) (*models.Household, error) {
+	member, err := s.membership(userID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.findHousehold(member.HouseholdID)
+	if err != nil {
+		return nil, err
+	}
+	if len(current.Members) == 1 {
+		return nil, models.ErrSoleHouseholdMember
+	}
+	if member.Role == models.RoleOwner {
+		return nil, models.ErrOwnerMustTransfer
+	}
+
+	return s.moveToNewHousehold(userID)
+}
+
+// TransferOwnership makes another member the owner. The previous owner stays on as a member.
+func (s *householdService) TransferOwnership(userID uint, newOwnerID uint) (*models.Household, error) {
+	member, err := s.ownerMembership(userID)
+	if err != nil {
+		return nil, err
+	}
+	if newOwnerID == userID {
+		return s.findHousehold(member.HouseholdID)
+	}
+
+	household, err := s.findHousehold(member.HouseholdID)
+	if err != nil {
+		return nil, err
+	}
+	next := findMember(household, newOwnerID)
+	if next == nil {
+		return nil, models.ErrNotHouseholdMember
+	}
+
+	err = s.repo.TransferOwnership(household.ID, userID, newOwnerID)
+	if errors.Is(err, repositories.ErrRecordNotFound) {
+		return nil, models.ErrNotHouseholdMember
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to transfer ownership: %w", err)
+	}
+	return s.findHousehold(household.ID)
+}
+
+// UpdateMemberRole makes another member of the owner's household a member or a viewer
+func (s *householdService) UpdateMemberRole(userID uint, memberID uint, role models.HouseholdRole) (*models.Household, error) {
+	owner, err := s.ownerMembership(userID)
+	if err != nil {
+		return nil, err
+	}
+	if role != models.RoleMember && role != models.RoleViewer {
+		return nil, models.ErrInvalidHouseholdRole
+	}
+
+	household, err := s.findHousehold(owner.HouseholdID)
+	if err != nil {
+		return nil, err
+	}
+	member := findMember(household, memberID)
+	if member == nil || memberID == userID {
+		return nil, models.ErrNotHouseholdMember
+	}
+
+	member.Role = role
+	if err := s.repo.SaveMember(member); err != nil {
+		return nil, fmt.Errorf("failed to update member: %w", err)
+	}
+	return s.findHousehold(household.ID)
+}
+
+// RemoveMember takes another member out of the owner's household. They get a new, empty household.
+func (s *householdService) RemoveMember(userID uint, memberID uint) (*models.Household, error) {
+	owner, err := s.ownerMembership(userID)
+	if err != nil {
+		return nil, err
+	}
+	household, err := s.findHousehold(owner.HouseholdID)
+	if err != nil {
+		return nil, err
+	}
+	if findMember(household, memberID) == nil || memberID == userID {
+		return nil, models.ErrNotHouseholdMember
+	}
+
+	if _, err := s.moveToNewHousehold(memberID); err != nil {
+		return nil, err
+	}
+	return s.findHousehold(household.ID)
+}
+
+// membership returns the user's membership, creating a household for users without one
+func (s *householdService) membership(userID uint) (*models.HouseholdMember, error) {
+	member, err := s.repo.FindMembership(userID)
+	if err == nil {
+		return member, nil
+	}
+	if !errors.Is(err, repositories.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get household membership: %w", err)
+	}
+
+	name, err := s.householdName(userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.CreateForUser(userID, name); err != nil {
+		// A concurrent request may have created it first
+		if member, findErr := s.repo.FindMembership(userID); findErr == nil {
+			return member, nil
+		}
+		return nil, fmt.Errorf("failed to create household: %w", err)
+	}
+	return s.repo.FindMembership(userID)
+}
+
+// ownerMembership returns the user's membership, or ErrNotHouseholdOwner when they don't own their household
+func (s *householdService) ownerMembership(userID uint) (*models.HouseholdMember, error) {
+	member, err := s.membership(userID)
+	if err != nil {
+		return nil, err
+	}
+	if member.Role != models.RoleOwner {
+		return nil, models.ErrNotHouseholdOwner
+	}
+	return member, nil
+}
+
+func (s *householdService) moveToNewHousehold(userID uint) (*models.Household, error) {
+	name, err := s.householdName(userID)
+	if err != nil {
+		return nil, err
+	}
+	household, err := s.repo.MoveToNewHousehold(userID, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create household: %w", err)
+	}
+	return s.findHousehold(household.ID)
+}
+
+func (s *householdService) findHousehold(id uint) (*models.Household, error) {
+	household, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return nil, models.ErrHouseholdNotFound
+		}
+		return nil, fmt.Errorf("failed to get household: %w", err)
+	}
+	return household, nil
+}
+
+// householdName names a user's own household after them
+func (s *householdService) householdName(userID uint) (string, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+	return fmt.Sprintf("%s's household", user.Name), nil
+}
+
+func findMember(household *models.Household, userID uint) *models.HouseholdMember {
+	for i := range household.Members {
+		if household.Members[i].UserID == userID {
+			return &household.Members[i]
+		}
+	}
+	return nil
+}
+
+// newInviteCode returns a random, easy to type invite code
+func newInviteCode() (string, error) {
+	code := make([]byte, inviteCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(inviteCodeAlphabet))))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate invite code: %w", err)
+		}
+		code[i] = inviteCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"zero-waste-kitchen/internal/models"
+)
+
+// measureWords are units and container words that may follow a quantity in an ingredient line
+var measureWords = map[string]bool{
+	"g": true, "gram": true, "grams": true, "kg": true, "kilogram": true, "kilograms": true,
+	"mg": true, "ml": true, "milliliter": true, "milliliters": true, "l": true, "liter": true, "liters": true,
+	"litre": true, "litres": true, "oz": true, "ounce": true, "ounces": true, "lb": true, "lbs": true,
+	"pound": true, "pounds": true, "cup": true, "cups": true, "tbsp": true, "tablespoon": true,
+	"tablespoons": true, "tsp": true, "teaspoon": true, "teaspoons": true, "pinch": true, "dash": true,
+	"clove": true, "cloves": true, "can": true, "cans": true, "slice": true, "slices": true,
+	"piece": true, "pieces": true, "handful": true, "bunch": true, "stick": true, "sticks": true,
+	"large": true, "medium": true, "small": true, "of": true, "x": true,
+}
+
+// implicitStaples are never reported as missing from the pantry
+var implicitStaples = []string{"water"}
+
+// ingredientName strips the quantity, unit and preparation notes from an ingredient
+// line, e.g. "2 cups cooked rice, rinsed" becomes "cooked rice"
+func ingredientName(line string) string {
+	line = strings.ToLower(strings.TrimSpace(line))
+	line = strings.TrimLeft(line, "-*• ")
+	if i := strings.IndexAny(line, ",("); i >= 0 {
+		line = line[:i]
+	}
+
+	words := strings.Fields(line)
+	for len(words) > 0 {
+		word := words[0]
+		if measureWords[word] || strings.IndexFunc(word, unicode.IsLetter) < 0 || isQuantityWithUnit(word) {
+			words = words[1:]
+			continue
+		}
+		break
+	}
+	return strings.Join(words, " ")
+}
+
+// isQuantityWithUnit reports whether a word is a number glued to a unit, e.g. "200g" or "1.5l"
+func isQuantityWithUnit(word string) bool {
+	i := strings.IndexFunc(word, unicode.IsLetter)
+	return i > 0 && measureWords[word[i:]]
+}
+
+// pantryWarnings flags recipe ingredients that are neither in the pantry nor one of the user's staples
+func pantryWarnings(recipe models.Recipe, groceries []models.GroceryItem, staples []string) []string {
+	var warnings []string
+	for _, line := range strings.Split(recipe.Ingredients, "\n") {
+		name := ingredientName(line)
+		if name == "" || strings.Contains(strings.ToLower(line), "optional") {
+			continue
+		}
+		if inPantry(name, groceries) || isStaple(name, staples) {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("uses %s, which is not in your pantry", name))
+	}
+	return warnings
+}
+
+// isStaple reports whether an ingredient is one of the user's pantry staples
+func isStaple(name string, staples []string) bool {
+	for _, staple := range append(staples, implicitStaples...) {
+		if usesIngredient(name, staple) || usesIngredient(staple, name) {
+			return true
+		}
+	}
+	return false
+}
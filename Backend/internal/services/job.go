@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/repositories"
+	"zero-waste-kitchen/internal/scheduler"
+)
+
+// jobRunRetention is how long run history is kept
+const jobRunRetention = 30 * 24 * time.Hour
+
+// JobUpdate changes a job's schedule and whether it runs. Nil fields stay as they are.
+type JobUpdate struct {
+	Schedule *string
+	Enabled  *bool
+}
+
+type JobService interface {
+	GetJobs() ([]models.ScheduledJob, error)
+	UpdateJob(name string, update JobUpdate) (*models.ScheduledJob, error)
+	TriggerJob(name string) (*models.ScheduledJob, error)
+	GetRuns(name string, limit int) ([]models.JobRun, error)
+	PruneRuns(ctx context.Context, now time.Time) error
+}
+
+type jobService struct {
+	repo repositories.JobRepository
+}
+
+func NewJobService(repo repositories.JobRepository) JobService {
+	return &jobService{repo: repo}
+}
+
+func (s *jobService) GetJobs() ([]models.ScheduledJob, error) {
+	jobs, err := s.repo.FindJobs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// UpdateJob changes a job's schedule or pauses it. A new schedule takes effect
+// from now on.
+func (s *jobService) UpdateJob(name string, update JobUpdate) (*models.ScheduledJob, error) {
+	job, err := s.getJob(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Schedule != nil {
+		expr := strings.TrimSpace(*update.Schedule)
+		schedule, err := scheduler.ParseSchedule(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", models.ErrInvalidJob, err)
+		}
+		job.Schedule = expr
+		job.NextRunAt = schedule.Next(time.Now())
+	}
+	if update.Enabled != nil {
+		job.Enabled = *update.Enabled
+	}
+
+	if err := s.repo.SaveJob(job); err != nil {
+		return nil, fmt.Errorf("failed to save job: %w", err)
+	}
+	return job, nil
+}
+
+// TriggerJob makes a job due now, so the next scheduler poll runs it
+func (s *jobService) TriggerJob(name string) (*models.ScheduledJob, error) {
+	job, err := s.getJob(name)
+	if err != nil {
+		return nil, err
+	}
+	if !job.Enabled {
+		return nil, fmt.Errorf("%w: job is disabled", models.ErrInvalidJob)
+	}
+
+	job.NextRunAt = time.Now()
+	if err := s.repo.SaveJob(job); err != nil {
+		return nil, fmt.Errorf("failed to save job: %w", err)
+	}
+	return job, nil
+}
+
+// GetRuns returns a job's most recent runs, newest first
+func (s *jobService) GetRuns(name string, limit int) ([]models.JobRun, error) {
+	if _, err := s.getJob(name); err != nil {
+		return nil, err
+	}
+	runs, err := s.repo.FindRuns(name, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job runs: %w", err)
+	}
+	return runs, nil
+}
+
+// PruneRuns removes run history older than a month. It runs as a job itself.
+func (s *jobService) PruneRuns(ctx context.Context, now time.Time) error {
+	count, err := s.repo.DeleteRunsBefore(now.Add(-jobRunRetention))
+	if err != nil {
+		return fmt.Errorf("failed to prune job runs: %w", err)
+	}
+	if count > 0 {
+		log.Printf("Pruned %d old job runs", count)
+	}
+	return nil
+}
+
+func (s *jobService) getJob(name string) (*models.ScheduledJob, error) {
+	job, err := s.repo.FindJob(name)
+	if errors.Is(err, repositories.ErrRecordNotFound) {
+		return nil, models.ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return job, nil
+}
@@ -0,0 +1,471 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/repositories"
+)
+
+// dateLayout is how meal plan dates are written in requests and messages
+const dateLayout = "2006-01-02"
+
+// MealPlanInput adds a recipe to the meal plan
+type MealPlanInput struct {
+	RecipeID uint
+	Date     time.Time
+	Meal     string
+	Servings int // 0 for the recipe's own servings
+}
+
+// MealPlanChanges is a partial update of a meal plan entry
+type MealPlanChanges struct {
+	Date     *time.Time
+	Meal     *string
+	Servings *int
+}
+
+// MealPlanSuggestion proposes moving a planned meal so expiring items get used first
+type MealPlanSuggestion struct {
+	EntryID  uint      `json:"entry_id"`
+	Recipe   string    `json:"recipe"`
+	Meal     string    `json:"meal"`
+	FromDate time.Time `json:"from_date"`
+	ToDate   time.Time `json:"to_date"`
+	Reason   string    `json:"reason"`
+}
+
+type MealPlanService interface {
+	GetPlan(householdID uint, from, to time.Time) ([]models.MealPlanEntry, error)
+	AddEntry(userID uint, householdID uint, input MealPlanInput) (*models.MealPlanEntry, error)
+	UpdateEntry(householdID uint, id uint, changes MealPlanChanges) (*models.MealPlanEntry, error)
+	DeleteEntry(householdID uint, id uint) error
+	CookEntry(householdID uint, id uint) (*models.MealPlanEntry, error)
+	SuggestReorder(householdID uint, from, to time.Time) ([]MealPlanSuggestion, error)
+}
+
+type mealPlanService struct {
+	repo        repositories.MealPlanRepository
+	groceryRepo repositories.GroceryRepository
+	recipeRepo  repositories.RecipeRepository
+	profileRepo repositories.FoodProfileRepository
+}
+
+func NewMealPlanService(repo repositories.MealPlanRepository, groceryRepo repositories.GroceryRepository, recipeRepo repositories.RecipeRepository, profileRepo repositories.FoodProfileRepository) MealPlanService {
+	return &mealPlanService{repo: repo, groceryRepo: groceryRepo, recipeRepo: recipeRepo, profileRepo: profileRepo}
+}
+
+// GetPlan returns the household's meal plan between from and to inclusive, with warnings
+func (s *mealPlanService) GetPlan(householdID uint, from, to time.Time) ([]models.MealPlanEntry, error) {
+	entries, err := s.repo.FindRange(householdID, dateOnly(from), dateOnly(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get meal plan: %w", err)
+	}
+
+	sortMealPlan(entries)
+	for i := range entries {
+		annotateMealPlanEntry(&entries[i])
+	}
+	return entries, nil
+}
+
+// AddEntry plans one of the household's recipes and reserves the stock it needs
+func (s *mealPlanService) AddEntry(userID uint, householdID uint, input MealPlanInput) (*models.MealPlanEntry, error) {
+	meal, err := parseMeal(input.Meal)
+	if err != nil {
+		return nil, err
+	}
+	if input.Servings < 0 || input.Servings > maxScaledServings {
+		return nil, ErrInvalidServings
+	}
+
+	recipe, err := s.recipeRepo.FindByID(householdID, input.RecipeID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return nil, models.ErrRecipeNotFound
+		}
+		return nil, fmt.Errorf("failed to get recipe: %w", err)
+	}
+
+	entry := &models.MealPlanEntry{
+		UserID:      userID,
+		HouseholdID: householdID,
+		RecipeID:    recipe.ID,
+		Date:        dateOnly(input.Date),
+		Meal:        meal,
+		Servings:    input.Servings,
+		Status:      models.MealPlanPlanned,
+	}
+	if entry.Servings == 0 {
+		entry.Servings = recipe.Servings
+	}
+
+	if err := s.repo.Save(entry); err != nil {
+		return nil, fmt.Errorf("failed to save meal plan entry: %w", err)
+	}
+	entry.Recipe = recipe
+
+	return s.reserveAndReload(entry)
+}
+
+// UpdateEntry moves or resizes a planned meal and re-reserves its stock
+func (s *mealPlanService) UpdateEntry(householdID uint, id uint, changes MealPlanChanges) (*models.MealPlanEntry, error) {
+	entry, err := s.findEntry(householdID, id)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Status == models.MealPlanCooked {
+		return nil, models.ErrMealAlreadyCooked
+	}
+
+	if changes.Date != nil {
+		entry.Date = dateOnly(*changes.Date)
+	}
+	if changes.Meal != nil {
+		if entry.Meal, err = parseMeal(*changes.Meal); err != nil {
+			return nil, err
+		}
+	}
+	if changes.Servings != nil {
+		if *changes.Servings < 1 || *changes.Servings > maxScaledServings {
+			return nil, ErrInvalidServings
+		}
+		entry.Servings = *changes.Servings
+	}
+
+	// The changes are saved together with the new reservations
+	return s.reserveAndReload(entry)
+}
+
+// DeleteEntry removes a meal from the plan and releases its reserved stock
+func (s *mealPlanService) DeleteEntry(householdID uint, id uint) error {
+	if _, err := s.findEntry(householdID, id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete meal plan entry: %w", err)
+	}
+	return nil
+}
+
+// CookEntry marks a planned meal as cooked and takes its reserved stock out of the pantry
+func (s *mealPlanService) CookEntry(householdID uint, id uint) (*models.MealPlanEntry, error) {
+	entry, err := s.findEntry(householdID, id)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Status == models.MealPlanCooked {
+		return nil, models.ErrMealAlreadyCooked
+	}
+
+	err = s.repo.Cook(entry)
+	if errors.Is(err, repositories.ErrRecordNotFound) {
+		return nil, models.ErrMealAlreadyCooked
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to cook meal plan entry: %w", err)
+	}
+	entry.Status = models.MealPlanCooked
+	entry.Shortfalls = []string{}
+	entry.Reservations = []models.PantryReservation{}
+	return entry, nil
+}
+
+// SuggestReorder proposes swapping the dates of planned meals of the same kind
+// so that meals using the soonest-expiring stock come first
+func (s *mealPlanService) SuggestReorder(householdID uint, from, to time.Time) ([]MealPlanSuggestion, error) {
+	entries, err := s.repo.FindRange(householdID, dateOnly(from), dateOnly(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get meal plan: %w", err)
+	}
+	groceries, err := s.groceryRepo.FindAll(householdID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get household groceries: %w", err)
+	}
+	groceries = rankByExpiry(groceries)
+
+	type plannedMeal struct {
+		entry   models.MealPlanEntry
+		expiry  time.Time // Soonest expiry among the stock it uses, zero if none expires
+		useName string
+	}
+
+	byMeal := map[string][]plannedMeal{}
+	for _, entry := range entries {
+		if entry.Status != models.MealPlanPlanned {
+			continue
+		}
+		planned := plannedMeal{entry: entry}
+		planned.expiry, planned.useName = soonestExpiry(entry, groceries)
+		byMeal[entry.Meal] = append(byMeal[entry.Meal], planned)
+	}
+
+	suggestions := []MealPlanSuggestion{}
+	for _, meals := range byMeal {
+		dates := make([]time.Time, len(meals))
+		for i, planned := range meals {
+			dates[i] = planned.entry.Date
+		}
+		sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+		sort.SliceStable(meals, func(i, j int) bool {
+			a, b := meals[i].expiry, meals[j].expiry
+			if a.IsZero() || b.IsZero() || a.Equal(b) {
+				if a.IsZero() != b.IsZero() {
+					return b.IsZero()
+				}
+				return meals[i].entry.Date.Before(meals[j].entry.Date)
+			}
+			return a.Before(b)
+		})
+
+		for i, planned := range meals {
+			if planned.entry.Date.Equal(dates[i]) {
+				continue
+			}
+			reason := "makes room for meals that use expiring items"
+			if !planned.expiry.IsZero() && dates[i].Before(planned.entry.Date) {
+				reason = fmt.Sprintf("uses %s, which expires on %s", planned.useName, planned.expiry.Format(dateLayout))
+			}
+			suggestions = append(suggestions, MealPlanSuggestion{
+				EntryID:  planned.entry.ID,
+				Recipe:   recipeTitle(planned.entry),
+				Meal:     planned.entry.Meal,
+				FromDate: planned.entry.Date,
+				ToDate:   dates[i],
+				Reason:   reason,
+			})
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool { return suggestions[i].ToDate.Before(suggestions[j].ToDate) })
+	return suggestions, nil
+}
+
+// soonestExpiry returns the earliest expiry among the stock a planned meal uses,
+// from its reservations or, failing that, by matching its recipe against the pantry
+func soonestExpiry(entry models.MealPlanEntry, groceries []models.GroceryItem) (time.Time, string) {
+	var soonest time.Time
+	var name string
+	consider := func(item models.GroceryItem) {
+		if item.ExpiryDate.IsZero() {
+			return
+		}
+		if soonest.IsZero() || item.ExpiryDate.Before(soonest) {
+			soonest, name = item.ExpiryDate, item.Name
+		}
+	}
+
+	for _, reservation := range entry.Reservations {
+		if reservation.GroceryItem != nil {
+			consider(*reservation.GroceryItem)
+		}
+	}
+	if soonest.IsZero() && entry.Recipe != nil {
+		for _, item := range groceries {
+			if recipeUses(*entry.Recipe, item.Name) {
+				consider(item)
+			}
+		}
+	}
+	return soonest, name
+}
+
+func (s *mealPlanService) findEntry(householdID uint, id uint) (*models.MealPlanEntry, error) {
+	entry, err := s.repo.FindByID(householdID, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return nil, models.ErrMealPlanEntryNotFound
+		}
+		return nil, fmt.Errorf("failed to get meal plan entry: %w", err)
+	}
+	return entry, nil
+}
+
+// reserveAndReload reserves stock for an entry and returns it as stored, with warnings
+func (s *mealPlanService) reserveAndReload(entry *models.MealPlanEntry) (*models.MealPlanEntry, error) {
+	if err := s.reserve(entry); err != nil {
+		return nil, err
+	}
+
+	saved, err := s.findEntry(entry.HouseholdID, entry.ID)
+	if err != nil {
+		return nil, err
+	}
+	annotateMealPlanEntry(saved)
+	return saved, nil
+}
+
+// reserve holds back the pantry stock a planned meal needs. Stock reserved for
+// other meals isn't available. What the pantry can't cover is recorded as a shortfall.
+// Staples come from the food profile of whoever planned the meal. The entry's
+// date, meal and servings are saved with the reservations, unless it was cooked.
+func (s *mealPlanService) reserve(entry *models.MealPlanEntry) error {
+	if entry.Recipe == nil {
+		return errors.New("meal plan entry has no recipe loaded")
+	}
+
+	profile, err := loadFoodProfile(s.profileRepo, entry.UserID)
+	if err != nil {
+		return err
+	}
+	ingredients := scaleIngredients(*entry.Recipe, entryScale(*entry))
+
+	err = s.repo.Reserve(entry, func(groceries []models.GroceryItem, reserved map[uint]float64) ([]models.PantryReservation, []string) {
+		reservations, shortfalls := allocateStock(ingredients, groceries, reserved, entry.Date, profile.Staples)
+		for i := range reservations {
+			reservations[i].UserID = entry.UserID
+			reservations[i].HouseholdID = entry.HouseholdID
+			reservations[i].MealPlanEntryID = entry.ID
+		}
+
+		described := make([]string, 0, len(shortfalls))
+		for _, shortfall := range shortfalls {
+			described = append(described, shortfall.String())
+		}
+		return reservations, described
+	})
+	if errors.Is(err, repositories.ErrRecordNotFound) {
+		// Deleted or cooked in the meantime
+		if _, err := s.findEntry(entry.HouseholdID, entry.ID); err != nil {
+			return err
+		}
+		return models.ErrMealAlreadyCooked
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reserve stock: %w", err)
+	}
+	return nil
+}
+
+// stockShortfall is how much of an ingredient the pantry can't cover
+type stockShortfall struct {
+	Name     string
+	Quantity float64 // 0 when the recipe gives no quantity
+	Unit     string
+	Missing  bool // Not in the pantry at all
+}
+
+func (s stockShortfall) String() string {
+	if s.Missing {
+		return fmt.Sprintf("%s is not in your pantry", s.Name)
+	}
+	return strings.Join(strings.Fields(fmt.Sprintf("needs %s %s more %s", formatQuantity(s.Quantity), s.Unit, s.Name)), " ")
+}
+
+// allocateStock works out which pantry stock covers the ingredients, taking
+// stock that is still good on date first, soonest-expiring first. reserved holds
+// the quantities already taken per grocery item and is updated in place. It
+// returns the reservations, without owner, and what the pantry can't cover.
+// Stock in a unit that can't be compared with the recipe's counts as enough.
+func allocateStock(ingredients []ScaledIngredient, groceries []models.GroceryItem, reserved map[uint]float64, date time.Time, staples []string) ([]models.PantryReservation, []stockShortfall) {
+	groceries = rankByExpiry(groceries)
+	sort.SliceStable(groceries, func(i, j int) bool {
+		return goodOn(groceries[i], date) && !goodOn(groceries[j], date)
+	})
+
+	var reservations []models.PantryReservation
+	var shortfalls []stockShortfall
+	for _, ingredient := range ingredients {
+		if ingredient.Optional || ingredient.Name == "" {
+			continue
+		}
+
+		matched, comparable := false, false
+		remaining := ingredient.Quantity
+		for _, item := range groceries {
+			if !usesIngredient(ingredient.Name, item.Name) && !usesIngredient(item.Name, ingredient.Name) {
+				continue
+			}
+			matched = true
+			if remaining <= 0 {
+				break // Nothing measurable to reserve, e.g. "salt to taste"
+			}
+
+			needed, ok := convertQuantity(remaining, ingredient.Unit, item.Unit)
+			if !ok {
+				continue
+			}
+			comparable = true
+
+			available := item.Quantity - reserved[item.ID]
+			if available <= 0 {
+				continue
+			}
+			take := needed
+			if available < take {
+				take = available
+			}
+			reservations = append(reservations, models.PantryReservation{
+				GroceryItemID: item.ID,
+				Ingredient:    ingredient.Name,
+				Quantity:      roundQuantity(take, 0.001),
+			})
+			reserved[item.ID] += take
+			remaining *= 1 - take/needed
+			if remaining <= 1e-9 {
+				remaining = 0
+				break
+			}
+		}
+
+		switch {
+		case !matched && !isStaple(ingredient.Name, staples):
+			shortfalls = append(shortfalls, stockShortfall{Name: ingredient.Name, Quantity: ingredient.Quantity, Unit: ingredient.Unit, Missing: true})
+		case comparable && remaining > 0:
+			quantity, unit := humanizeQuantity(remaining, ingredient.Unit)
+			shortfalls = append(shortfalls, stockShortfall{Name: ingredient.Name, Quantity: quantity, Unit: unit})
+		}
+	}
+	return reservations, shortfalls
+}
+
+// annotateMealPlanEntry warns about reserved stock that expires before the planned date
+func annotateMealPlanEntry(entry *models.MealPlanEntry) {
+	if entry.Status != models.MealPlanPlanned {
+		return
+	}
+	for _, reservation := range entry.Reservations {
+		if item := reservation.GroceryItem; item != nil && !goodOn(*item, entry.Date) {
+			entry.Warnings = append(entry.Warnings, fmt.Sprintf("%s expires on %s, before this meal", item.Name, item.ExpiryDate.Format(dateLayout)))
+		}
+	}
+}
+
+// goodOn reports whether an item is still within its expiry date on the given day
+func goodOn(item models.GroceryItem, date time.Time) bool {
+	return item.ExpiryDate.IsZero() || !dateOnly(item.ExpiryDate).Before(dateOnly(date))
+}
+
+// sortMealPlan orders entries by date, then breakfast to snack
+func sortMealPlan(entries []models.MealPlanEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].Date.Equal(entries[j].Date) {
+			return entries[i].Date.Before(entries[j].Date)
+		}
+		return models.MealOrder[models.MealType(entries[i].Meal)] < models.MealOrder[models.MealType(entries[j].Meal)]
+	})
+}
+
+func parseMeal(meal string) (string, error) {
+	meal = strings.ToLower(strings.TrimSpace(meal))
+	if _, ok := models.MealOrder[models.MealType(meal)]; !ok {
+		return "", models.ErrInvalidMeal
+	}
+	return meal, nil
+}
+
+func recipeTitle(entry models.MealPlanEntry) string {
+	if entry.Recipe == nil {
+		return ""
+	}
+	return entry.Recipe.Title
+}
+
+// dateOnly truncates a time to midnight UTC of its calendar day
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"zero-waste-kitchen/internal/models"
+)
+
+func TestAllocateStock(t *testing.T) {
+	now := time.Now()
+	date := now.AddDate(0, 0, 5) // The planned meal
+	groceries := []models.GroceryItem{
+		{ID: 1, Name: "milk", Quantity: 1, Unit: "L", ExpiryDate: now.AddDate(0, 0, 2)}, // Expires before the meal
+		{ID: 2, Name: "whole milk", Quantity: 1, Unit: "L", ExpiryDate: now.AddDate(0, 0, 10)},
+		{ID: 3, Name: "Milk", Quantity: 500, Unit: "ml"},
+		{ID: 4, Name: "eggs", Quantity: 6},
+		{ID: 5, Name: "yogurt", Quantity: 500, Unit: "g", ExpiryDate: now.AddDate(0, 0, -1)},
+		{ID: 6, Name: "flour", Quantity: 1, Unit: "bag"},
+	}
+
+	tests := []struct {
+		name         string
+		ingredients  []ScaledIngredient
+		reserved     map[uint]float64 // By other planned meals
+		reservations []models.PantryReservation
+		shortfalls   []stockShortfall
+	}{
+		{
+			name:        "soonest stock still good on the day first",
+			ingredients: []ScaledIngredient{{Name: "milk", Quantity: 1.5, Unit: "L"}},
+			reservations: []models.PantryReservation{
+				{GroceryItemID: 2, Ingredient: "milk", Quantity: 1},
+				{GroceryItemID: 3, Ingredient: "milk", Quantity: 500},
+			},
+		},
+		{
+			name:        "stock expiring before the meal last",
+			ingredients: []ScaledIngredient{{Name: "milk", Quantity: 2, Unit: "L"}},
+			reservations: []models.PantryReservation{
+				{GroceryItemID: 2, Ingredient: "milk", Quantity: 1},
+				{GroceryItemID: 3, Ingredient: "milk", Quantity: 500},
+				{GroceryItemID: 1, Ingredient: "milk", Quantity: 0.5},
+			},
+		},
+		{
+			name:        "stock reserved for other meals is skipped",
+			ingredients: []ScaledIngredient{{Name: "milk", Quantity: 1000, Unit: "ml"}},
+			reserved:    map[uint]float64{2: 0.75, 3: 500},
+			reservations: []models.PantryReservation{
+				{GroceryItemID: 2, Ingredient: "milk", Quantity: 0.25},
+				{GroceryItemID: 1, Ingredient: "milk", Quantity: 0.75},
+			},
+		},
+		{
+			name: "lines of the same recipe share the stock",
+			ingredients: []ScaledIngredient{
+				{Name: "milk", Quantity: 1, Unit: "L"},
+				{Name: "milk", Quantity: 1, Unit: "cup"},
+			},
+			reservations: []models.PantryReservation{
+				{GroceryItemID: 2, Ingredient: "milk", Quantity: 1},
+				{GroceryItemID: 3, Ingredient: "milk", Quantity: 236.588},
+			},
+		},
+		{
+			name:         "not enough",
+			ingredients:  []ScaledIngredient{{Name: "eggs", Quantity: 8}},
+			reservations: []models.PantryReservation{{GroceryItemID: 4, Ingredient: "eggs", Quantity: 6}},
+			shortfalls:   []stockShortfall{{Name: "eggs", Quantity: 2}},
+		},
+		{
+			name:        "all reserved",
+			ingredients: []ScaledIngredient{{Name: "eggs", Quantity: 2}},
+			reserved:    map[uint]float64{4: 6},
+			shortfalls:  []stockShortfall{{Name: "eggs", Quantity: 2}},
+		},
+		{
+			name:        "not in the pantry",
+			ingredients: []ScaledIngredient{{Name: "butter", Quantity: 50, Unit: "g"}},
+			shortfalls:  []stockShortfall{{Name: "butter", Quantity: 50, Unit: "g", Missing: true}},
+		},
+		{
+			name:        "expired stock isn't used",
+			ingredients: []ScaledIngredient{{Name: "yogurt", Quantity: 200, Unit: "g"}},
+			shortfalls:  []stockShortfall{{Name: "yogurt", Quantity: 200, Unit: "g", Missing: true}},
+		},
+		{
+			name: "nothing to reserve",
+			ingredients: []ScaledIngredient{
+				{Name: "flour", Quantity: 2, Unit: "cups"}, // Units can't be compared
+				{Name: "eggs"}, // No quantity
+				{Name: "parsley", Quantity: 1, Unit: "bunch", Optional: true},
+				{Name: "olive oil", Quantity: 2, Unit: "tbsp"}, // A staple
+				{Name: "water", Quantity: 1, Unit: "cup"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reserved := map[uint]float64{}
+			for id, quantity := range tt.reserved {
+				reserved[id] = quantity
+			}
+
+			reservations, shortfalls := allocateStock(tt.ingredients, groceries, reserved, date, []string{"olive oil"})
+			if !reflect.DeepEqual(reservations, tt.reservations) {
+				t.Errorf("reservations = %+v, want %+v", reservations, tt.reservations)
+			}
+			if !reflect.DeepEqual(shortfalls, tt.shortfalls) {
+				t.Errorf("shortfalls = %+v, want %+v", shortfalls, tt.shortfalls)
+			}
+		})
+	}
+}
@@ -1,136 +1,86 @@
 package services
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
-	"log"
-	"os"
+	"strconv"
+	"strings"
 	"time"
+	"zero-waste-kitchen/internal/i18n"
 	"zero-waste-kitchen/internal/models"
-
-	firebase "firebase.google.com/go/v4"
-	"firebase.google.com/go/v4/messaging"
-	"google.golang.org/api/option"
+	"zero-waste-kitchen/internal/notify"
 )
 
-var (
-	fcmClient *messaging.Client
-)
+// expiryTemplateData is what the expiry_alert and expiry_digest templates of
+// the locale files are rendered with
+type expiryTemplateData struct {
+	Count int
+	Days  int // Calendar days until the first item expires, in the user's time zone
+	Items []models.GroceryItem
+	Names []string
+}
 
-func InitializeFirebase() error {
-	ctx := context.Background()
+// expiryAlertMessage announces items that reached one of the user's lead times
+func expiryAlertMessage(p *i18n.Printer, items []models.GroceryItem, now time.Time) (notify.Message, error) {
+	return expiryMessage(p, "expiry_alert", items, now)
+}
 
-	// Use environment variables for Firebase configuration
-	conf := &firebase.Config{
-		ProjectID: os.Getenv("FIREBASE_PROJECT_ID"),
-	}
+// expiryDigestMessage is the daily summary of items expiring soon
+func expiryDigestMessage(p *i18n.Printer, items []models.GroceryItem, now time.Time) (notify.Message, error) {
+	return expiryMessage(p, "expiry_digest", items, now)
+}
 
-	// Ensure the service account key file exists
-	opt := option.WithCredentialsFile("serviceAccountKey.json")
-	if _, err := os.Stat("serviceAccountKey.json"); os.IsNotExist(err) {
-		return fmt.Errorf("serviceAccountKey.json file not found")
+// expiryMessage renders the kind.title and kind.body templates in the printer's
+// locale. Email and push send the same title and body.
+func expiryMessage(p *i18n.Printer, kind string, items []models.GroceryItem, now time.Time) (notify.Message, error) {
+	data := expiryTemplateData{
+		Count: len(items),
+		Days:  daysUntil(now, items[0].ExpiryDate, p.Location()),
+		Items: items,
+		Names: make([]string, len(items)),
 	}
-
-	app, err := firebase.NewApp(ctx, conf, opt)
-	if err != nil {
-		return fmt.Errorf("error initializing Firebase app: %v", err)
+	for i, item := range items {
+		data.Names[i] = item.Name
 	}
 
-	fcmClient, err = app.Messaging(ctx)
+	title, err := p.Render(kind+".title", data)
 	if err != nil {
-		return fmt.Errorf("error getting Firebase messaging client: %v", err)
-	}
-
-	log.Println("Firebase Cloud Messaging initialized successfully")
-	return nil
-}
-
-func SendExpiryNotification(user models.User, items []models.GroceryItem) {
-	if fcmClient == nil {
-		log.Println("FCM client not initialized")
-		return
+		return notify.Message{}, fmt.Errorf("failed to render notification title: %w", err)
 	}
-
-	ctx := context.Background()
-
-	if user.FCMToken == "" {
-		log.Printf("User %s has no FCM token registered", user.Email)
-		return
+	body, err := p.Render(kind+".body", data)
+	if err != nil {
+		return notify.Message{}, fmt.Errorf("failed to render notification body: %w", err)
 	}
 
-	// Calculate days left until expiry
-	daysLeft := int(time.Until(items[0].ExpiryDate).Hours() / 24)
-	notificationTitle := fmt.Sprintf("%d items expiring in %d days", len(items), daysLeft)
-
-	message := &messaging.Message{
-		Token: user.FCMToken,
-		Notification: &messaging.Notification{
-			Title: notificationTitle,
-			Body:  prepareNotificationBody(items),
-		},
+	return notify.Message{
+		Title: title,
+		Body:  body,
+		Kind:  kind,
 		Data: map[string]string{
-			"type":    "expiry_alert",
-			"count":   fmt.Sprintf("%d", len(items)),
-			"details": prepareItemsJSON(items),
-		},
-		Android: &messaging.AndroidConfig{
-			Priority: "high",
+			"count":    fmt.Sprintf("%d", len(items)),
+			"details":  prepareItemsJSON(items),
+			"item_ids": joinItemIDs(items),
 		},
-		APNS: &messaging.APNSConfig{
-			Headers: map[string]string{
-				"apns-priority": "10",
-			},
-		},
-	}
-
-	response, err := fcmClient.Send(ctx, message)
-	if err != nil {
-		log.Printf("Failed to send FCM message to user %s: %v", user.Email, err)
-		return
-	}
-
-	log.Printf("Successfully sent FCM message to user %s: %v", user.Email, response)
+	}, nil
 }
 
-func SendPushNotification(token, message string) error {
-	// Ensure Firebase is initialized
-	if fcmClient == nil {
-		return fmt.Errorf("FCM client not initialized. Call InitializeFirebase first")
-	}
-
-	ctx := context.Background()
-
-	msg := &messaging.Message{
-		Token: token,
-		Notification: &messaging.Notification{
-			Title: "Grocery Notification",
-			Body:  message,
-		},
-	}
-
-	// Send the notification and log the error if it fails
-	response, err := fcmClient.Send(ctx, msg)
-	if err != nil {
-		return fmt.Errorf("FCM send failed: %v", err)
+// daysUntil counts the calendar days from now to t in loc, so an item expiring
+// tomorrow morning is 1 day away even if that's only a few hours. Past dates count as 0.
+func daysUntil(now, t time.Time, loc *time.Location) int {
+	days := int(calendarDate(t, loc).Sub(calendarDate(now, loc)).Hours() / 24)
+	if days < 0 {
+		return 0
 	}
-
-	// Log the response for debugging
-	log.Printf("FCM response: %s\n", response)
-	return nil
+	return days
 }
 
-func prepareNotificationBody(items []models.GroceryItem) string {
-	if len(items) == 1 {
-		return fmt.Sprintf("%s is expiring on %s", items[0].Name, items[0].ExpiryDate.Format("Jan 2"))
-	}
-
-	itemNames := make([]string, 0, len(items))
-	for _, item := range items {
-		itemNames = append(itemNames, item.Name)
+// joinItemIDs lists the item IDs for deep links, e.g. "4,9,12"
+func joinItemIDs(items []models.GroceryItem) string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = strconv.FormatUint(uint64(item.ID), 10)
 	}
-
-	return fmt.Sprintf("Items: %s", joinStringsWithAnd(itemNames))
+	return strings.Join(ids, ",")
 }
 
 func prepareItemsJSON(items []models.GroceryItem) string {
@@ -154,16 +104,9 @@ func prepareItemsJSON(items []models.GroceryItem) string {
 	return string(jsonData)
 }
 
-func joinStringsWithAnd(items []string) string {
-	if len(items) == 0 {
-		return ""
-	}
-	if len(items) == 1 {
-		return items[0]
-	}
-	if len(items) == 2 {
-		return items[0] + " and " + items[1]
-	}
-
-	return items[0] + ", " + joinStringsWithAnd(items[1:])
+// calendarDate is midnight UTC on t's date in loc, so dates can be subtracted
+// without daylight saving getting in the way
+func calendarDate(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
 }
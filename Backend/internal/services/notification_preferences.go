@@ -0,0 +1,548 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"zero-waste-kitchen/internal/i18n"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/notify"
+	"zero-waste-kitchen/internal/repositories"
+)
+
+// sendTimeout bounds a single delivery so a slow channel can't hold up the others
+const sendTimeout = 30 * time.Second
+
+const (
+	clockLayout     = "15:04"
+	maxLeadDays     = 30
+	maxLeadTimes    = 5
+	defaultDigestAt = "08:00"
+)
+
+// defaultLeadDays match the 7, 3 and 1 day alerts sent before preferences existed
+var defaultLeadDays = []int{7, 3, 1}
+
+var notificationChannels = []string{models.ChannelPush, models.ChannelEmail, models.ChannelWebhook}
+
+// defaultChannels keep new users on push only, as before channels were configurable
+var defaultChannels = []string{models.ChannelPush}
+
+type NotificationService interface {
+	GetPreferences(userID uint) (*models.NotificationPreferences, error)
+	UpdatePreferences(prefs *models.NotificationPreferences) error
+	SendExpiryAlerts(ctx context.Context, now time.Time) error
+	Notify(userID uint, msg notify.Message) ([]string, error)
+	GetSentNotifications(filter models.SentNotificationFilter) ([]models.SentNotification, int64, error)
+	GetInbox(userID uint, unreadOnly bool, limit, offset int) (*Inbox, error)
+	MarkRead(userID uint, ids []uint) (int64, error)
+}
+
+// Inbox is a page of a user's in-app notifications
+type Inbox struct {
+	Notifications []models.InboxNotification `json:"notifications"`
+	Total         int64                      `json:"total"`  // Matching notifications across all pages
+	Unread        int64                      `json:"unread"` // Unread notifications overall
+}
+
+type notificationService struct {
+	repo             repositories.NotificationRepository
+	userRepo         repositories.UserRepository
+	groceryRepo      repositories.GroceryRepository
+	householdService HouseholdService
+	deviceRepo       repositories.DeviceRepository
+	notifiers        map[string]notify.Notifier // By channel
+}
+
+// NewNotificationService delivers on the given notifiers, keyed by channel.
+// Channels without a notifier are skipped.
+func NewNotificationService(repo repositories.NotificationRepository, userRepo repositories.UserRepository, groceryRepo repositories.GroceryRepository, householdService HouseholdService, deviceRepo repositories.DeviceRepository, notifiers map[string]notify.Notifier) NotificationService {
+	return &notificationService{repo: repo, userRepo: userRepo, groceryRepo: groceryRepo, householdService: householdService, deviceRepo: deviceRepo, notifiers: notifiers}
+}
+
+// GetPreferences returns the user's notification preferences, or the defaults if none were saved yet
+func (s *notificationService) GetPreferences(userID uint) (*models.NotificationPreferences, error) {
+	prefs, err := s.repo.FindPreferences(userID)
+	if errors.Is(err, repositories.ErrRecordNotFound) {
+		return defaultNotificationPreferences(userID), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences validates and stores the user's notification preferences.
+// Empty fields get their defaults; an empty channel list mutes all alerts.
+func (s *notificationService) UpdatePreferences(prefs *models.NotificationPreferences) error {
+	if err := normalizePreferences(prefs); err != nil {
+		return err
+	}
+
+	existing, err := s.repo.FindPreferences(prefs.UserID)
+	if err != nil && !errors.Is(err, repositories.ErrRecordNotFound) {
+		return fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	if existing != nil {
+		prefs.ID = existing.ID
+		prefs.CreatedAt = existing.CreatedAt
+		prefs.LastDigestAt = existing.LastDigestAt
+	}
+
+	if err := s.repo.SavePreferences(prefs); err != nil {
+		return fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	return nil
+}
+
+// Notify puts a message in a user's inbox and sends it on each of their enabled
+// channels, ignoring quiet hours. Messages without a title get a generic one in
+// the user's language. It returns the channels that took it, the inbox included.
+func (s *notificationService) Notify(userID uint, msg notify.Message) ([]string, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	prefs, err := s.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.Title == "" {
+		msg.Title, err = userPrinter(user).Render("notification.default_title", nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render notification title: %w", err)
+		}
+	}
+
+	return s.deliver(*user, prefs, msg)
+}
+
+// GetInbox returns a page of the user's in-app notifications, newest first
+func (s *notificationService) GetInbox(userID uint, unreadOnly bool, limit, offset int) (*Inbox, error) {
+	notifications, total, err := s.repo.FindInbox(userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notifications: %w", err)
+	}
+	unread, err := s.repo.CountUnread(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return &Inbox{Notifications: notifications, Total: total, Unread: unread}, nil
+}
+
+// MarkRead marks the given notifications as read, or all of them when ids is
+// empty, and returns how many were unread
+func (s *notificationService) MarkRead(userID uint, ids []uint) (int64, error) {
+	count, err := s.repo.MarkRead(userID, ids, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications as read: %w", err)
+	}
+	return count, nil
+}
+
+// GetSentNotifications returns a page of the sent-notification ledger, newest first
+func (s *notificationService) GetSentNotifications(filter models.SentNotificationFilter) ([]models.SentNotification, int64, error) {
+	sent, total, err := s.repo.FindSent(filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get sent notifications: %w", err)
+	}
+	return sent, total, nil
+}
+
+// deliver puts a message in the user's inbox, sends it on their enabled channels
+// and returns the channels that took it, the inbox included
+func (s *notificationService) deliver(user models.User, prefs *models.NotificationPreferences, msg notify.Message) ([]string, error) {
+	return s.deliverOn(user, prefs, append([]string{models.ChannelInbox}, prefs.Channels...), msg)
+}
+
+// deliverOn delivers a message on the given channels, the inbox being one of
+// them, and returns the channels that took it
+func (s *notificationService) deliverOn(user models.User, prefs *models.NotificationPreferences, channels []string, msg notify.Message) ([]string, error) {
+	var sent []string
+	if containsString(channels, models.ChannelInbox) {
+		inbox := &models.InboxNotification{
+			UserID: user.ID,
+			Title:  msg.Title,
+			Body:   msg.Body,
+			Kind:   msg.Kind,
+			Data:   msg.Data,
+		}
+		if err := s.repo.CreateInbox(inbox); err != nil {
+			return nil, fmt.Errorf("failed to save notification: %w", err)
+		}
+		sent = append(sent, models.ChannelInbox)
+	}
+
+	// The message is in the inbox now, so carry on without push rather than fail
+	tokens, err := s.deviceRepo.FindTokens(user.ID)
+	if err != nil {
+		log.Printf("Failed to get devices of user %d: %v", user.ID, err)
+	}
+	to := notify.Recipient{
+		UserID:     user.ID,
+		Name:       user.Name,
+		Email:      user.Email,
+		FCMTokens:  tokens,
+		WebhookURL: prefs.WebhookURL,
+	}
+
+	for _, channel := range channels {
+		notifier, ok := s.notifiers[channel]
+		if !ok {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		err := notifier.Send(ctx, to, msg)
+		cancel()
+		switch {
+		case errors.Is(err, notify.ErrNoAddress):
+			// Nothing to send to, e.g. the app never registered for push
+		case err != nil:
+			log.Printf("Failed to send %s notification to user %d: %v", channel, user.ID, err)
+		default:
+			sent = append(sent, channel)
+		}
+	}
+	return sent, nil
+}
+
+// SendExpiryAlerts sends every user the expiry alerts that are due at now,
+// following their lead times, quiet hours, delivery mode and channels. The
+// scheduler runs it every few minutes; it stops between users when ctx ends.
+func (s *notificationService) SendExpiryAlerts(ctx context.Context, now time.Time) error {
+	users, err := s.userRepo.FindAll()
+	if err != nil {
+		return fmt.Errorf("failed to get users: %w", err)
+	}
+	saved, err := s.repo.FindAllPreferences()
+	if err != nil {
+		return fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	byUser := make(map[uint]*models.NotificationPreferences, len(saved))
+	for i := range saved {
+		byUser[saved[i].UserID] = &saved[i]
+	}
+
+	for _, user := range users {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		prefs := byUser[user.ID]
+		if prefs == nil {
+			prefs = defaultNotificationPreferences(user.ID)
+		}
+		if err := s.alertUser(user, prefs, now); err != nil {
+			log.Printf("Failed to send expiry alerts to user %d: %v", user.ID, err)
+		}
+	}
+	return nil
+}
+
+// alertUser sends one user the alerts that are due and records them in the ledger
+func (s *notificationService) alertUser(user models.User, prefs *models.NotificationPreferences, now time.Time) error {
+	printer := userPrinter(&user)
+	if inQuietHours(prefs, now.In(printer.Location())) {
+		return nil // Alerts wait until quiet hours are over
+	}
+
+	digest := prefs.Delivery == models.DeliveryDigest
+	if digest && !digestDue(prefs, now, printer.Location()) {
+		return nil
+	}
+
+	householdID, _, err := s.householdService.Membership(user.ID)
+	if err != nil {
+		return err
+	}
+	groceries, err := s.groceryRepo.FindAll(householdID)
+	if err != nil {
+		return fmt.Errorf("failed to get household groceries: %w", err)
+	}
+
+	thresholds := alertThresholds(prefs, groceries, now)
+	if digest {
+		prefs.LastDigestAt = &now
+		if err := s.repo.SavePreferences(prefs); err != nil {
+			return fmt.Errorf("failed to save notification preferences: %w", err)
+		}
+		if len(thresholds.items) == 0 {
+			return nil
+		}
+
+		msg, err := expiryDigestMessage(printer, thresholds.items, now)
+		if err != nil {
+			return err
+		}
+		channels, err := s.deliver(user, prefs, msg)
+		if err != nil {
+			return err
+		}
+		// Items listed in a digest count as alerted too, so switching to instant
+		// alerts doesn't repeat them
+		return s.recordAlerts(user.ID, thresholds, thresholds.items, channels, msg.Kind, now)
+	}
+
+	ids := make([]uint, len(thresholds.items))
+	for i, item := range thresholds.items {
+		ids[i] = item.ID
+	}
+	sent, err := s.repo.FindSentForItems(user.ID, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get sent notifications: %w", err)
+	}
+
+	// Each channel gets the alerts it hasn't taken yet, so a channel that failed
+	// is retried on the next run without repeating the alert on the others
+	var channels []string
+	for _, channel := range append([]string{models.ChannelInbox}, prefs.Channels...) {
+		if _, ok := s.notifiers[channel]; ok || channel == models.ChannelInbox {
+			channels = append(channels, channel)
+		}
+	}
+	for _, pending := range pendingAlerts(thresholds, sent, channels) {
+		msg, err := expiryAlertMessage(printer, pending.items, now)
+		if err != nil {
+			return err
+		}
+		delivered, err := s.deliverOn(user, prefs, pending.channels, msg)
+		if err != nil {
+			return err
+		}
+		if err := s.recordAlerts(user.ID, thresholds, pending.items, delivered, msg.Kind, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordAlerts adds the items alerted on each of channels to the ledger
+func (s *notificationService) recordAlerts(userID uint, thresholds itemThresholds, items []models.GroceryItem, channels []string, kind string, now time.Time) error {
+	var entries []models.SentNotification
+	for _, channel := range channels {
+		for _, item := range items {
+			entries = append(entries, models.SentNotification{
+				UserID:        userID,
+				GroceryItemID: item.ID,
+				Threshold:     thresholds.days[item.ID],
+				Channel:       channel,
+				Kind:          kind,
+				ItemName:      item.Name,
+				SentAt:        now,
+			})
+		}
+	}
+	if err := s.repo.RecordSent(entries); err != nil {
+		return fmt.Errorf("failed to record sent notifications: %w", err)
+	}
+	return nil
+}
+
+// itemThresholds are the unexpired items within one of the lead times, sorted
+// by expiry, with the shortest lead time each has reached
+type itemThresholds struct {
+	items []models.GroceryItem
+	days  map[uint]int
+}
+
+func alertThresholds(prefs *models.NotificationPreferences, groceries []models.GroceryItem, now time.Time) itemThresholds {
+	thresholds := itemThresholds{days: map[uint]int{}}
+	for _, item := range groceries {
+		if item.ExpiryDate.IsZero() || !item.ExpiryDate.After(now) {
+			continue
+		}
+		// Lead days are sorted longest first, so the last one reached wins
+		reached := 0
+		for _, days := range prefs.LeadDays {
+			if !item.ExpiryDate.AddDate(0, 0, -days).After(now) {
+				reached = days
+			}
+		}
+		if reached > 0 {
+			thresholds.items = append(thresholds.items, item)
+			thresholds.days[item.ID] = reached
+		}
+	}
+	sortByExpiry(thresholds.items)
+	return thresholds
+}
+
+// pendingAlert is the items still to be alerted on some channels
+type pendingAlert struct {
+	channels []string
+	items    []models.GroceryItem
+}
+
+// pendingAlerts finds the items each channel hasn't alerted at their current lead
+// time, going by the ledger, and groups the channels missing the same items.
+// An item that jumped past several lead times at once is alerted only for the shortest.
+func pendingAlerts(thresholds itemThresholds, sent []models.SentNotification, channels []string) []pendingAlert {
+	type ledgerKey struct {
+		itemID    uint
+		threshold int
+		channel   string
+	}
+	done := map[ledgerKey]bool{}
+	for _, entry := range sent {
+		done[ledgerKey{entry.GroceryItemID, entry.Threshold, entry.Channel}] = true
+	}
+
+	var pending []pendingAlert
+	byItems := map[string]int{} // Index in pending by the items' IDs
+	for _, channel := range channels {
+		var items []models.GroceryItem
+		var ids []string
+		for _, item := range thresholds.items {
+			if !done[ledgerKey{item.ID, thresholds.days[item.ID], channel}] {
+				items = append(items, item)
+				ids = append(ids, strconv.FormatUint(uint64(item.ID), 10))
+			}
+		}
+		if len(items) == 0 {
+			continue
+		}
+
+		key := strings.Join(ids, ",")
+		if i, ok := byItems[key]; ok {
+			pending[i].channels = append(pending[i].channels, channel)
+			continue
+		}
+		byItems[key] = len(pending)
+		pending = append(pending, pendingAlert{channels: []string{channel}, items: items})
+	}
+	return pending
+}
+
+// digestDue reports whether today's digest hasn't gone out yet and its time has come
+func digestDue(prefs *models.NotificationPreferences, now time.Time, loc *time.Location) bool {
+	local := now.In(loc)
+	if prefs.LastDigestAt != nil && prefs.LastDigestAt.In(loc).Format(dateLayout) == local.Format(dateLayout) {
+		return false
+	}
+	digestAt, err := time.Parse(clockLayout, prefs.DigestTime)
+	if err != nil {
+		digestAt, _ = time.Parse(clockLayout, defaultDigestAt)
+	}
+	return minuteOfDay(local) >= minuteOfDay(digestAt)
+}
+
+// inQuietHours reports whether a local time falls within the quiet hours, which may span midnight
+func inQuietHours(prefs *models.NotificationPreferences, local time.Time) bool {
+	start, err := time.Parse(clockLayout, prefs.QuietHoursStart)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(clockLayout, prefs.QuietHoursEnd)
+	if err != nil {
+		return false
+	}
+
+	from, to, minute := minuteOfDay(start), minuteOfDay(end), minuteOfDay(local)
+	switch {
+	case from == to:
+		return false
+	case from < to:
+		return minute >= from && minute < to
+	default:
+		return minute >= from || minute < to
+	}
+}
+
+func minuteOfDay(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
+
+// userPrinter renders text in the user's language and time zone
+func userPrinter(user *models.User) *i18n.Printer {
+	return i18n.NewPrinter(user.Locale, user.TimeZone)
+}
+
+func sortByExpiry(items []models.GroceryItem) {
+	sort.SliceStable(items, func(i, j int) bool { return items[i].ExpiryDate.Before(items[j].ExpiryDate) })
+}
+
+func defaultNotificationPreferences(userID uint) *models.NotificationPreferences {
+	return &models.NotificationPreferences{
+		UserID:     userID,
+		LeadDays:   append([]int{}, defaultLeadDays...),
+		Delivery:   models.DeliveryInstant,
+		DigestTime: defaultDigestAt,
+		Channels:   append([]string{}, defaultChannels...),
+	}
+}
+
+// normalizePreferences validates preferences and fills in defaults for empty fields
+func normalizePreferences(prefs *models.NotificationPreferences) error {
+	defaults := defaultNotificationPreferences(prefs.UserID)
+
+	if len(prefs.LeadDays) == 0 {
+		prefs.LeadDays = defaults.LeadDays
+	}
+	seen := map[int]bool{}
+	leadDays := make([]int, 0, len(prefs.LeadDays))
+	for _, days := range prefs.LeadDays {
+		if days < 1 || days > maxLeadDays {
+			return fmt.Errorf("%w: lead days must be between 1 and %d", models.ErrInvalidNotificationPreferences, maxLeadDays)
+		}
+		if !seen[days] {
+			seen[days] = true
+			leadDays = append(leadDays, days)
+		}
+	}
+	if len(leadDays) > maxLeadTimes {
+		return fmt.Errorf("%w: at most %d lead times", models.ErrInvalidNotificationPreferences, maxLeadTimes)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(leadDays)))
+	prefs.LeadDays = leadDays
+
+	switch prefs.Delivery {
+	case "":
+		prefs.Delivery = defaults.Delivery
+	case models.DeliveryInstant, models.DeliveryDigest:
+	default:
+		return fmt.Errorf("%w: delivery must be instant or digest", models.ErrInvalidNotificationPreferences)
+	}
+
+	if prefs.DigestTime == "" {
+		prefs.DigestTime = defaults.DigestTime
+	}
+	for _, clock := range []string{prefs.DigestTime, prefs.QuietHoursStart, prefs.QuietHoursEnd} {
+		if _, err := time.Parse(clockLayout, clock); clock != "" && err != nil {
+			return fmt.Errorf("%w: times must be written as HH:MM", models.ErrInvalidNotificationPreferences)
+		}
+	}
+	if (prefs.QuietHoursStart == "") != (prefs.QuietHoursEnd == "") {
+		return fmt.Errorf("%w: quiet hours need both a start and an end", models.ErrInvalidNotificationPreferences)
+	}
+
+	if prefs.Channels == nil {
+		prefs.Channels = defaults.Channels
+	}
+	channels := make([]string, 0, len(prefs.Channels))
+	for _, channel := range cleanTerms(prefs.Channels) {
+		if !containsString(notificationChannels, channel) {
+			return fmt.Errorf("%w: unknown channel %s", models.ErrInvalidNotificationPreferences, channel)
+		}
+		channels = append(channels, channel)
+	}
+	prefs.Channels = channels
+
+	if prefs.WebhookURL != "" || containsString(channels, models.ChannelWebhook) {
+		u, err := url.Parse(prefs.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: webhook channel needs an http or https webhook_url", models.ErrInvalidNotificationPreferences)
+		}
+		defaultPort := map[string]string{"http": "80", "https": "443"}[u.Scheme]
+		if port := u.Port(); !notify.IsPublicHost(u) || (port != "" && port != defaultPort) {
+			return fmt.Errorf("%w: webhook_url must point to a public address on the default port", models.ErrInvalidNotificationPreferences)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"zero-waste-kitchen/internal/models"
+)
+
+func TestPendingAlerts(t *testing.T) {
+	thresholds := itemThresholds{
+		items: []models.GroceryItem{{ID: 1, Name: "milk"}, {ID: 2, Name: "spinach"}},
+		days:  map[uint]int{1: 1, 2: 3},
+	}
+	channels := []string{models.ChannelPush, models.ChannelEmail, models.ChannelWebhook}
+	sentOn := func(itemID uint, threshold int, channel string) models.SentNotification {
+		return models.SentNotification{GroceryItemID: itemID, Threshold: threshold, Channel: channel}
+	}
+
+	tests := []struct {
+		name string
+		sent []models.SentNotification
+		want map[string][]uint // Item IDs by the channels alerting them
+	}{
+		{
+			name: "nothing sent",
+			want: map[string][]uint{"push,email,webhook": {1, 2}},
+		},
+		{
+			name: "failed channel is retried alone",
+			sent: []models.SentNotification{
+				sentOn(1, 1, models.ChannelPush), sentOn(2, 3, models.ChannelPush),
+				sentOn(1, 1, models.ChannelWebhook), sentOn(2, 3, models.ChannelWebhook),
+			},
+			want: map[string][]uint{"email": {1, 2}},
+		},
+		{
+			name: "channels missing different items",
+			sent: []models.SentNotification{sentOn(1, 1, models.ChannelPush), sentOn(1, 1, models.ChannelEmail)},
+			want: map[string][]uint{"push,email": {2}, "webhook": {1, 2}},
+		},
+		{
+			name: "a longer lead time doesn't count",
+			sent: []models.SentNotification{
+				sentOn(1, 3, models.ChannelPush), sentOn(1, 3, models.ChannelEmail), sentOn(1, 3, models.ChannelWebhook),
+				sentOn(2, 3, models.ChannelPush), sentOn(2, 3, models.ChannelEmail), sentOn(2, 3, models.ChannelWebhook),
+			},
+			want: map[string][]uint{"push,email,webhook": {1}},
+		},
+		{
+			name: "all sent",
+			sent: []models.SentNotification{
+				sentOn(1, 1, models.ChannelPush), sentOn(1, 1, models.ChannelEmail), sentOn(1, 1, models.ChannelWebhook),
+				sentOn(2, 3, models.ChannelPush), sentOn(2, 3, models.ChannelEmail), sentOn(2, 3, models.ChannelWebhook),
+			},
+			want: map[string][]uint{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string][]uint{}
+			for _, pending := range pendingAlerts(thresholds, tt.sent, channels) {
+				key := strings.Join(pending.channels, ",")
+				for _, item := range pending.items {
+					got[key] = append(got[key], item.ID)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pending alerts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
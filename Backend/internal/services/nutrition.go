@@ -0,0 +1,494 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/repositories"
+)
+
+const (
+	// defaultSummaryWeeks and maxSummaryWeeks bound the weekly nutrition summary
+	defaultSummaryWeeks = 4
+	maxSummaryWeeks     = 52
+
+	// BundledNutritionSource labels foods imported from the bundled CSV, a small
+	// hand-compiled table of common pantry foods
+	BundledNutritionSource = "bundled"
+)
+
+var ErrNutritionFoodNotFound = errors.New("nutrition food not found")
+
+// nutritionColumns maps the normalized CSV headers of common nutrition exports,
+// including USDA SR Legacy, to NutritionFood fields
+var nutritionColumns = map[string]string{
+	"name": "name", "description": "name", "food": "name", "food_name": "name", "shrt_desc": "name", "long_desc": "name",
+	"category": "category", "food_category": "category", "food_group": "category",
+	"source_id": "source_id", "fdc_id": "source_id", "ndb_no": "source_id",
+	"calories": "calories", "energy": "calories", "energy_kcal": "calories", "kcal": "calories", "energ_kcal": "calories",
+	"protein": "protein", "protein_g": "protein",
+	"fat": "fat", "fat_g": "fat", "total_fat": "fat", "total_lipid_fat_g": "fat", "lipid_tot_g": "fat",
+	"carbohydrates": "carbohydrates", "carbohydrate": "carbohydrates", "carbs": "carbohydrates",
+	"carbohydrate_g": "carbohydrates", "carbohydrate_by_difference_g": "carbohydrates", "carbohydrt_g": "carbohydrates",
+	"fiber": "fiber", "fiber_g": "fiber", "fiber_total_dietary_g": "fiber", "fiber_td_g": "fiber",
+	"sugar": "sugar", "sugars": "sugar", "sugar_g": "sugar", "sugars_total_g": "sugar", "sugar_tot_g": "sugar",
+	"sodium": "sodium", "sodium_mg": "sodium", "sodium_na_mg": "sodium",
+	"grams_per_piece": "grams_per_piece", "gmwt_1": "grams_per_piece",
+	"density": "density",
+}
+
+// IngredientNutrition is the nutrition of one recipe ingredient
+type IngredientNutrition struct {
+	Name      string           `json:"name"`
+	Food      string           `json:"food"` // Matched nutrition database entry
+	Grams     float64          `json:"grams"`
+	Nutrition models.Nutrition `json:"nutrition"`
+}
+
+// RecipeNutrition is the estimated nutrition of a recipe
+type RecipeNutrition struct {
+	RecipeID    uint                  `json:"recipe_id"`
+	Servings    int                   `json:"servings"`
+	PerServing  models.Nutrition      `json:"per_serving"`
+	Total       models.Nutrition      `json:"total"`
+	Ingredients []IngredientNutrition `json:"ingredients"`
+	Unmatched   []string              `json:"unmatched"` // Ingredients left out of the estimate
+}
+
+// WeeklyNutrition sums up what the user consumed in one week, starting Monday (UTC)
+type WeeklyNutrition struct {
+	WeekStart    time.Time        `json:"week_start"`
+	Items        int              `json:"items"`
+	Total        models.Nutrition `json:"total"`
+	DailyAverage models.Nutrition `json:"daily_average"`
+}
+
+type NutritionService interface {
+	ImportCSV(r io.Reader, source string) (int, error)
+	ImportBundled(path string) (int, error)
+	SearchFoods(query string, limit int) ([]models.NutritionFood, error)
+	RecipeNutrition(householdID uint, recipeID uint) (*RecipeNutrition, error)
+	ConsumeGrocery(userID uint, householdID uint, groceryID uint, quantity float64) (*models.ConsumptionEntry, error)
+	WeeklySummary(userID uint, weeks int) ([]WeeklyNutrition, error)
+}
+
+type nutritionService struct {
+	repo        repositories.NutritionRepository
+	groceryRepo repositories.GroceryRepository
+	recipeRepo  repositories.RecipeRepository
+
+	// foods caches the nutrition database for name matching until the next import
+	mu    sync.RWMutex
+	foods []models.NutritionFood
+}
+
+func NewNutritionService(repo repositories.NutritionRepository, groceryRepo repositories.GroceryRepository, recipeRepo repositories.RecipeRepository) NutritionService {
+	return &nutritionService{repo: repo, groceryRepo: groceryRepo, recipeRepo: recipeRepo}
+}
+
+// ImportCSV imports foods with per-100 g values from CSV and returns how many were
+// saved. Foods that already exist are updated. Headers are matched loosely, so
+// flat exports such as USDA SR Legacy's ABBREV file work as they are.
+func (s *nutritionService) ImportCSV(r io.Reader, source string) (int, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if field, ok := nutritionColumns[normalizeHeader(name)]; ok {
+			if _, seen := columns[field]; !seen {
+				columns[field] = i
+			}
+		}
+	}
+	if _, ok := columns["name"]; !ok {
+		return 0, errors.New("CSV is missing a name or description column")
+	}
+
+	byName := map[string]models.NutritionFood{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read CSV line %d: %w", line, err)
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		var numberErr error
+		number := func(name string) float64 {
+			value := field(name)
+			if value == "" {
+				return 0
+			}
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil || n < 0 {
+				numberErr = fmt.Errorf("CSV line %d: invalid %s %q", line, name, value)
+			}
+			return n
+		}
+
+		food := models.NutritionFood{
+			Name:     strings.ToLower(field("name")),
+			Category: field("category"),
+			Source:   source,
+			SourceID: field("source_id"),
+			Nutrition: models.Nutrition{
+				Calories:      number("calories"),
+				Protein:       number("protein"),
+				Fat:           number("fat"),
+				Carbohydrates: number("carbohydrates"),
+				Fiber:         number("fiber"),
+				Sugar:         number("sugar"),
+				Sodium:        number("sodium"),
+			},
+			GramsPerPiece: number("grams_per_piece"),
+			Density:       number("density"),
+		}
+		if numberErr != nil {
+			return 0, numberErr
+		}
+		if food.Name == "" {
+			return 0, fmt.Errorf("CSV line %d: name is required", line)
+		}
+		byName[food.Name] = food // Later rows win
+	}
+
+	foods := make([]models.NutritionFood, 0, len(byName))
+	for _, food := range byName {
+		foods = append(foods, food)
+	}
+	if err := s.repo.Upsert(foods); err != nil {
+		return 0, fmt.Errorf("failed to save nutrition foods: %w", err)
+	}
+
+	s.mu.Lock()
+	s.foods = nil
+	s.mu.Unlock()
+
+	return len(foods), nil
+}
+
+// ImportBundled seeds an empty nutrition database from the CSV at path
+func (s *nutritionService) ImportBundled(path string) (int, error) {
+	count, err := s.repo.Count()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count nutrition foods: %w", err)
+	}
+	if count > 0 {
+		return 0, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	return s.ImportCSV(file, BundledNutritionSource)
+}
+
+// normalizeHeader turns a header such as "Energy (kcal)" into "energy_kcal"
+func normalizeHeader(header string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(strings.TrimSpace(header)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			underscore = false
+		} else if !underscore && b.Len() > 0 {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
+
+// SearchFoods finds nutrition database entries by name, e.g. to link a pantry item
+func (s *nutritionService) SearchFoods(query string, limit int) ([]models.NutritionFood, error) {
+	if limit <= 0 || limit > 50 {
+		limit = 20
+	}
+	foods, err := s.repo.Search(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search nutrition foods: %w", err)
+	}
+	return foods, nil
+}
+
+// RecipeNutrition estimates a recipe's nutrition in total and per serving.
+// Optional ingredients and ingredients without a known weight are left out.
+func (s *nutritionService) RecipeNutrition(householdID uint, recipeID uint) (*RecipeNutrition, error) {
+	recipe, err := s.recipeRepo.FindByID(householdID, recipeID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return nil, models.ErrRecipeNotFound
+		}
+		return nil, fmt.Errorf("failed to get recipe: %w", err)
+	}
+
+	result := &RecipeNutrition{
+		RecipeID:    recipe.ID,
+		Servings:    recipe.Servings,
+		Ingredients: []IngredientNutrition{},
+		Unmatched:   []string{},
+	}
+	if result.Servings <= 0 {
+		result.Servings = 1
+	}
+
+	add := func(name string, quantity float64, unit string, linkedID *uint) error {
+		food, err := s.resolveFood(name, linkedID)
+		if err != nil {
+			return err
+		}
+		if food == nil {
+			result.Unmatched = append(result.Unmatched, name)
+			return nil
+		}
+		grams, ok := gramsOf(food, quantity, unit)
+		if !ok {
+			result.Unmatched = append(result.Unmatched, name)
+			return nil
+		}
+
+		nutrition := food.Nutrition.Scale(grams / 100)
+		result.Total = result.Total.Add(nutrition)
+		result.Ingredients = append(result.Ingredients, IngredientNutrition{
+			Name:      name,
+			Food:      food.Name,
+			Grams:     roundQuantity(grams, 0.1),
+			Nutrition: nutrition.Rounded(),
+		})
+		return nil
+	}
+
+	if len(recipe.IngredientList) > 0 {
+		for _, ingredient := range recipe.IngredientList {
+			if ingredient.Optional {
+				continue
+			}
+			if err := add(ingredient.Name, ingredient.Quantity, ingredient.Unit, ingredient.NutritionFoodID); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		for _, line := range strings.Split(recipe.Ingredients, "\n") {
+			parsed := parseIngredientLine(line)
+			if parsed.Name == "" || parsed.Optional {
+				continue
+			}
+			if err := add(parsed.Name, parsed.Quantity, parsed.Unit, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result.PerServing = result.Total.Scale(1 / float64(result.Servings)).Rounded()
+	result.Total = result.Total.Rounded()
+	return result, nil
+}
+
+// ConsumeGrocery records that the user ate some of a household pantry item and takes it
+// out of stock. A quantity of 0 consumes everything left; an item that runs out is removed.
+func (s *nutritionService) ConsumeGrocery(userID uint, householdID uint, groceryID uint, quantity float64) (*models.ConsumptionEntry, error) {
+	item, err := s.groceryRepo.FindByID(groceryID, householdID)
+	if err != nil {
+		return nil, err
+	}
+
+	if quantity == 0 {
+		quantity = item.Quantity
+	}
+	if quantity <= 0 || quantity > item.Quantity {
+		return nil, models.ErrInvalidConsumption
+	}
+
+	entry := &models.ConsumptionEntry{
+		UserID:        userID,
+		GroceryItemID: &item.ID,
+		Name:          item.Name,
+		Quantity:      quantity,
+		Unit:          item.Unit,
+		ConsumedAt:    time.Now(),
+	}
+
+	food, err := s.resolveFood(item.Name, item.NutritionFoodID)
+	if err != nil {
+		return nil, err
+	}
+	if food != nil {
+		entry.NutritionFoodID = &food.ID
+		if grams, ok := gramsOf(food, quantity, item.Unit); ok {
+			entry.Grams = roundQuantity(grams, 0.1)
+			entry.Nutrition = food.Nutrition.Scale(grams / 100).Rounded()
+		}
+	}
+
+	if err := s.repo.Consume(entry); err != nil {
+		return nil, fmt.Errorf("failed to record consumption: %w", err)
+	}
+
+	return entry, nil
+}
+
+// WeeklySummary sums up the user's consumption per week for the last weeks weeks,
+// including the current one, oldest first
+func (s *nutritionService) WeeklySummary(userID uint, weeks int) ([]WeeklyNutrition, error) {
+	if weeks <= 0 {
+		weeks = defaultSummaryWeeks
+	}
+	if weeks > maxSummaryWeeks {
+		weeks = maxSummaryWeeks
+	}
+
+	now := time.Now().UTC()
+	first := weekStart(now).AddDate(0, 0, -7*(weeks-1))
+
+	entries, err := s.repo.FindConsumptionSince(userID, first)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get consumption: %w", err)
+	}
+
+	summary := make([]WeeklyNutrition, weeks)
+	for i := range summary {
+		summary[i].WeekStart = first.AddDate(0, 0, 7*i)
+	}
+	for _, entry := range entries {
+		i := int(weekStart(entry.ConsumedAt.UTC()).Sub(first).Hours() / (24 * 7))
+		if i < 0 || i >= weeks {
+			continue
+		}
+		summary[i].Items++
+		summary[i].Total = summary[i].Total.Add(entry.Nutrition)
+	}
+
+	for i := range summary {
+		days := 7.0
+		if elapsed := now.Sub(summary[i].WeekStart).Hours() / 24; elapsed < days {
+			days = float64(int(elapsed) + 1) // The current week so far
+		}
+		summary[i].DailyAverage = summary[i].Total.Scale(1 / days).Rounded()
+		summary[i].Total = summary[i].Total.Rounded()
+	}
+	return summary, nil
+}
+
+// weekStart returns midnight on the Monday of t's week
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7 // Days since Monday
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -offset)
+}
+
+// resolveFood returns the explicitly linked food, or the best name match, or nil
+func (s *nutritionService) resolveFood(name string, linkedID *uint) (*models.NutritionFood, error) {
+	if linkedID != nil {
+		food, err := s.repo.FindByID(*linkedID)
+		if err == nil {
+			return food, nil
+		}
+		if !errors.Is(err, repositories.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to get nutrition food: %w", err)
+		}
+	}
+
+	foods, err := s.allFoods()
+	if err != nil {
+		return nil, err
+	}
+	return matchFood(name, foods), nil
+}
+
+// allFoods returns the cached nutrition database, loading it on first use
+func (s *nutritionService) allFoods() ([]models.NutritionFood, error) {
+	s.mu.RLock()
+	foods := s.foods
+	s.mu.RUnlock()
+	if foods != nil {
+		return foods, nil
+	}
+
+	foods, err := s.repo.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nutrition foods: %w", err)
+	}
+
+	s.mu.Lock()
+	s.foods = foods
+	s.mu.Unlock()
+	return foods, nil
+}
+
+// matchFood picks the food whose name appears in the ingredient name as whole
+// words, preferring the most specific match: "chicken breast" over "chicken".
+// Database names like "milk, whole" also match on their first part.
+func matchFood(name string, foods []models.NutritionFood) *models.NutritionFood {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return nil
+	}
+
+	var best *models.NutritionFood
+	bestKey := 0
+	for i := range foods {
+		food := &foods[i]
+		if food.Name == name {
+			return food
+		}
+
+		for _, key := range []string{food.Name, strings.TrimSpace(strings.SplitN(food.Name, ",", 2)[0])} {
+			if key == "" || len(key) < bestKey || findTerm(name, []string{key}) == "" {
+				continue
+			}
+			if best == nil || len(key) > bestKey || len(food.Name) < len(best.Name) {
+				best, bestKey = food, len(key)
+			}
+		}
+	}
+	return best
+}
+
+// gramsOf converts a quantity of food to grams, using the food's density for
+// volumes and its piece weight for counted items
+func gramsOf(food *models.NutritionFood, quantity float64, unit string) (float64, bool) {
+	if quantity <= 0 {
+		return 0, false
+	}
+
+	base, dimension, measured := toBaseUnit(quantity, unit)
+	switch {
+	case measured && dimension == dimensionMass:
+		return base, true
+	case measured && dimension == dimensionVolume:
+		density := food.Density
+		if density <= 0 {
+			density = 1
+		}
+		return base * density, true
+	case food.GramsPerPiece > 0:
+		return quantity * food.GramsPerPiece, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/repositories"
+)
+
+// PurchaseInput is a product that is being bought
+type PurchaseInput struct {
+	Name     string
+	Quantity float64 // 0 when no amount is known
+	Unit     string
+}
+
+type OverPurchaseService interface {
+	EvaluatePurchases(userID uint, householdID uint, source string, items []PurchaseInput) ([]models.OverPurchaseWarning, []models.PurchaseRecord, error)
+	GetRates(householdID uint, days int) ([]models.OverPurchaseRate, error)
+}
+
+type overPurchaseService struct {
+	repo         repositories.PurchaseRepository
+	groceryRepo  repositories.GroceryRepository
+	mealPlanRepo repositories.MealPlanRepository
+}
+
+func NewOverPurchaseService(repo repositories.PurchaseRepository, groceryRepo repositories.GroceryRepository, mealPlanRepo repositories.MealPlanRepository) OverPurchaseService {
+	return &overPurchaseService{repo: repo, groceryRepo: groceryRepo, mealPlanRepo: mealPlanRepo}
+}
+
+// EvaluatePurchases warns about the products a user is buying that the household's
+// pantry already holds enough of, and returns the purchase records to save along
+// with the purchase. It writes nothing and must be called before the products are
+// added to the pantry.
+func (s *overPurchaseService) EvaluatePurchases(userID uint, householdID uint, source string, items []PurchaseInput) ([]models.OverPurchaseWarning, []models.PurchaseRecord, error) {
+	groceries, err := s.groceryRepo.FindAll(householdID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get household groceries: %w", err)
+	}
+	reserved, err := s.mealPlanRepo.ReservedQuantities(householdID, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get reserved stock: %w", err)
+	}
+
+	warnings, records := checkPurchases(userID, householdID, source, items, groceries, reserved)
+	return warnings, records, nil
+}
+
+// GetRates returns how often the household bought each product while already in stock over the last days
+func (s *overPurchaseService) GetRates(householdID uint, days int) ([]models.OverPurchaseRate, error) {
+	rates, err := s.repo.RatesSince(householdID, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get over-purchase rates: %w", err)
+	}
+	for i := range rates {
+		rates[i].Rate = math.Round(rates[i].Rate*1000) / 1000
+	}
+	return rates, nil
+}
+
+// checkPurchases warns about the items the pantry already holds enough of and
+// builds a purchase record for each item, without receipt
+func checkPurchases(userID uint, householdID uint, source string, items []PurchaseInput, groceries []models.GroceryItem, reserved map[uint]float64) ([]models.OverPurchaseWarning, []models.PurchaseRecord) {
+	warnings := []models.OverPurchaseWarning{}
+	var records []models.PurchaseRecord
+	for _, item := range items {
+		name := strings.TrimSpace(item.Name)
+		if name == "" {
+			continue
+		}
+
+		record := models.PurchaseRecord{
+			UserID:      userID,
+			HouseholdID: householdID,
+			Product:     strings.ToLower(name),
+			Quantity:    item.Quantity,
+			Unit:        item.Unit,
+			Source:      source,
+		}
+		if warning := overPurchaseWarning(item, groceries, reserved); warning != nil {
+			warnings = append(warnings, *warning)
+			record.OverPurchase = true
+		}
+		record.PantryQuantity, _, _ = pantryStock(name, item.Unit, groceries, reserved)
+		records = append(records, record)
+	}
+	return warnings, records
+}
+
+// overPurchaseWarning returns a warning when the pantry holds at least as much
+// of the product as is being bought, or any of it when no amount is known.
+// Expired stock and stock reserved for planned meals don't count.
+func overPurchaseWarning(item PurchaseInput, groceries []models.GroceryItem, reserved map[uint]float64) *models.OverPurchaseWarning {
+	unit := item.Unit
+	if item.Quantity <= 0 {
+		// Without an amount, any stock is enough, counted in the unit it is kept in
+		for _, stocked := range rankByExpiry(groceries) {
+			if usesIngredient(stocked.Name, item.Name) || usesIngredient(item.Name, stocked.Name) {
+				unit = stocked.Unit
+				break
+			}
+		}
+	}
+
+	stock, expiry, ok := pantryStock(item.Name, unit, groceries, reserved)
+	if !ok || stock <= 0 || stock+1e-9 < item.Quantity {
+		return nil
+	}
+
+	quantity, unit := humanizeQuantity(stock, unit)
+	message := strings.Join(strings.Fields(fmt.Sprintf("You already have %s %s of %s", formatQuantity(quantity), unit, item.Name)), " ")
+	if !expiry.IsZero() {
+		message += fmt.Sprintf(", good until %s", expiry.Format(dateLayout))
+	}
+	return &models.OverPurchaseWarning{
+		Name:           item.Name,
+		Quantity:       item.Quantity,
+		Unit:           item.Unit,
+		PantryQuantity: quantity,
+		PantryUnit:     unit,
+		ExpiresOn:      expiry,
+		Message:        message,
+	}
+}
+
+// pantryStock sums the unexpired, unreserved stock of a product in the given
+// unit, with its soonest expiry. ok is false when no stock could be converted.
+func pantryStock(name, unit string, groceries []models.GroceryItem, reserved map[uint]float64) (stock float64, expiry time.Time, ok bool) {
+	for _, item := range rankByExpiry(groceries) {
+		if !usesIngredient(item.Name, name) && !usesIngredient(name, item.Name) {
+			continue
+		}
+		available, comparable := convertQuantity(item.Quantity-reserved[item.ID], item.Unit, unit)
+		if !comparable || available <= 0 {
+			continue
+		}
+		ok = true
+		stock += available
+		if expiry.IsZero() || (!item.ExpiryDate.IsZero() && item.ExpiryDate.Before(expiry)) {
+			expiry = item.ExpiryDate
+		}
+	}
+	return stock, expiry, ok
+}
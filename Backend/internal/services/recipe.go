@@ -1,95 +1,315 @@
 package services
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
+	"log"
+	"math"
+	"sort"
 	"strings"
+	"time"
+	"zero-waste-kitchen/internal/llm"
 	"zero-waste-kitchen/internal/models"
 	"zero-waste-kitchen/internal/repositories"
+)
+
+const (
+	// DefaultMinExpiringItems is how many of the soonest-expiring items each
+	// generated recipe must use when the caller doesn't ask for a specific number
+	DefaultMinExpiringItems = 2
+
+	// expiringWindowDays bounds which items count towards the waste rescued score
+	expiringWindowDays = 7
+
+	// maxRepairAttempts is how many times an invalid model reply is sent back for repair
+	maxRepairAttempts = 2
+)
 
-	"github.com/jpoz/groq"
+var (
+	ErrNoSafeRecipes     = errors.New("no recipes matched your food profile")
+	ErrNoExpiringRecipes = errors.New("no recipes used enough of your soonest-expiring items")
+	ErrInvalidAIResponse = errors.New("the recipe model returned an invalid response")
 )
 
 type RecipeService struct {
-	groceryRepo repositories.GroceryRepository
-	recipeRepo  repositories.RecipeRepository
-	groqClient  *groq.Client
+	groceryRepo    repositories.GroceryRepository
+	recipeRepo     repositories.RecipeRepository
+	profileRepo    repositories.FoodProfileRepository
+	generationRepo repositories.RecipeGenerationRepository
+	mealPlanRepo   repositories.MealPlanRepository
+	llmClient      llm.Client
+	limits         GenerationLimits
 }
 
-func NewRecipeService(groceryRepo repositories.GroceryRepository, recipeRepo repositories.RecipeRepository, apiKey string) *RecipeService {
+func NewRecipeService(groceryRepo repositories.GroceryRepository, recipeRepo repositories.RecipeRepository, profileRepo repositories.FoodProfileRepository, generationRepo repositories.RecipeGenerationRepository, mealPlanRepo repositories.MealPlanRepository, llmClient llm.Client, limits GenerationLimits) *RecipeService {
 	return &RecipeService{
-		groceryRepo: groceryRepo,
-		recipeRepo:  recipeRepo,
-		groqClient:  groq.NewClient(groq.WithAPIKey(apiKey)),
+		groceryRepo:    groceryRepo,
+		recipeRepo:     recipeRepo,
+		profileRepo:    profileRepo,
+		generationRepo: generationRepo,
+		mealPlanRepo:   mealPlanRepo,
+		llmClient:      llmClient,
+		limits:         limits,
 	}
 }
 
-// GenerateRecipes generates recipes based on the user's groceries and cuisine preference
-func (s *RecipeService) GenerateRecipes(userID uint, cuisinePreference string) ([]models.Recipe, error) {
-	// Get user's groceries
-	groceries, err := s.groceryRepo.FindAll(userID)
+// recipeGeneration holds what one generation request needs from the database
+type recipeGeneration struct {
+	userID      uint
+	householdID uint
+	groceries   []models.GroceryItem // Ranked by expiry, soonest first
+	priority    []string             // Soonest-expiring items each recipe must use some of
+	minExpiring int                  // How many of priority each recipe must use
+	profile     *models.FoodProfile
+	saved       []models.Recipe // The household's saved recipes, for near-duplicate detection
+	messages    []llm.Message
+
+	fingerprint      string // Cache key, see generationFingerprint
+	parsedCount      int    // Recipes the model returned
+	expiringCount    int    // Of those, recipes using enough priority items
+	promptTokens     int    // Tokens spent on this generation so far
+	completionTokens int
+}
+
+// addUsage counts the tokens of a model reply towards the generation
+func (gen *recipeGeneration) addUsage(completion *llm.Completion) {
+	gen.promptTokens += completion.PromptTokens
+	gen.completionTokens += completion.CompletionTokens
+}
+
+// GenerateRecipes generates recipes based on the household's groceries and the user's cuisine preference.
+// Each recipe must use at least minExpiring of the 2*minExpiring soonest-expiring items.
+// Recent recipes for an unchanged pantry are reused instead of calling the model,
+// and model calls are limited by the user's daily quota.
+func (s *RecipeService) GenerateRecipes(ctx context.Context, userID uint, householdID uint, cuisinePreference string, minExpiring int) ([]models.Recipe, error) {
+	gen, err := s.prepareGeneration(userID, householdID, cuisinePreference, minExpiring)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user groceries: %w", err)
-	}
-
-	// Prepare prompt for Groq API
-	prompt := s.buildPrompt(groceries, cuisinePreference)
-
-	// Call Groq API
-	response, err := s.groqClient.CreateChatCompletion(groq.CompletionCreateParams{
-		Model: "llama3-70b-8192",
-		Messages: []groq.Message{
-			{
-				Role:    "system",
-				Content: "You are a professional chef that generates recipes based on available ingredients.",
-			},
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
-		Temperature: 0.7,
-		MaxTokens:   1500,
+		return nil, err
+	}
+
+	// Without a model, answer from the local recipe library instead
+	if s.llmClient == nil {
+		return s.fallbackRecipes(gen.groceries, gen.profile, llm.ErrNotConfigured)
+	}
+
+	recipes, err := s.cachedRecipes(gen)
+	if err != nil {
+		return nil, err
+	}
+	if len(recipes) > 0 {
+		s.recordGeneration(gen, models.GenerationSourceCache, recipes)
+	} else {
+		if err := s.checkQuota(userID); err != nil {
+			return nil, err
+		}
+
+		// Call the configured model, repairing invalid replies
+		recipes, err = s.completeRecipes(ctx, gen, gen.messages)
+		if err != nil {
+			s.recordGeneration(gen, models.GenerationSourceModel, nil)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return s.fallbackRecipes(gen.groceries, gen.profile, err)
+		}
+
+		recipes, err = s.finishRecipes(gen, recipes)
+		if err != nil {
+			return nil, err
+		}
+		s.recordGeneration(gen, models.GenerationSourceModel, recipes)
+	}
+	if len(recipes) == 0 {
+		return nil, gen.noRecipesError()
+	}
+
+	sort.SliceStable(recipes, func(i, j int) bool {
+		return recipes[i].WasteRescuedScore > recipes[j].WasteRescuedScore
 	})
+	return recipes, nil
+}
+
+// prepareGeneration loads the household's pantry and saved recipes and the user's food profile and builds the prompt
+func (s *RecipeService) prepareGeneration(userID uint, householdID uint, cuisinePreference string, minExpiring int) (*recipeGeneration, error) {
+	// Get household's groceries
+	groceries, err := s.groceryRepo.FindAll(householdID)
 	if err != nil {
-		return nil, fmt.Errorf("groq API error: %w", err)
+		return nil, fmt.Errorf("failed to get household groceries: %w", err)
 	}
 
-	// Log the raw response for debugging
-	fmt.Printf("Raw AI Response: %s\n", response.Choices[0].Message.Content)
+	profile, err := loadFoodProfile(s.profileRepo, userID)
+	if err != nil {
+		return nil, err
+	}
 
-	// Parse the response into Recipe structs
-	recipes, err := s.parseAIResponse(response.Choices[0].Message.Content)
+	saved, err := s.recipeRepo.FindByHouseholdID(householdID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+		return nil, fmt.Errorf("failed to get saved recipes: %w", err)
 	}
 
-	// Save recipes to the database
-	for _, recipe := range recipes {
-		recipe.UserID = userID // Associate the recipe with the user
-		if err := s.recipeRepo.Save(&recipe); err != nil {
+	feedbackRules, err := s.feedbackPromptRules(householdID)
+	if err != nil {
+		return nil, err
+	}
+
+	if minExpiring <= 0 {
+		minExpiring = DefaultMinExpiringItems
+	}
+
+	gen := &recipeGeneration{
+		userID:      userID,
+		householdID: householdID,
+		// Soonest-expiring items first, already expired items dropped
+		groceries: rankByExpiry(groceries),
+		profile:   profile,
+		saved:     saved,
+	}
+	gen.priority = priorityItems(gen.groceries, 2*minExpiring)
+	gen.minExpiring = min(minExpiring, len(gen.priority))
+	gen.fingerprint = generationFingerprint(gen.groceries, profile, cuisinePreference, minExpiring)
+
+	// Prepare prompt for the model
+	prompt := s.buildPrompt(gen.groceries, cuisinePreference, gen.priority, gen.minExpiring, profile, feedbackRules)
+	gen.messages = []llm.Message{
+		{
+			Role:    "system",
+			Content: "You are a professional chef that generates recipes based on available ingredients.",
+		},
+		{
+			Role:    "user",
+			Content: prompt,
+		},
+	}
+	return gen, nil
+}
+
+// finishRecipes turns parsed model output into saved recipes. Recipes that skip
+// the soonest-expiring items are dropped, near-duplicates of saved recipes are
+// replaced by the saved ones, recipes that break the food profile are rejected,
+// and the rest are scored, flagged and saved.
+func (s *RecipeService) finishRecipes(gen *recipeGeneration, recipes []models.Recipe) ([]models.Recipe, error) {
+	recipes = gen.filterExpiringRecipes(recipes)
+
+	// Reuse saved recipes instead of piling up near-duplicates
+	recipes = dedupeRecipes(recipes, gen.saved)
+
+	// Reject recipes that break the food profile, then score the rest by how
+	// much soon-expiring stock they rescue
+	recipes = filterSafeRecipes(recipes, gen.profile)
+	for i := range recipes {
+		score, used := scoreWasteRescued(recipes[i], gen.groceries)
+		recipes[i].WasteRescuedScore = score
+		recipes[i].ExpiringIngredients = strings.Join(used, "\n")
+		recipes[i].Warnings = append(recipes[i].Warnings, pantryWarnings(recipes[i], gen.groceries, gen.profile.Staples)...)
+	}
+
+	// Save new recipes to the database
+	for i := range recipes {
+		if recipes[i].ID != 0 {
+			continue
+		}
+		recipes[i].UserID = &gen.userID // Associate the recipe with the user
+		recipes[i].HouseholdID = &gen.householdID
+		if err := s.recipeRepo.Save(&recipes[i]); err != nil {
 			return nil, fmt.Errorf("failed to save recipe to database: %w", err)
 		}
+		gen.saved = append(gen.saved, recipes[i])
 	}
 
 	return recipes, nil
 }
 
-// buildPrompt creates a prompt for the Groq API based on the user's groceries and cuisine preference
-func (s *RecipeService) buildPrompt(groceries []models.GroceryItem, cuisine string) string {
+// priorityItems returns the names of up to limit soonest-expiring items.
+// groceries must already be ranked by expiry, soonest first.
+func priorityItems(groceries []models.GroceryItem, limit int) []string {
+	var priority []string
+	for _, item := range groceries {
+		if !item.ExpiryDate.IsZero() && len(priority) < limit {
+			priority = append(priority, item.Name)
+		}
+	}
+	return priority
+}
+
+// usesExpiring reports whether a recipe uses at least minExpiring of the priority items
+func (gen *recipeGeneration) usesExpiring(recipe models.Recipe) bool {
+	used := 0
+	for _, name := range gen.priority {
+		if recipeUses(recipe, name) {
+			used++
+		}
+	}
+	return used >= gen.minExpiring
+}
+
+// filterExpiringRecipes drops recipes that don't use enough of the soonest-expiring items
+func (gen *recipeGeneration) filterExpiringRecipes(recipes []models.Recipe) []models.Recipe {
+	kept := make([]models.Recipe, 0, len(recipes))
+	for _, recipe := range recipes {
+		if !gen.usesExpiring(recipe) {
+			log.Printf("Rejected recipe %q: uses fewer than %d of %s", recipe.Title, gen.minExpiring, strings.Join(gen.priority, ", "))
+			continue
+		}
+		kept = append(kept, recipe)
+	}
+	gen.parsedCount += len(recipes)
+	gen.expiringCount += len(kept)
+	return kept
+}
+
+// checkExpiring returns an error for the repair prompt when none of the recipes
+// use enough of the soonest-expiring items
+func (gen *recipeGeneration) checkExpiring(recipes []models.Recipe) error {
+	for _, recipe := range recipes {
+		if gen.usesExpiring(recipe) {
+			return nil
+		}
+	}
+	return fmt.Errorf("no recipe uses at least %d of these soonest-expiring ingredients: %s", gen.minExpiring, strings.Join(gen.priority, ", "))
+}
+
+// noRecipesError explains why a generation ended without recipes: either none
+// used enough of the soonest-expiring items, or the food profile rejected them
+func (gen *recipeGeneration) noRecipesError() error {
+	if gen.parsedCount > 0 && gen.expiringCount == 0 {
+		return ErrNoExpiringRecipes
+	}
+	return ErrNoSafeRecipes
+}
+
+// buildPrompt creates a prompt for the model based on the user's groceries, cuisine preference,
+// the soonest-expiring items every recipe must use minExpiring of, the food profile and recipe feedback.
+// groceries must already be ranked by expiry, soonest first.
+func (s *RecipeService) buildPrompt(groceries []models.GroceryItem, cuisine string, priority []string, minExpiring int, profile *models.FoodProfile, feedbackRules []string) string {
 	var ingredients []string
 	for _, item := range groceries {
-		ingredients = append(ingredients, fmt.Sprintf("%.1f %s %s", item.Quantity, item.Unit, item.Name))
+		ingredients = append(ingredients, fmt.Sprintf("%.1f %s %s (%s)", item.Quantity, item.Unit, item.Name, describeExpiry(item)))
+	}
+
+	expiryRule := "Prefer ingredients that expire soonest"
+	if minExpiring > 0 {
+		expiryRule = fmt.Sprintf("Each recipe MUST use at least %d of these soonest-expiring ingredients: %s", minExpiring, strings.Join(priority, ", "))
+	}
+
+	rules := append([]string{
+		"Cuisine preference: " + cuisine,
+		expiryRule,
+	}, profilePromptRules(profile)...)
+	rules = append(rules, feedbackRules...)
+	rules = append(rules,
+		"Return ONLY a valid JSON array without any additional text or markdown formatting",
+		"Each recipe must have these exact fields:",
+	)
+	for i := range rules {
+		rules[i] = fmt.Sprintf("%d. %s", i+1, rules[i])
 	}
 
-	prompt := fmt.Sprintf(`Generate 3 detailed recipes using these ingredients:
+	prompt := fmt.Sprintf(`Generate 3 detailed recipes using these ingredients, listed from soonest to latest expiry:
 %s
 
 Important instructions:
-1. Cuisine preference: %s
-2. Return ONLY a valid JSON array without any additional text or markdown formatting
-3. Each recipe must have these exact fields:
+%s
    - title (string)
    - ingredients (string with each ingredient on a new line)
    - instructions (string with each step on a new line)
@@ -99,6 +319,9 @@ Important instructions:
    - difficulty (string: Easy, Medium, or Hard)
    - cuisine (string)
 
+The array must match this JSON schema:
+%s
+
 Example format:
 [
   {
@@ -111,52 +334,189 @@ Example format:
     "difficulty": "Easy",
     "cuisine": "Italian"
   }
-]`, strings.Join(ingredients, "\n"), cuisine)
+]`, strings.Join(ingredients, "\n"), strings.Join(rules, "\n"), recipeSchemaJSON)
 
 	return prompt
 }
 
-// parseAIResponse parses the AI response into a slice of Recipe structs
-func (s *RecipeService) parseAIResponse(response string) ([]models.Recipe, error) {
-	// Clean the response by removing markdown code blocks if present
-	cleanedResponse := strings.TrimSpace(response)
-	cleanedResponse = strings.TrimPrefix(cleanedResponse, "```json")
-	cleanedResponse = strings.TrimPrefix(cleanedResponse, "```")
-	cleanedResponse = strings.TrimSuffix(cleanedResponse, "```")
-	cleanedResponse = strings.TrimSpace(cleanedResponse)
+// filterSafeRecipes drops recipes that contain a declared allergen or break the
+// diet, and attaches soft food profile warnings to the rest
+func filterSafeRecipes(recipes []models.Recipe, profile *models.FoodProfile) []models.Recipe {
+	safe := make([]models.Recipe, 0, len(recipes))
+	for _, recipe := range recipes {
+		if violations := profileViolations(recipe, profile); len(violations) > 0 {
+			log.Printf("Rejected recipe %q: %s", recipe.Title, strings.Join(violations, "; "))
+			continue
+		}
+		recipe.Warnings = append(recipe.Warnings, profileWarnings(recipe, profile)...)
+		safe = append(safe, recipe)
+	}
+	return safe
+}
+
+// rankByExpiry drops already expired items and orders the rest by expiry date,
+// soonest first. Items without an expiry date are treated as non-perishable and go last.
+func rankByExpiry(groceries []models.GroceryItem) []models.GroceryItem {
+	now := time.Now()
+	ranked := make([]models.GroceryItem, 0, len(groceries))
+	for _, item := range groceries {
+		if !item.ExpiryDate.IsZero() && item.ExpiryDate.Before(now) {
+			continue
+		}
+		ranked = append(ranked, item)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i].ExpiryDate, ranked[j].ExpiryDate
+		if a.IsZero() || b.IsZero() {
+			return !a.IsZero() && b.IsZero()
+		}
+		return a.Before(b)
+	})
+
+	return ranked
+}
+
+// daysLeft returns the number of whole days until the item expires
+func daysLeft(item models.GroceryItem) int {
+	return int(time.Until(item.ExpiryDate).Hours() / 24)
+}
 
-	// Parse the JSON into Recipe structs
-	var recipes []models.Recipe
-	if err := json.Unmarshal([]byte(cleanedResponse), &recipes); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal AI response: %w\nResponse content: %s", err, cleanedResponse)
+// describeExpiry renders an item's days-left for the prompt
+func describeExpiry(item models.GroceryItem) string {
+	if item.ExpiryDate.IsZero() {
+		return "no expiry date"
 	}
 
-	// Validate the parsed recipes
-	if len(recipes) == 0 {
-		return nil, errors.New("no recipes found in AI response")
+	switch days := daysLeft(item); days {
+	case 0:
+		return "expires today"
+	case 1:
+		return "expires in 1 day"
+	default:
+		return fmt.Sprintf("expires in %d days", days)
+	}
+}
+
+// scoreWasteRescued rates a recipe from 0 to 100 by the share of soon-expiring
+// stock it uses. Items closer to expiry weigh more. It also returns the names
+// of the soon-expiring items the recipe uses.
+func scoreWasteRescued(recipe models.Recipe, groceries []models.GroceryItem) (float64, []string) {
+	var total, rescued float64
+	var used []string
+	for _, item := range groceries {
+		if item.ExpiryDate.IsZero() {
+			continue
+		}
+
+		days := daysLeft(item)
+		if days > expiringWindowDays {
+			continue
+		}
+
+		weight := 1 / float64(days+1)
+		total += weight
+		if recipeUses(recipe, item.Name) {
+			rescued += weight
+			used = append(used, item.Name)
+		}
+	}
+
+	if total == 0 {
+		return 0, used
+	}
+
+	return math.Round(rescued/total*1000) / 10, used
+}
+
+// recipeUses reports whether a recipe calls for a pantry item, preferring
+// structured ingredients when the recipe has them
+func recipeUses(recipe models.Recipe, name string) bool {
+	if len(recipe.IngredientList) == 0 {
+		return usesIngredient(recipe.Ingredients, name)
 	}
 
-	for i, recipe := range recipes {
-		if recipe.Title == "" || recipe.Instructions == "" {
-			return nil, fmt.Errorf("invalid recipe format at index %d", i)
+	for _, ingredient := range recipe.IngredientList {
+		if usesIngredient(ingredient.Name, name) || usesIngredient(name, ingredient.Name) {
+			return true
 		}
 	}
+	return false
+}
 
-	return recipes, nil
+// usesIngredient reports whether a pantry item name appears in a recipe's ingredient list
+func usesIngredient(ingredients, name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return false
+	}
+	return strings.Contains(strings.ToLower(ingredients), name)
 }
 
-// GetAllRecipes retrieves all recipes for a user
-func (s *RecipeService) GetAllRecipes(userID uint) ([]models.Recipe, error) {
-	recipes, err := s.recipeRepo.FindByUserID(userID)
+// completeRecipes asks the model for recipes. When a reply can't be parsed,
+// breaks the recipe schema or has no recipe using enough soonest-expiring items,
+// the model is shown its mistakes and asked to repair the reply, up to
+// maxRepairAttempts times. Token usage is added to gen.
+func (s *RecipeService) completeRecipes(ctx context.Context, gen *recipeGeneration, messages []llm.Message) ([]models.Recipe, error) {
+	var lastErr error
+	for attempt := 0; attempt <= maxRepairAttempts; attempt++ {
+		completion, err := s.llmClient.Complete(ctx, messages)
+		if err != nil {
+			return nil, err
+		}
+		gen.addUsage(completion)
+
+		recipes, err := parseRecipes(completion.Content)
+		if err == nil {
+			err = gen.checkExpiring(recipes)
+		}
+		if err == nil {
+			return recipes, nil
+		}
+
+		// The raw reply is only logged, never returned to the client
+		log.Printf("Invalid recipe response (attempt %d): %v\nResponse content: %s", attempt+1, err, completion.Content)
+		lastErr = err
+
+		messages = append(messages,
+			llm.Message{Role: "assistant", Content: completion.Content},
+			llm.Message{Role: "user", Content: repairPrompt(err)},
+		)
+	}
+
+	return nil, fmt.Errorf("%w: %v", ErrInvalidAIResponse, lastErr)
+}
+
+// repairPrompt asks the model to fix a reply that failed validation
+func repairPrompt(err error) string {
+	return fmt.Sprintf(`Your previous reply could not be used: %v
+
+Reply again with ONLY a JSON array of recipes, without any other text or markdown, matching this JSON schema:
+%s`, err, recipeSchemaJSON)
+}
+
+// GetAllRecipes retrieves all recipes of a household, or only its favorites
+func (s *RecipeService) GetAllRecipes(householdID uint, favoritesOnly bool) ([]models.Recipe, error) {
+	recipes, err := s.recipeRepo.FindByHouseholdID(householdID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get recipes: %w", err)
 	}
+
+	if favoritesOnly {
+		favorites := make([]models.Recipe, 0, len(recipes))
+		for _, recipe := range recipes {
+			if recipe.Favorite {
+				favorites = append(favorites, recipe)
+			}
+		}
+		recipes = favorites
+	}
 	return recipes, nil
 }
 
-// GetRecipeByID retrieves a specific recipe by ID for a user
-func (s *RecipeService) GetRecipeByID(userID uint, recipeID uint) (*models.Recipe, error) {
-	recipe, err := s.recipeRepo.FindByID(userID, recipeID)
+// GetRecipeByID retrieves a specific recipe by ID for a household
+func (s *RecipeService) GetRecipeByID(householdID uint, recipeID uint) (*models.Recipe, error) {
+	recipe, err := s.recipeRepo.FindByID(householdID, recipeID)
 	if err != nil {
 		if errors.Is(err, repositories.ErrRecordNotFound) {
 			return nil, models.ErrRecipeNotFound
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"zero-waste-kitchen/internal/models"
+)
+
+const (
+	// maxFeedbackExamples caps how many liked and disliked recipes are shown to the model
+	maxFeedbackExamples = 5
+
+	// Ratings at or above likedRating count as liked, at or below dislikedRating as disliked
+	likedRating    = 4
+	dislikedRating = 2
+
+	// Similarity thresholds for near-duplicate recipes
+	duplicateTitleSimilarity      = 0.75
+	duplicateMinTitleSimilarity   = 0.4
+	duplicateIngredientSimilarity = 0.7
+)
+
+// RecipeFeedback is a partial update of a saved recipe's user feedback
+type RecipeFeedback struct {
+	Favorite *bool   `json:"favorite"`
+	Rating   *int    `json:"rating"`
+	Notes    *string `json:"notes"`
+}
+
+// UpdateFeedback favorites, rates or annotates one of the household's recipes
+func (s *RecipeService) UpdateFeedback(householdID uint, recipeID uint, feedback RecipeFeedback) (*models.Recipe, error) {
+	recipe, err := s.GetRecipeByID(householdID, recipeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if feedback.Rating != nil {
+		if *feedback.Rating < 0 || *feedback.Rating > 5 {
+			return nil, models.ErrInvalidRating
+		}
+		recipe.Rating = *feedback.Rating
+	}
+	if feedback.Favorite != nil {
+		recipe.Favorite = *feedback.Favorite
+	}
+	if feedback.Notes != nil {
+		recipe.Notes = strings.TrimSpace(*feedback.Notes)
+	}
+
+	if err := s.recipeRepo.Save(recipe); err != nil {
+		return nil, fmt.Errorf("failed to save recipe: %w", err)
+	}
+	return recipe, nil
+}
+
+// DeleteRecipe deletes one of the household's recipes
+func (s *RecipeService) DeleteRecipe(householdID uint, recipeID uint) error {
+	if _, err := s.GetRecipeByID(householdID, recipeID); err != nil {
+		return err
+	}
+
+	if err := s.recipeRepo.DeleteByID(recipeID); err != nil {
+		return fmt.Errorf("failed to delete recipe: %w", err)
+	}
+	return nil
+}
+
+// feedbackPromptRules turns the household's ratings into liked and disliked examples for the model
+func (s *RecipeService) feedbackPromptRules(householdID uint) ([]string, error) {
+	rated, err := s.recipeRepo.FindFeedbackByHouseholdID(householdID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recipe feedback: %w", err)
+	}
+
+	var liked, disliked []string
+	for _, recipe := range rated {
+		switch {
+		case recipe.Rating > 0 && recipe.Rating <= dislikedRating:
+			if len(disliked) < maxFeedbackExamples {
+				disliked = append(disliked, recipe.Title)
+			}
+		case recipe.Favorite || recipe.Rating >= likedRating:
+			if len(liked) < maxFeedbackExamples {
+				liked = append(liked, recipe.Title)
+			}
+		}
+	}
+
+	var rules []string
+	if len(liked) > 0 {
+		rules = append(rules, fmt.Sprintf("The user liked these recipes, suggest dishes in a similar style: %s", strings.Join(liked, "; ")))
+	}
+	if len(disliked) > 0 {
+		rules = append(rules, fmt.Sprintf("The user disliked these recipes, avoid anything similar: %s", strings.Join(disliked, "; ")))
+	}
+	return rules, nil
+}
+
+// dedupeRecipes drops generated recipes that nearly duplicate an earlier one in
+// the same batch, and replaces those that nearly duplicate a saved recipe with
+// the saved one. Only recipes without an ID still need saving afterwards.
+func dedupeRecipes(generated []models.Recipe, saved []models.Recipe) []models.Recipe {
+	kept := make([]models.Recipe, 0, len(generated))
+
+	for _, recipe := range generated {
+		duplicate := false
+		for i := range kept {
+			if isNearDuplicate(recipe, kept[i]) {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+
+		for _, existing := range saved {
+			if isNearDuplicate(recipe, existing) {
+				recipe = existing
+				break
+			}
+		}
+
+		kept = append(kept, recipe)
+	}
+	return kept
+}
+
+// isNearDuplicate compares titles and ingredient sets. Recipes are duplicates when
+// their titles are nearly the same, or similar with mostly the same ingredients.
+func isNearDuplicate(a, b models.Recipe) bool {
+	titleSimilarity := jaccard(titleWords(a.Title), titleWords(b.Title))
+	if titleSimilarity >= duplicateTitleSimilarity {
+		return true
+	}
+	if titleSimilarity < duplicateMinTitleSimilarity {
+		return false
+	}
+	return jaccard(ingredientSet(a), ingredientSet(b)) >= duplicateIngredientSimilarity
+}
+
+// titleStopWords carry no meaning when comparing recipe titles
+var titleStopWords = map[string]bool{"a": true, "an": true, "and": true, "the": true, "with": true, "of": true, "in": true, "on": true, "style": true, "easy": true, "quick": true, "simple": true}
+
+func titleWords(title string) map[string]bool {
+	words := map[string]bool{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !titleStopWords[word] {
+			words[strings.TrimSuffix(word, "s")] = true
+		}
+	}
+	return words
+}
+
+func ingredientSet(recipe models.Recipe) map[string]bool {
+	set := map[string]bool{}
+	for _, line := range strings.Split(recipe.Ingredients, "\n") {
+		if name := ingredientName(line); name != "" {
+			set[strings.TrimSuffix(name, "s")] = true
+		}
+	}
+	return set
+}
+
+// jaccard returns the size of the intersection of two sets over the size of their union
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+
+	intersection := 0
+	for key := range a {
+		if b[key] {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/repositories"
+)
+
+// GenerationLimits caps how much model time each user can spend
+type GenerationLimits struct {
+	DailyQuota int           // Model generations per user per UTC day, 0 for unlimited
+	CacheTTL   time.Duration // How long recipes are reused for an unchanged pantry, 0 to disable caching
+}
+
+// generationFingerprint hashes everything that shapes the prompt except recipe
+// feedback: the pantry's ingredient set, the food profile, the cuisine and the
+// number of expiring items each recipe must use. Quantities and expiry dates
+// are left out, so using up a little of an item keeps the cache warm.
+func generationFingerprint(groceries []models.GroceryItem, profile *models.FoodProfile, cuisine string, minExpiring int) string {
+	ingredients := make([]string, 0, len(groceries))
+	for _, item := range groceries {
+		ingredients = append(ingredients, strings.ToLower(strings.TrimSpace(item.Name)))
+	}
+
+	parts := []string{
+		"ingredients=" + sortedJoin(ingredients),
+		"cuisine=" + strings.ToLower(strings.TrimSpace(cuisine)),
+		"min_expiring=" + strconv.Itoa(minExpiring),
+	}
+	if profile != nil {
+		parts = append(parts,
+			"diet="+profile.Diet,
+			"allergens="+sortedJoin(profile.Allergens),
+			"disliked="+sortedJoin(profile.DislikedIngredients),
+			"equipment="+sortedJoin(profile.Equipment),
+			"staples="+sortedJoin(profile.Staples),
+			"household="+strconv.Itoa(profile.HouseholdSize),
+			"max_cook_time="+strconv.Itoa(profile.MaxCookTime),
+		)
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// sortedJoin joins a sorted, de-duplicated copy of values
+func sortedJoin(values []string) string {
+	sorted := cleanTerms(values)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// cachedRecipes returns the recipes of the user's latest generation with the same
+// fingerprint, if it is recent enough and any of its recipes still exist
+func (s *RecipeService) cachedRecipes(gen *recipeGeneration) ([]models.Recipe, error) {
+	if s.limits.CacheTTL <= 0 {
+		return nil, nil
+	}
+
+	previous, err := s.generationRepo.FindLatestByFingerprint(gen.userID, gen.fingerprint, time.Now().Add(-s.limits.CacheTTL))
+	if errors.Is(err, repositories.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached recipes: %w", err)
+	}
+
+	var recipes []models.Recipe
+	for _, id := range previous.RecipeIDs {
+		recipe, err := s.recipeRepo.FindByID(gen.householdID, id)
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			continue // Deleted since
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cached recipe: %w", err)
+		}
+		recipes = append(recipes, *recipe)
+	}
+	if len(recipes) == 0 {
+		return nil, nil
+	}
+
+	// Rescore against today's expiry dates
+	return s.finishRecipes(gen, recipes)
+}
+
+// checkQuota returns ErrGenerationQuotaExceeded when the user has used up today's model generations
+func (s *RecipeService) checkQuota(userID uint) error {
+	if s.limits.DailyQuota <= 0 {
+		return nil
+	}
+
+	startOfDay := time.Now().UTC().Truncate(24 * time.Hour)
+	used, err := s.generationRepo.CountModelCallsSince(userID, startOfDay)
+	if err != nil {
+		return fmt.Errorf("failed to check generation quota: %w", err)
+	}
+	if used >= int64(s.limits.DailyQuota) {
+		return fmt.Errorf("%w: %d of %d used today", models.ErrGenerationQuotaExceeded, used, s.limits.DailyQuota)
+	}
+	return nil
+}
+
+// recordGeneration logs a generation for caching, quotas and token accounting.
+// A failure is only logged, since the recipes have already been saved.
+func (s *RecipeService) recordGeneration(gen *recipeGeneration, source string, recipes []models.Recipe) {
+	recipeIDs := make([]uint, 0, len(recipes))
+	for _, recipe := range recipes {
+		recipeIDs = append(recipeIDs, recipe.ID)
+	}
+
+	generation := &models.RecipeGeneration{
+		UserID:      gen.userID,
+		Fingerprint: gen.fingerprint,
+		Source:      source,
+		RecipeIDs:   recipeIDs,
+	}
+	if source == models.GenerationSourceModel {
+		generation.PromptTokens = gen.promptTokens
+		generation.CompletionTokens = gen.completionTokens
+	}
+
+	if err := s.generationRepo.Save(generation); err != nil {
+		log.Printf("Failed to record recipe generation for user %d: %v", gen.userID, err)
+	}
+}
+
+// GenerationUsage returns per-user generation counts and token usage since the given time
+func (s *RecipeService) GenerationUsage(since time.Time) ([]models.GenerationUsage, error) {
+	usage, err := s.generationRepo.UsageSince(since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get generation usage: %w", err)
+	}
+	return usage, nil
+}
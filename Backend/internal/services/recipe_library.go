@@ -0,0 +1,319 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"zero-waste-kitchen/internal/models"
+)
+
+const (
+	// defaultMatchLimit is how many library matches are returned when no limit is given
+	defaultMatchLimit = 5
+
+	// fallbackRecipeCount mirrors the number of recipes the model is asked for
+	fallbackRecipeCount = 3
+)
+
+var ErrEmptyRecipeLibrary = errors.New("recipe library is empty")
+
+// RecipeMatch is a library recipe scored against a user's pantry
+type RecipeMatch struct {
+	Recipe             models.Recipe `json:"recipe"`
+	Score              float64       `json:"score"`    // 0-100, overall rank
+	Coverage           float64       `json:"coverage"` // 0-1, share of required ingredients in the pantry
+	MissingIngredients []string      `json:"missing_ingredients"`
+}
+
+// libraryRecipeInput is the JSON import format for library recipes
+type libraryRecipeInput struct {
+	Title        string                    `json:"title"`
+	Ingredients  []models.RecipeIngredient `json:"ingredients"`
+	Instructions string                    `json:"instructions"`
+	PrepTime     int                       `json:"prep_time"`
+	CookTime     int                       `json:"cook_time"`
+	Servings     int                       `json:"servings"`
+	Difficulty   string                    `json:"difficulty"`
+	Cuisine      string                    `json:"cuisine"`
+}
+
+// ImportLibraryJSON imports library recipes from a JSON array and returns how many were saved
+func (s *RecipeService) ImportLibraryJSON(r io.Reader) (int, error) {
+	var inputs []libraryRecipeInput
+	if err := json.NewDecoder(r).Decode(&inputs); err != nil {
+		return 0, fmt.Errorf("invalid recipe JSON: %w", err)
+	}
+
+	return s.importLibrary(inputs)
+}
+
+// ImportLibraryCSV imports library recipes from CSV with the header
+// title,ingredients,instructions,prep_time,cook_time,servings,difficulty,cuisine.
+// Ingredients are separated by ";" and written as quantity|unit|name, with an
+// optional fourth "optional" field, e.g. "2|cup|rice;1||onion;1|tsp|chili|optional".
+func (s *RecipeService) ImportLibraryCSV(r io.Reader) (int, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"title", "ingredients", "instructions"} {
+		if _, ok := columns[required]; !ok {
+			return 0, fmt.Errorf("CSV is missing the %q column", required)
+		}
+	}
+
+	var inputs []libraryRecipeInput
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read CSV line %d: %w", line, err)
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		number := func(name string) int {
+			n, _ := strconv.Atoi(field(name))
+			return n
+		}
+
+		ingredients, err := parseCSVIngredients(field("ingredients"))
+		if err != nil {
+			return 0, fmt.Errorf("CSV line %d: %w", line, err)
+		}
+
+		inputs = append(inputs, libraryRecipeInput{
+			Title:        field("title"),
+			Ingredients:  ingredients,
+			Instructions: field("instructions"),
+			PrepTime:     number("prep_time"),
+			CookTime:     number("cook_time"),
+			Servings:     number("servings"),
+			Difficulty:   field("difficulty"),
+			Cuisine:      field("cuisine"),
+		})
+	}
+
+	return s.importLibrary(inputs)
+}
+
+// parseCSVIngredients parses the quantity|unit|name[|optional] ingredient list of a CSV row
+func parseCSVIngredients(value string) ([]models.RecipeIngredient, error) {
+	var ingredients []models.RecipeIngredient
+	for _, entry := range strings.Split(value, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, "|")
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("ingredient %q must be written as quantity|unit|name", entry)
+		}
+
+		ingredient := models.RecipeIngredient{
+			Unit: strings.TrimSpace(parts[1]),
+			Name: strings.TrimSpace(parts[2]),
+		}
+		if q := strings.TrimSpace(parts[0]); q != "" {
+			quantity, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				return nil, fmt.Errorf("ingredient %q has an invalid quantity", entry)
+			}
+			ingredient.Quantity = quantity
+		}
+		if len(parts) > 3 && strings.EqualFold(strings.TrimSpace(parts[3]), "optional") {
+			ingredient.Optional = true
+		}
+
+		ingredients = append(ingredients, ingredient)
+	}
+	return ingredients, nil
+}
+
+// importLibrary validates and saves library recipes
+func (s *RecipeService) importLibrary(inputs []libraryRecipeInput) (int, error) {
+	for i, in := range inputs {
+		if in.Title == "" || in.Instructions == "" || len(in.Ingredients) == 0 {
+			return 0, fmt.Errorf("recipe %d needs a title, instructions and at least one ingredient", i+1)
+		}
+		for _, ingredient := range in.Ingredients {
+			if strings.TrimSpace(ingredient.Name) == "" {
+				return 0, fmt.Errorf("recipe %q has an ingredient without a name", in.Title)
+			}
+		}
+	}
+
+	for _, in := range inputs {
+		recipe := models.Recipe{
+			Title:          in.Title,
+			Ingredients:    formatIngredients(in.Ingredients),
+			Instructions:   in.Instructions,
+			PrepTime:       in.PrepTime,
+			CookTime:       in.CookTime,
+			Servings:       in.Servings,
+			Difficulty:     in.Difficulty,
+			Cuisine:        in.Cuisine,
+			IngredientList: in.Ingredients,
+		}
+		if err := s.recipeRepo.Save(&recipe); err != nil {
+			return 0, fmt.Errorf("failed to save library recipe %q: %w", in.Title, err)
+		}
+	}
+
+	return len(inputs), nil
+}
+
+// formatIngredients renders structured ingredients as the newline-separated text used by generated recipes
+func formatIngredients(ingredients []models.RecipeIngredient) string {
+	lines := make([]string, 0, len(ingredients))
+	for _, ingredient := range ingredients {
+		var parts []string
+		if ingredient.Quantity > 0 {
+			parts = append(parts, strconv.FormatFloat(ingredient.Quantity, 'f', -1, 64))
+		}
+		if ingredient.Unit != "" {
+			parts = append(parts, ingredient.Unit)
+		}
+		parts = append(parts, ingredient.Name)
+		if ingredient.Optional {
+			parts = append(parts, "(optional)")
+		}
+		lines = append(lines, strings.Join(parts, " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// MatchLibrary scores every library recipe against the household's current pantry
+// and returns the best matches for the user. It never calls the model.
+func (s *RecipeService) MatchLibrary(userID uint, householdID uint, limit int) ([]RecipeMatch, error) {
+	groceries, err := s.groceryRepo.FindAll(householdID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get household groceries: %w", err)
+	}
+
+	profile, err := loadFoodProfile(s.profileRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.matchLibrary(rankByExpiry(groceries), profile, limit)
+}
+
+// matchLibrary scores library recipes that are safe for the food profile
+// against groceries that are already ranked by expiry
+func (s *RecipeService) matchLibrary(groceries []models.GroceryItem, profile *models.FoodProfile, limit int) ([]RecipeMatch, error) {
+	library, err := s.recipeRepo.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recipe library: %w", err)
+	}
+	library = filterSafeRecipes(library, profile)
+
+	if limit <= 0 {
+		limit = defaultMatchLimit
+	}
+
+	matches := make([]RecipeMatch, 0, len(library))
+	for _, recipe := range library {
+		matches = append(matches, scoreLibraryRecipe(recipe, groceries))
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		if len(matches[i].MissingIngredients) != len(matches[j].MissingIngredients) {
+			return len(matches[i].MissingIngredients) < len(matches[j].MissingIngredients)
+		}
+		return matches[i].Recipe.Title < matches[j].Recipe.Title
+	})
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// scoreLibraryRecipe rates a library recipe by pantry coverage (70%) and waste rescued (30%)
+func scoreLibraryRecipe(recipe models.Recipe, groceries []models.GroceryItem) RecipeMatch {
+	var required, covered int
+	missing := []string{}
+	for _, ingredient := range recipe.IngredientList {
+		if ingredient.Optional {
+			continue
+		}
+
+		required++
+		if inPantry(ingredient.Name, groceries) {
+			covered++
+		} else {
+			missing = append(missing, ingredient.Name)
+		}
+	}
+
+	coverage := 1.0
+	if required > 0 {
+		coverage = float64(covered) / float64(required)
+	}
+
+	score, used := scoreWasteRescued(recipe, groceries)
+	recipe.WasteRescuedScore = score
+	recipe.ExpiringIngredients = strings.Join(used, "\n")
+
+	return RecipeMatch{
+		Recipe:             recipe,
+		Score:              math.Round((coverage*70+score*0.3)*10) / 10,
+		Coverage:           math.Round(coverage*100) / 100,
+		MissingIngredients: missing,
+	}
+}
+
+// inPantry reports whether any pantry item matches the ingredient name
+func inPantry(name string, groceries []models.GroceryItem) bool {
+	for _, item := range groceries {
+		if usesIngredient(name, item.Name) || usesIngredient(item.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// fallbackRecipes returns the best library matches when the model is unavailable
+func (s *RecipeService) fallbackRecipes(groceries []models.GroceryItem, profile *models.FoodProfile, cause error) ([]models.Recipe, error) {
+	matches, err := s.matchLibrary(groceries, profile, fallbackRecipeCount)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%w: %v", ErrEmptyRecipeLibrary, cause)
+	}
+
+	log.Printf("Recipe generation unavailable (%v), falling back to the recipe library", cause)
+
+	recipes := make([]models.Recipe, 0, len(matches))
+	for _, match := range matches {
+		recipes = append(recipes, match.Recipe)
+	}
+	return recipes, nil
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"zero-waste-kitchen/internal/models"
+)
+
+// maxScaledServings bounds ?servings, matching the recipe schema
+const maxScaledServings = 100
+
+// Pantry availability of a scaled ingredient
+const (
+	AvailabilityAvailable = "available"
+	AvailabilityPartial   = "partial"
+	AvailabilityMissing   = "missing"
+)
+
+var (
+	ErrInvalidServings  = fmt.Errorf("servings must be between 1 and %d", maxScaledServings)
+	ErrUnscalableRecipe = errors.New("recipe has no serving count to scale from")
+)
+
+// ScaledIngredient is one recipe ingredient adjusted to the requested servings
+type ScaledIngredient struct {
+	Name           string  `json:"name"`
+	Quantity       float64 `json:"quantity"` // 0 when the recipe gives no quantity
+	Unit           string  `json:"unit"`
+	Text           string  `json:"text"` // The scaled ingredient line
+	Optional       bool    `json:"optional"`
+	Availability   string  `json:"availability"`              // available, partial or missing
+	PantryQuantity float64 `json:"pantry_quantity,omitempty"` // In Unit, when the pantry stock could be converted
+}
+
+// ScaledRecipe is a recipe with its ingredients adjusted to a different number of servings.
+// It is computed on request and never saved.
+type ScaledRecipe struct {
+	models.Recipe
+	OriginalServings  int                `json:"original_servings"`
+	Scale             float64            `json:"scale"`
+	ScaledIngredients []ScaledIngredient `json:"scaled_ingredients"`
+}
+
+// ScaleRecipe returns one of the household's recipes scaled to the given servings, with
+// quantities converted to sensible units and checked against the pantry stock not reserved for planned meals
+func (s *RecipeService) ScaleRecipe(userID uint, householdID uint, recipeID uint, servings int) (*ScaledRecipe, error) {
+	if servings < 1 || servings > maxScaledServings {
+		return nil, ErrInvalidServings
+	}
+
+	recipe, err := s.GetRecipeByID(householdID, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	if recipe.Servings <= 0 {
+		return nil, ErrUnscalableRecipe
+	}
+
+	groceries, err := s.groceryRepo.FindAll(householdID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get household groceries: %w", err)
+	}
+	profile, err := loadFoodProfile(s.profileRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	groceries = rankByExpiry(groceries) // Expired stock doesn't count as available
+	// Nor does stock already held back for planned meals
+	reserved, err := s.mealPlanRepo.ReservedQuantities(householdID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reserved stock: %w", err)
+	}
+
+	scale := float64(servings) / float64(recipe.Servings)
+	ingredients := scaleIngredients(*recipe, scale)
+	lines := make([]string, 0, len(ingredients))
+	for i := range ingredients {
+		checkAvailability(&ingredients[i], groceries, reserved, profile.Staples)
+		lines = append(lines, ingredients[i].Text)
+	}
+
+	scaled := &ScaledRecipe{
+		Recipe:            *recipe,
+		OriginalServings:  recipe.Servings,
+		Scale:             math.Round(scale*100) / 100,
+		ScaledIngredients: ingredients,
+	}
+	scaled.Servings = servings
+	scaled.Ingredients = strings.Join(lines, "\n")
+	return scaled, nil
+}
+
+// scaleIngredients scales a recipe's structured ingredients, or its ingredient lines when it has none
+func scaleIngredients(recipe models.Recipe, scale float64) []ScaledIngredient {
+	var scaled []ScaledIngredient
+
+	if len(recipe.IngredientList) > 0 {
+		for _, ingredient := range recipe.IngredientList {
+			quantity, unit := scaleQuantity(ingredient.Quantity, ingredient.Unit, scale)
+			scaled = append(scaled, ScaledIngredient{
+				Name:     ingredient.Name,
+				Quantity: quantity,
+				Unit:     unit,
+				Text:     formatIngredients([]models.RecipeIngredient{{Name: ingredient.Name, Quantity: quantity, Unit: unit, Optional: ingredient.Optional}}),
+				Optional: ingredient.Optional,
+			})
+		}
+		return scaled
+	}
+
+	for _, line := range strings.Split(recipe.Ingredients, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		parsed := parseIngredientLine(line)
+		ingredient := ScaledIngredient{
+			Name:     parsed.Name,
+			Text:     strings.TrimSpace(line), // Lines without a quantity, e.g. "salt to taste", stay as they are
+			Optional: parsed.Optional,
+		}
+		if parsed.Quantity > 0 {
+			ingredient.Quantity, ingredient.Unit = scaleQuantity(parsed.Quantity, parsed.Unit, scale)
+			ingredient.Text = strings.Join(strings.Fields(formatQuantity(ingredient.Quantity)+" "+ingredient.Unit+" "+parsed.Rest), " ")
+		}
+		scaled = append(scaled, ingredient)
+	}
+	return scaled
+}
+
+// scaleQuantity multiplies a quantity and converts it to a sensible unit
+func scaleQuantity(quantity float64, unit string, scale float64) (float64, string) {
+	if quantity <= 0 {
+		return 0, unit
+	}
+	quantity, converted := humanizeQuantity(quantity*scale, unit)
+	if converted == unitCup.symbol && quantity > 1 {
+		converted = "cups"
+	}
+	return quantity, converted
+}
+
+// checkAvailability compares a scaled ingredient with the matching pantry stock.
+// Stock that can't be converted to the ingredient's unit, e.g. a bag of flour
+// for a recipe in cups, counts as available since the amount is unknown.
+func checkAvailability(ingredient *ScaledIngredient, groceries []models.GroceryItem, reserved map[uint]float64, staples []string) {
+	if isStaple(ingredient.Name, staples) {
+		ingredient.Availability = AvailabilityAvailable
+		return
+	}
+
+	var matched, comparable bool
+	var stock float64
+	needBase, needDimension, needMeasured := toBaseUnit(ingredient.Quantity, ingredient.Unit)
+	for _, item := range groceries {
+		if !usesIngredient(ingredient.Name, item.Name) && !usesIngredient(item.Name, ingredient.Name) {
+			continue
+		}
+		matched = true
+
+		quantity := math.Max(item.Quantity-reserved[item.ID], 0)
+		switch base, dimension, measured := toBaseUnit(quantity, item.Unit); {
+		case needMeasured && measured && dimension == needDimension:
+			stock += base
+			comparable = true
+		case !needMeasured && !measured && sameCountUnit(ingredient.Unit, item.Unit):
+			// Counted items, e.g. 3 eggs against "2 eggs"
+			stock += quantity
+			comparable = true
+		}
+	}
+
+	switch {
+	case !matched:
+		ingredient.Availability = AvailabilityMissing
+	case !comparable || ingredient.Quantity == 0:
+		ingredient.Availability = AvailabilityAvailable
+	default:
+		need := ingredient.Quantity
+		if needMeasured {
+			need = needBase
+			info, _ := lookupUnit(ingredient.Unit)
+			ingredient.PantryQuantity = math.Round(stock/info.toBase*100) / 100
+		} else {
+			ingredient.PantryQuantity = stock
+		}
+
+		switch {
+		case stock >= need:
+			ingredient.Availability = AvailabilityAvailable
+		case stock > 0:
+			ingredient.Availability = AvailabilityPartial
+		default:
+			ingredient.Availability = AvailabilityMissing
+		}
+	}
+}
+
+// genericCountUnits mean "one of the item itself"
+var genericCountUnits = map[string]bool{"": true, "pc": true, "pcs": true, "piece": true, "pieces": true, "unit": true, "units": true}
+
+// sameCountUnit reports whether two count units can be compared, e.g. "can" and "cans"
+func sameCountUnit(a, b string) bool {
+	a = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(a)), "s")
+	b = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(b)), "s")
+	return a == b || (genericCountUnits[a] && genericCountUnits[b])
+}
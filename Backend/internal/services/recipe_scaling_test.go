@@ -0,0 +1,99 @@
+package services
+
+import (
+	"testing"
+	"zero-waste-kitchen/internal/models"
+)
+
+func TestScaleIngredients(t *testing.T) {
+	recipe := models.Recipe{
+		Servings:    4,
+		Ingredients: "2 cups rice\n500 g chicken, diced\n3 eggs\n1/2 tsp salt\npepper to taste\n\n1 can tomatoes (optional)",
+	}
+
+	tests := []struct {
+		name  string
+		scale float64
+		want  []string
+	}{
+		{"double", 2, []string{"4 cups rice", "1 kg chicken, diced", "6 eggs", "1 tsp salt", "pepper to taste", "2 can tomatoes (optional)"}},
+		{"half", 0.5, []string{"1 cup rice", "250 g chicken, diced", "1.5 eggs", "0.25 tsp salt", "pepper to taste", "0.5 can tomatoes (optional)"}},
+		{"a quarter", 0.25, []string{"0.5 cup rice", "125 g chicken, diced", "0.75 eggs", "0.125 tsp salt", "pepper to taste", "0.25 can tomatoes (optional)"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scaled := scaleIngredients(recipe, tt.scale)
+			if len(scaled) != len(tt.want) {
+				t.Fatalf("got %d ingredients, want %d", len(scaled), len(tt.want))
+			}
+			for i, ingredient := range scaled {
+				if ingredient.Text != tt.want[i] {
+					t.Errorf("ingredient %d = %q, want %q", i, ingredient.Text, tt.want[i])
+				}
+			}
+			if !scaled[len(scaled)-1].Optional {
+				t.Error("optional ingredient lost its flag")
+			}
+		})
+	}
+}
+
+func TestScaleStructuredIngredients(t *testing.T) {
+	recipe := models.Recipe{
+		Servings: 2,
+		IngredientList: []models.RecipeIngredient{
+			{Name: "milk", Quantity: 300, Unit: "ml"},
+			{Name: "oats", Quantity: 80, Unit: "g"},
+		},
+	}
+
+	scaled := scaleIngredients(recipe, 5)
+	want := []struct {
+		quantity float64
+		unit     string
+	}{{1.5, "L"}, {400, "g"}}
+	for i, ingredient := range scaled {
+		if ingredient.Quantity != want[i].quantity || ingredient.Unit != want[i].unit {
+			t.Errorf("ingredient %d = %v %s, want %v %s", i, ingredient.Quantity, ingredient.Unit, want[i].quantity, want[i].unit)
+		}
+	}
+}
+
+func TestCheckAvailability(t *testing.T) {
+	groceries := []models.GroceryItem{
+		{ID: 1, Name: "Rice", Quantity: 1, Unit: "kg"},
+		{ID: 2, Name: "eggs", Quantity: 6},
+		{ID: 3, Name: "milk", Quantity: 1, Unit: "L"},
+		{ID: 4, Name: "flour", Quantity: 1, Unit: "bag"},
+	}
+
+	tests := []struct {
+		name       string
+		ingredient ScaledIngredient
+		reserved   map[uint]float64
+		want       string
+		wantPantry float64
+	}{
+		{"enough", ScaledIngredient{Name: "rice", Quantity: 500, Unit: "g"}, nil, AvailabilityAvailable, 1000},
+		{"converted to the recipe's unit", ScaledIngredient{Name: "milk", Quantity: 2, Unit: "cups"}, nil, AvailabilityAvailable, 4.23},
+		{"counted", ScaledIngredient{Name: "eggs", Quantity: 3}, nil, AvailabilityAvailable, 6},
+		{"not enough", ScaledIngredient{Name: "eggs", Quantity: 8}, nil, AvailabilityPartial, 6},
+		{"reserved for a planned meal", ScaledIngredient{Name: "rice", Quantity: 750, Unit: "g"}, map[uint]float64{1: 0.5}, AvailabilityPartial, 500},
+		{"all reserved", ScaledIngredient{Name: "milk", Quantity: 250, Unit: "ml"}, map[uint]float64{3: 1}, AvailabilityMissing, 0},
+		{"over-reserved", ScaledIngredient{Name: "milk", Quantity: 250, Unit: "ml"}, map[uint]float64{3: 2}, AvailabilityMissing, 0},
+		{"unknown amount", ScaledIngredient{Name: "flour", Quantity: 2, Unit: "cups"}, nil, AvailabilityAvailable, 0},
+		{"not in the pantry", ScaledIngredient{Name: "butter", Quantity: 50, Unit: "g"}, nil, AvailabilityMissing, 0},
+		{"staple", ScaledIngredient{Name: "olive oil", Quantity: 2, Unit: "tbsp"}, nil, AvailabilityAvailable, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ingredient := tt.ingredient
+			checkAvailability(&ingredient, groceries, tt.reserved, []string{"olive oil"})
+			if ingredient.Availability != tt.want || ingredient.PantryQuantity != tt.wantPantry {
+				t.Errorf("availability = %s with %v in the pantry, want %s with %v", ingredient.Availability, ingredient.PantryQuantity, tt.want, tt.wantPantry)
+			}
+		})
+	}
+}
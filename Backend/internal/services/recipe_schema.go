@@ -0,0 +1,290 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"zero-waste-kitchen/internal/models"
+)
+
+// recipeSchemaJSON is the JSON Schema the model's reply must satisfy. It is sent
+// to the model as part of the prompt and used to validate every reply.
+const recipeSchemaJSON = `{
+  "type": "array",
+  "minItems": 1,
+  "maxItems": 10,
+  "items": {
+    "type": "object",
+    "required": ["title", "ingredients", "instructions", "prep_time", "cook_time", "servings", "difficulty", "cuisine"],
+    "properties": {
+      "title": {"type": "string", "minLength": 1, "maxLength": 255},
+      "ingredients": {"type": "string", "minLength": 1},
+      "instructions": {"type": "string", "minLength": 1},
+      "prep_time": {"type": "integer", "minimum": 0, "maximum": 1440},
+      "cook_time": {"type": "integer", "minimum": 0, "maximum": 1440},
+      "servings": {"type": "integer", "minimum": 1, "maximum": 100},
+      "difficulty": {"type": "string", "enum": ["Easy", "Medium", "Hard"]},
+      "cuisine": {"type": "string", "maxLength": 100}
+    }
+  }
+}`
+
+// jsonSchema is the subset of JSON Schema used by recipeSchemaJSON
+type jsonSchema struct {
+	Type       string                 `json:"type"`
+	Required   []string               `json:"required"`
+	Properties map[string]*jsonSchema `json:"properties"`
+	Items      *jsonSchema            `json:"items"`
+	Enum       []string               `json:"enum"`
+	Minimum    *float64               `json:"minimum"`
+	Maximum    *float64               `json:"maximum"`
+	MinLength  *int                   `json:"minLength"`
+	MaxLength  *int                   `json:"maxLength"`
+	MinItems   *int                   `json:"minItems"`
+	MaxItems   *int                   `json:"maxItems"`
+}
+
+var recipeSchema = mustParseSchema(recipeSchemaJSON)
+
+var ErrNoJSONFound = errors.New("no JSON found in response")
+
+func mustParseSchema(raw string) *jsonSchema {
+	var schema jsonSchema
+	if err := json.Unmarshal([]byte(raw), &schema); err != nil {
+		panic(fmt.Sprintf("invalid JSON schema: %v", err))
+	}
+	return &schema
+}
+
+// validate checks value against the schema and returns every problem found,
+// each prefixed with the JSON path of the offending field
+func (s *jsonSchema) validate(path string, value interface{}) []string {
+	var problems []string
+	fail := func(format string, args ...interface{}) {
+		problems = append(problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	switch s.Type {
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			fail("must be an array")
+			return problems
+		}
+		if s.MinItems != nil && len(items) < *s.MinItems {
+			fail("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(items) > *s.MaxItems {
+			fail("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				problems = append(problems, s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item)...)
+			}
+		}
+
+	case "object":
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			fail("must be an object")
+			return problems
+		}
+		for _, name := range s.Required {
+			if _, ok := fields[name]; !ok {
+				fail("missing required field %q", name)
+			}
+		}
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if fieldValue, ok := fields[name]; ok {
+				problems = append(problems, s.Properties[name].validate(path+"."+name, fieldValue)...)
+			}
+		}
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			fail("must be a string")
+			return problems
+		}
+		length := len([]rune(strings.TrimSpace(str)))
+		if s.MinLength != nil && length < *s.MinLength {
+			fail("must not be empty")
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			fail("must be at most %d characters", *s.MaxLength)
+		}
+		if len(s.Enum) > 0 && !containsString(s.Enum, str) {
+			fail("must be one of %s", strings.Join(s.Enum, ", "))
+		}
+
+	case "integer", "number":
+		number, ok := value.(float64)
+		if !ok {
+			fail("must be a number")
+			return problems
+		}
+		if s.Type == "integer" && number != math.Trunc(number) {
+			fail("must be a whole number")
+		}
+		if s.Minimum != nil && number < *s.Minimum {
+			fail("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && number > *s.Maximum {
+			fail("must be at most %v", *s.Maximum)
+		}
+	}
+
+	return problems
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// extractJSON returns the first complete JSON array or object in text, skipping
+// any prose or markdown around it
+func extractJSON(text string) (string, error) {
+	for start := 0; start < len(text); start++ {
+		if text[start] != '[' && text[start] != '{' {
+			continue
+		}
+		if end := matchingBracket(text, start); end > 0 && json.Valid([]byte(text[start:end+1])) {
+			return text[start : end+1], nil
+		}
+	}
+	return "", ErrNoJSONFound
+}
+
+// matchingBracket returns the index of the bracket closing the one at start, or -1
+func matchingBracket(text string, start int) int {
+	var stack []byte
+	inString, escaped := false, false
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '[':
+			stack = append(stack, ']')
+		case '{':
+			stack = append(stack, '}')
+		case ']', '}':
+			if len(stack) == 0 || stack[len(stack)-1] != c {
+				return -1
+			}
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseRecipes extracts, validates and decodes the recipes in a model reply.
+// A single recipe object, or an object wrapping a "recipes" array, is accepted too.
+func parseRecipes(content string) ([]models.Recipe, error) {
+	raw, err := extractJSON(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	if object, ok := value.(map[string]interface{}); ok {
+		if wrapped, ok := object["recipes"]; ok {
+			value = wrapped
+		} else {
+			value = []interface{}{object}
+		}
+	}
+
+	if problems := recipeSchema.validate("$", value); len(problems) > 0 {
+		return nil, &schemaError{Problems: problems}
+	}
+	return decodeRecipes(value)
+}
+
+// parseRecipeObject validates and decodes a single recipe object, as found while streaming
+func parseRecipeObject(raw string) (models.Recipe, error) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return models.Recipe{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	if problems := recipeSchema.Items.validate("$", value); len(problems) > 0 {
+		return models.Recipe{}, &schemaError{Problems: problems}
+	}
+
+	recipes, err := decodeRecipes([]interface{}{value})
+	if err != nil {
+		return models.Recipe{}, err
+	}
+	return recipes[0], nil
+}
+
+// decodeRecipes converts schema-valid JSON values into recipes
+func decodeRecipes(value interface{}) ([]models.Recipe, error) {
+	normalized, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var recipes []models.Recipe
+	if err := json.NewDecoder(bytes.NewReader(normalized)).Decode(&recipes); err != nil {
+		return nil, fmt.Errorf("invalid recipe JSON: %w", err)
+	}
+
+	// Keep only the fields the schema allows, so the model can't set IDs or scores
+	for i, r := range recipes {
+		recipes[i] = models.Recipe{
+			Title:        strings.TrimSpace(r.Title),
+			Ingredients:  strings.TrimSpace(r.Ingredients),
+			Instructions: strings.TrimSpace(r.Instructions),
+			PrepTime:     r.PrepTime,
+			CookTime:     r.CookTime,
+			Servings:     r.Servings,
+			Difficulty:   r.Difficulty,
+			Cuisine:      r.Cuisine,
+		}
+	}
+	return recipes, nil
+}
+
+// schemaError lists every schema violation in a model reply
+type schemaError struct {
+	Problems []string
+}
+
+func (e *schemaError) Error() string {
+	return "response does not match the recipe schema: " + strings.Join(e.Problems, "; ")
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"zero-waste-kitchen/internal/llm"
+)
+
+const validRecipeJSON = `{"title": "Omelette", "ingredients": "2 eggs\n1 tbsp butter", "instructions": "Step 1: Whisk\nStep 2: Fry",
+	"prep_time": 5, "cook_time": 5, "servings": 1, "difficulty": "Easy", "cuisine": "French"}`
+
+func TestParseRecipes(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantCount int
+		wantErr   error
+		problems  []string // Expected in the schema error
+	}{
+		{
+			name:      "array",
+			content:   "[" + validRecipeJSON + "," + validRecipeJSON + "]",
+			wantCount: 2,
+		},
+		{
+			name:      "prose and markdown around the JSON",
+			content:   "Sure! Here you go:\n```json\n[" + validRecipeJSON + "]\n```\nEnjoy [your meal]",
+			wantCount: 1,
+		},
+		{
+			name:      "single object",
+			content:   validRecipeJSON,
+			wantCount: 1,
+		},
+		{
+			name:      "wrapped in a recipes object",
+			content:   `{"recipes": [` + validRecipeJSON + `]}`,
+			wantCount: 1,
+		},
+		{
+			name:    "no JSON",
+			content: "I can't think of any recipes",
+			wantErr: ErrNoJSONFound,
+		},
+		{
+			name:     "empty array",
+			content:  "[]",
+			problems: []string{"$: must have at least 1 items"},
+		},
+		{
+			name:    "every problem is listed",
+			content: `[{"title": " ", "ingredients": "eggs", "instructions": "Fry", "prep_time": -1, "cook_time": 2.5, "difficulty": "Trivial", "cuisine": "Any"}]`,
+			problems: []string{
+				`$[0]: missing required field "servings"`,
+				"$[0].title: must not be empty",
+				"$[0].prep_time: must be at least 0",
+				"$[0].cook_time: must be a whole number",
+				"$[0].difficulty: must be one of Easy, Medium, Hard",
+			},
+		},
+		{
+			name:     "wrong types",
+			content:  `[{"title": 1, "ingredients": "eggs", "instructions": "Fry", "prep_time": "5", "cook_time": 5, "servings": 1, "difficulty": "Easy", "cuisine": "Any"}]`,
+			problems: []string{"$[0].title: must be a string", "$[0].prep_time: must be a number"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recipes, err := parseRecipes(tt.content)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if len(tt.problems) > 0 {
+				var schemaErr *schemaError
+				if !errors.As(err, &schemaErr) {
+					t.Fatalf("err = %v, want a schema error", err)
+				}
+				if len(schemaErr.Problems) != len(tt.problems) {
+					t.Errorf("problems = %q, want %q", schemaErr.Problems, tt.problems)
+				}
+				for _, problem := range tt.problems {
+					if !containsString(schemaErr.Problems, problem) {
+						t.Errorf("problems = %q, missing %q", schemaErr.Problems, problem)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(recipes) != tt.wantCount {
+				t.Fatalf("got %d recipes, want %d", len(recipes), tt.wantCount)
+			}
+			if recipes[0].Title != "Omelette" || recipes[0].Servings != 1 || recipes[0].Difficulty != "Easy" {
+				t.Errorf("recipe = %+v", recipes[0])
+			}
+		})
+	}
+}
+
+func TestParseRecipesDropsUnknownFields(t *testing.T) {
+	content := `[{"id": 99, "waste_rescued_score": 100, "favorite": true,` + strings.TrimPrefix(validRecipeJSON, "{") + `]`
+	recipes, err := parseRecipes(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if recipes[0].ID != 0 || recipes[0].WasteRescuedScore != 0 || recipes[0].Favorite {
+		t.Errorf("model set fields outside the schema: %+v", recipes[0])
+	}
+}
+
+func TestCompleteRecipesRepair(t *testing.T) {
+	invalid := strings.Replace(validRecipeJSON, `"servings": 1, `, "", 1)
+	tests := []struct {
+		name       string
+		responses  []string
+		wantCalls  int
+		wantRepair string // In the last repair prompt
+		wantErr    error
+	}{
+		{
+			name:      "valid reply",
+			responses: []string{"[" + validRecipeJSON + "]"},
+			wantCalls: 1,
+		},
+		{
+			name:       "schema problems are sent back",
+			responses:  []string{"[" + invalid + "]", "[" + validRecipeJSON + "]"},
+			wantCalls:  2,
+			wantRepair: `$[0]: missing required field "servings"`,
+		},
+		{
+			name:       "unparsable reply is sent back",
+			responses:  []string{"No recipes today", "[" + validRecipeJSON + "]"},
+			wantCalls:  2,
+			wantRepair: ErrNoJSONFound.Error(),
+		},
+		{
+			name:       "gives up after the repair attempts",
+			responses:  []string{"[" + invalid + "]"},
+			wantCalls:  maxRepairAttempts + 1,
+			wantRepair: `$[0]: missing required field "servings"`,
+			wantErr:    ErrInvalidAIResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := llm.NewFakeClient(tt.responses...)
+			service := &RecipeService{llmClient: client}
+			gen := &recipeGeneration{} // No expiring items to check
+			prompt := []llm.Message{{Role: "user", Content: "Generate recipes"}}
+
+			recipes, err := service.completeRecipes(context.Background(), gen, prompt)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil || len(recipes) != 1 {
+				t.Fatalf("recipes = %+v, err = %v", recipes, err)
+			}
+
+			calls := client.Calls()
+			if len(calls) != tt.wantCalls {
+				t.Fatalf("model calls = %d, want %d", len(calls), tt.wantCalls)
+			}
+			if tt.wantRepair == "" {
+				return
+			}
+			// The last call shows the model its reply and what was wrong with it
+			last := calls[len(calls)-1]
+			if len(last) != 1+2*(tt.wantCalls-1) {
+				t.Fatalf("last call has %d messages, want %d", len(last), 1+2*(tt.wantCalls-1))
+			}
+			repair := last[len(last)-1]
+			if last[len(last)-2].Role != "assistant" || repair.Role != "user" {
+				t.Errorf("roles = %s, %s, want assistant, user", last[len(last)-2].Role, repair.Role)
+			}
+			if !strings.Contains(repair.Content, tt.wantRepair) || !strings.Contains(repair.Content, recipeSchemaJSON) {
+				t.Errorf("repair prompt %q doesn't contain %q and the schema", repair.Content, tt.wantRepair)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"zero-waste-kitchen/internal/llm"
+	"zero-waste-kitchen/internal/models"
+)
+
+// Recipe stream event types
+const (
+	RecipeEventProgress = "progress"
+	RecipeEventRecipe   = "recipe"
+	RecipeEventDone     = "done"
+	RecipeEventError    = "error"
+)
+
+// Progress stages reported while streaming
+const (
+	StagePreparing  = "preparing"
+	StageGenerating = "generating"
+	StageRepairing  = "repairing"
+	StageCached     = "cached"
+	StageFallback   = "fallback"
+)
+
+// RecipeEvent is one server-sent event of a streamed recipe generation
+type RecipeEvent struct {
+	Type    string         `json:"-"`
+	Stage   string         `json:"stage,omitempty"`
+	Message string         `json:"message,omitempty"`
+	Recipe  *models.Recipe `json:"recipe,omitempty"`
+	Count   int            `json:"count"` // Recipes sent so far
+}
+
+// GenerateRecipesStream generates recipes like GenerateRecipes, but calls emit
+// with each recipe as soon as it has been parsed, validated and saved, and with
+// progress events in between. Cancelling ctx stops the model call.
+func (s *RecipeService) GenerateRecipesStream(ctx context.Context, userID uint, householdID uint, cuisinePreference string, minExpiring int, emit func(RecipeEvent)) error {
+	emit(RecipeEvent{Type: RecipeEventProgress, Stage: StagePreparing})
+
+	gen, err := s.prepareGeneration(userID, householdID, cuisinePreference, minExpiring)
+	if err != nil {
+		return err
+	}
+
+	stream := &recipeStream{emit: emit, sent: map[uint]bool{}}
+
+	if s.llmClient == nil {
+		return s.streamFallback(gen, stream, llm.ErrNotConfigured)
+	}
+
+	cached, err := s.cachedRecipes(gen)
+	if err != nil {
+		return err
+	}
+	if len(cached) > 0 {
+		emit(RecipeEvent{Type: RecipeEventProgress, Stage: StageCached, Message: "Reusing recipes generated for the same pantry"})
+		if err := s.streamRecipes(gen, stream, cached); err != nil {
+			return err
+		}
+		s.recordGeneration(gen, models.GenerationSourceCache, stream.recipes)
+		emit(RecipeEvent{Type: RecipeEventDone, Count: stream.count})
+		return nil
+	}
+
+	if err := s.checkQuota(userID); err != nil {
+		return err
+	}
+
+	emit(RecipeEvent{Type: RecipeEventProgress, Stage: StageGenerating})
+
+	var saveErr error
+	var content string
+	if streaming, ok := s.llmClient.(llm.StreamingClient); ok {
+		var parser recipeStreamParser
+		completion, err := streaming.CompleteStream(ctx, gen.messages, func(delta string) {
+			for _, raw := range parser.feed(delta) {
+				recipe, err := parseRecipeObject(raw)
+				if err != nil {
+					log.Printf("Skipping invalid streamed recipe: %v", err)
+					continue
+				}
+				if err := s.streamRecipes(gen, stream, []models.Recipe{recipe}); err != nil && saveErr == nil {
+					saveErr = err
+				}
+			}
+		})
+		if completion != nil {
+			gen.addUsage(completion)
+			content = completion.Content
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				s.recordGeneration(gen, models.GenerationSourceModel, stream.recipes)
+				return ctx.Err()
+			}
+			if stream.count == 0 {
+				s.recordGeneration(gen, models.GenerationSourceModel, nil)
+				return s.streamFallback(gen, stream, err)
+			}
+		}
+	}
+	if saveErr != nil {
+		s.recordGeneration(gen, models.GenerationSourceModel, stream.recipes)
+		return saveErr
+	}
+
+	// Nothing usable arrived while streaming, so ask again with repairs
+	if stream.count == 0 {
+		messages := gen.messages
+		if content != "" {
+			emit(RecipeEvent{Type: RecipeEventProgress, Stage: StageRepairing})
+			recipes, parseErr := parseRecipes(content)
+			if parseErr == nil {
+				parseErr = gen.checkExpiring(recipes)
+			}
+			if parseErr == nil {
+				parseErr = gen.noRecipesError()
+			}
+			messages = append(messages,
+				llm.Message{Role: "assistant", Content: content},
+				llm.Message{Role: "user", Content: repairPrompt(parseErr)},
+			)
+		}
+
+		recipes, err := s.completeRecipes(ctx, gen, messages)
+		if err != nil {
+			s.recordGeneration(gen, models.GenerationSourceModel, nil)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return s.streamFallback(gen, stream, err)
+		}
+		if err := s.streamRecipes(gen, stream, recipes); err != nil {
+			s.recordGeneration(gen, models.GenerationSourceModel, stream.recipes)
+			return err
+		}
+	}
+	s.recordGeneration(gen, models.GenerationSourceModel, stream.recipes)
+
+	if stream.count == 0 {
+		return gen.noRecipesError()
+	}
+
+	emit(RecipeEvent{Type: RecipeEventDone, Count: stream.count})
+	return nil
+}
+
+// recipeStream tracks what has been sent to one streaming client
+type recipeStream struct {
+	emit    func(RecipeEvent)
+	sent    map[uint]bool
+	count   int
+	recipes []models.Recipe // Sent so far
+}
+
+// streamRecipes finishes recipes and sends each one that wasn't sent before
+func (s *RecipeService) streamRecipes(gen *recipeGeneration, stream *recipeStream, recipes []models.Recipe) error {
+	finished, err := s.finishRecipes(gen, recipes)
+	if err != nil {
+		return err
+	}
+
+	for i := range finished {
+		if stream.sent[finished[i].ID] {
+			continue
+		}
+		stream.sent[finished[i].ID] = true
+		stream.count++
+		stream.recipes = append(stream.recipes, finished[i])
+		stream.emit(RecipeEvent{Type: RecipeEventRecipe, Recipe: &finished[i], Count: stream.count})
+	}
+	return nil
+}
+
+// streamFallback sends the best library matches when the model is unavailable
+func (s *RecipeService) streamFallback(gen *recipeGeneration, stream *recipeStream, cause error) error {
+	stream.emit(RecipeEvent{Type: RecipeEventProgress, Stage: StageFallback, Message: "Using the local recipe library"})
+
+	recipes, err := s.fallbackRecipes(gen.groceries, gen.profile, cause)
+	if err != nil {
+		return err
+	}
+
+	for i := range recipes {
+		stream.count++
+		stream.emit(RecipeEvent{Type: RecipeEventRecipe, Recipe: &recipes[i], Count: stream.count})
+	}
+	stream.emit(RecipeEvent{Type: RecipeEventDone, Count: stream.count})
+	return nil
+}
+
+// recipeStreamParser picks complete recipe objects out of a JSON reply while it
+// is still arriving. Recipes are the objects directly inside the top-level array,
+// or inside the array of a top-level wrapper object.
+type recipeStreamParser struct {
+	started  bool
+	stack    []byte
+	inString bool
+	escaped  bool
+	current  strings.Builder
+	objStart int // Depth at which the current recipe object started, or -1
+}
+
+// feed consumes the next piece of the reply and returns the recipe objects it completed
+func (p *recipeStreamParser) feed(delta string) []string {
+	var complete []string
+	for i := 0; i < len(delta); i++ {
+		c := delta[i]
+
+		if !p.started {
+			if c != '[' && c != '{' {
+				continue
+			}
+			p.started = true
+			p.objStart = -1
+		}
+
+		if p.objStart >= 0 {
+			p.current.WriteByte(c)
+		}
+
+		if p.inString {
+			switch {
+			case p.escaped:
+				p.escaped = false
+			case c == '\\':
+				p.escaped = true
+			case c == '"':
+				p.inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			p.inString = true
+		case '[':
+			p.stack = append(p.stack, c)
+		case '{':
+			if p.objStart < 0 && p.isRecipeParent() {
+				p.objStart = len(p.stack)
+				p.current.Reset()
+				p.current.WriteByte(c)
+			}
+			p.stack = append(p.stack, c)
+		case ']', '}':
+			if len(p.stack) == 0 {
+				continue
+			}
+			p.stack = p.stack[:len(p.stack)-1]
+			if c == '}' && p.objStart == len(p.stack) {
+				complete = append(complete, p.current.String())
+				p.current.Reset()
+				p.objStart = -1
+			}
+		}
+	}
+	return complete
+}
+
+// isRecipeParent reports whether an object opened now would be a recipe: its
+// parent must be an array that is either top-level or inside a top-level object
+func (p *recipeStreamParser) isRecipeParent() bool {
+	switch len(p.stack) {
+	case 1:
+		return p.stack[0] == '['
+	case 2:
+		return p.stack[0] == '{' && p.stack[1] == '['
+	default:
+		return false
+	}
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"zero-waste-kitchen/internal/llm"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/repositories"
+)
+
+// fakeGroceryRepo serves a fixed pantry. Methods the tests don't need panic.
+type fakeGroceryRepo struct {
+	repositories.GroceryRepository
+	items []models.GroceryItem
+}
+
+func (r *fakeGroceryRepo) FindAll(householdID uint) ([]models.GroceryItem, error) {
+	return r.items, nil
+}
+
+// fakeRecipeRepo keeps saved recipes in memory
+type fakeRecipeRepo struct {
+	repositories.RecipeRepository
+	saved []models.Recipe
+}
+
+func (r *fakeRecipeRepo) Save(recipe *models.Recipe) error {
+	if recipe.ID == 0 {
+		recipe.ID = uint(len(r.saved) + 1)
+	}
+	r.saved = append(r.saved, *recipe)
+	return nil
+}
+
+func (r *fakeRecipeRepo) FindByHouseholdID(householdID uint) ([]models.Recipe, error) {
+	return nil, nil
+}
+
+func (r *fakeRecipeRepo) FindFeedbackByHouseholdID(householdID uint) ([]models.Recipe, error) {
+	return nil, nil
+}
+
+func (r *fakeRecipeRepo) FindAll() ([]models.Recipe, error) {
+	return nil, nil // An empty library, so a failed generation can't fall back
+}
+
+// fakeProfileRepo returns profile, or no profile when it is nil
+type fakeProfileRepo struct {
+	repositories.FoodProfileRepository
+	profile *models.FoodProfile
+}
+
+func (r *fakeProfileRepo) FindByUserID(userID uint) (*models.FoodProfile, error) {
+	if r.profile == nil {
+		return nil, repositories.ErrRecordNotFound
+	}
+	return r.profile, nil
+}
+
+// fakeGenerationRepo discards recorded generations
+type fakeGenerationRepo struct {
+	repositories.RecipeGenerationRepository
+}
+
+func (r *fakeGenerationRepo) Save(generation *models.RecipeGeneration) error {
+	return nil
+}
+
+const (
+	// usesExpiringReply uses both soonest-expiring items of testPantry
+	usesExpiringReply = `[{"title": "Spinach Milk Soup", "ingredients": "1 cup milk\n2 cups spinach\n1 cup rice",
+		"instructions": "Step 1: Simmer everything", "prep_time": 5, "cook_time": 15, "servings": 2,
+		"difficulty": "Easy", "cuisine": "Any"}]`
+	// skipsExpiringReply only uses the rice, which doesn't expire
+	skipsExpiringReply = `[{"title": "Plain Rice", "ingredients": "1 cup rice\n2 cups water",
+		"instructions": "Step 1: Boil the rice", "prep_time": 5, "cook_time": 15, "servings": 2,
+		"difficulty": "Easy", "cuisine": "Any"}]`
+)
+
+// testPantry has two items expiring soon and one without an expiry date
+func testPantry() []models.GroceryItem {
+	now := time.Now()
+	return []models.GroceryItem{
+		{ID: 1, Name: "milk", Quantity: 1, Unit: "l", ExpiryDate: now.Add(36 * time.Hour)},
+		{ID: 2, Name: "spinach", Quantity: 200, Unit: "g", ExpiryDate: now.Add(60 * time.Hour)},
+		{ID: 3, Name: "rice", Quantity: 1, Unit: "kg"},
+	}
+}
+
+func newTestRecipeService(client llm.Client, profile *models.FoodProfile) (*RecipeService, *fakeRecipeRepo) {
+	recipeRepo := &fakeRecipeRepo{}
+	service := NewRecipeService(&fakeGroceryRepo{items: testPantry()}, recipeRepo, &fakeProfileRepo{profile: profile}, &fakeGenerationRepo{}, nil, client, GenerationLimits{})
+	return service, recipeRepo
+}
+
+func TestGenerateRecipes(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []string
+		profile   *models.FoodProfile
+		wantTitle string
+		wantErr   error
+		wantCalls int
+	}{
+		{
+			name:      "uses the expiring items",
+			responses: []string{usesExpiringReply},
+			wantTitle: "Spinach Milk Soup",
+			wantCalls: 1,
+		},
+		{
+			name:      "repairs invalid JSON",
+			responses: []string{"Here are your recipes!", usesExpiringReply},
+			wantTitle: "Spinach Milk Soup",
+			wantCalls: 2,
+		},
+		{
+			name:      "repairs recipes that skip the expiring items",
+			responses: []string{skipsExpiringReply, usesExpiringReply},
+			wantTitle: "Spinach Milk Soup",
+			wantCalls: 2,
+		},
+		{
+			name:      "gives up after the repair attempts",
+			responses: []string{skipsExpiringReply},
+			wantErr:   ErrEmptyRecipeLibrary,
+			wantCalls: maxRepairAttempts + 1,
+		},
+		{
+			name:      "rejects recipes with an allergen",
+			responses: []string{usesExpiringReply},
+			profile:   &models.FoodProfile{Allergens: []string{"milk"}},
+			wantErr:   ErrNoSafeRecipes,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := llm.NewFakeClient(tt.responses...)
+			service, recipeRepo := newTestRecipeService(client, tt.profile)
+
+			recipes, err := service.GenerateRecipes(context.Background(), 1, 1, "Any", 2)
+			if got := len(client.Calls()); got != tt.wantCalls {
+				t.Errorf("model calls = %d, want %d", got, tt.wantCalls)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(recipes) != 1 || recipes[0].Title != tt.wantTitle {
+				t.Fatalf("recipes = %+v, want %q", recipes, tt.wantTitle)
+			}
+			if recipes[0].WasteRescuedScore != 100 {
+				t.Errorf("waste rescued score = %v, want 100", recipes[0].WasteRescuedScore)
+			}
+			if len(recipeRepo.saved) != 1 {
+				t.Errorf("saved %d recipes, want 1", len(recipeRepo.saved))
+			}
+		})
+	}
+}
+
+func TestGenerateRecipesStream(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []string
+		wantTitle string
+		wantErr   error
+	}{
+		{
+			name:      "streams the recipe",
+			responses: []string{usesExpiringReply},
+			wantTitle: "Spinach Milk Soup",
+		},
+		{
+			name:      "repairs recipes that skip the expiring items",
+			responses: []string{skipsExpiringReply, usesExpiringReply},
+			wantTitle: "Spinach Milk Soup",
+		},
+		{
+			name:      "gives up after the repair attempts",
+			responses: []string{skipsExpiringReply},
+			wantErr:   ErrEmptyRecipeLibrary,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := newTestRecipeService(llm.NewFakeClient(tt.responses...), nil)
+
+			var recipes []models.Recipe
+			var done bool
+			err := service.GenerateRecipesStream(context.Background(), 1, 1, "Any", 2, func(event RecipeEvent) {
+				switch event.Type {
+				case RecipeEventRecipe:
+					recipes = append(recipes, *event.Recipe)
+				case RecipeEventDone:
+					done = true
+				}
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(recipes) != 1 || recipes[0].Title != tt.wantTitle {
+				t.Fatalf("recipes = %+v, want %q", recipes, tt.wantTitle)
+			}
+			if !done {
+				t.Error("no done event")
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"strings"
+	"time"
+	"zero-waste-kitchen/internal/models"
+)
+
+// shelfLifeRules give typical shelf lives by ingredient, checked in order
+var shelfLifeRules = []struct {
+	terms    []string
+	location models.StorageLocation
+	days     int
+}{
+	{[]string{"frozen", "ice cream"}, models.DeepFreeze, 90},
+	{allergenTerms["fish"], models.Refrigerator, 2},
+	{allergenTerms["shellfish"], models.Refrigerator, 2},
+	{[]string{"minced", "ground beef", "mince"}, models.Refrigerator, 2},
+	{meatTerms, models.Refrigerator, 3},
+	{[]string{"milk", "cream", "yogurt", "yoghurt"}, models.Refrigerator, 7},
+	{[]string{"cheese", "butter"}, models.Refrigerator, 21},
+	{[]string{"egg"}, models.Refrigerator, 28},
+	{[]string{"lettuce", "spinach", "herb", "basil", "cilantro", "parsley", "berries", "strawberries", "raspberries", "mushroom"}, models.Refrigerator, 5},
+	{[]string{"broccoli", "cauliflower", "zucchini", "cucumber", "bell pepper", "tomato", "avocado", "tofu"}, models.Refrigerator, 7},
+	{[]string{"carrot", "cabbage", "celery", "apple", "orange", "lemon", "lime"}, models.Refrigerator, 21},
+	{[]string{"bread", "tortilla", "bun", "bagel"}, models.DryPantry, 5},
+	{[]string{"banana", "pear", "peach"}, models.DryPantry, 5},
+	{[]string{"potato", "onion", "garlic", "sweet potato"}, models.DryPantry, 30},
+	{[]string{"rice", "pasta", "noodle", "flour", "sugar", "oats", "lentil", "bean", "chickpea", "quinoa", "canned", "salt", "oil", "honey", "vinegar", "sauce", "spice"}, models.DryPantry, 365},
+}
+
+// defaultShelfLife is used by storage location when nothing better is known
+var defaultShelfLife = map[models.StorageLocation]int{
+	models.DeepFreeze:   90,
+	models.Refrigerator: 7,
+	models.DryPantry:    180,
+}
+
+// estimateShelfLife guesses where a product is stored and when it expires. The
+// user's own history of the product wins, then the given location and days,
+// then typical shelf lives.
+func estimateShelfLife(name string, location string, days int, history []models.GroceryItem) (models.StorageLocation, time.Time) {
+	now := time.Now()
+	storage := models.StorageLocation(location)
+
+	// Average shelf life of the same product bought before
+	var total float64
+	var count int
+	for _, item := range history {
+		if !strings.EqualFold(strings.TrimSpace(item.Name), strings.TrimSpace(name)) || item.ExpiryDate.IsZero() || item.CreatedAt.IsZero() {
+			continue
+		}
+		if lifetime := item.ExpiryDate.Sub(item.CreatedAt).Hours() / 24; lifetime > 0 {
+			total += lifetime
+			count++
+			if storage == "" {
+				storage = models.StorageLocation(item.StorageLocation)
+			}
+		}
+	}
+	if count > 0 && days <= 0 {
+		days = int(total/float64(count) + 0.5)
+	}
+
+	lower := strings.ToLower(name)
+	if days <= 0 || storage == "" {
+		for _, rule := range shelfLifeRules {
+			if findTerm(lower, rule.terms) == "" {
+				continue
+			}
+			if storage == "" {
+				storage = rule.location
+			}
+			if days <= 0 {
+				days = rule.days
+			}
+			break
+		}
+	}
+
+	if storage == "" {
+		storage = models.Refrigerator
+	}
+	if days <= 0 {
+		days = defaultShelfLife[storage]
+	}
+	return storage, now.AddDate(0, 0, days)
+}
@@ -0,0 +1,554 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/repositories"
+)
+
+// ShoppingItemInput is an item added to a shopping list by hand
+type ShoppingItemInput struct {
+	Name     string
+	Quantity float64 // 0 when no amount is given
+	Unit     string
+}
+
+// ShoppingItemChanges is a partial update of a shopping list item. Checking an
+// item off with AddToPantry set adds it to the pantry with an estimated expiry date.
+type ShoppingItemChanges struct {
+	Name            *string
+	Quantity        *float64
+	Unit            *string
+	Checked         *bool
+	AddToPantry     bool
+	StorageLocation string     // Overrides the estimated storage location
+	ExpiryDate      *time.Time // Overrides the estimated expiry date
+}
+
+// ShoppingListGenerateInput says what a generated shopping list should cover
+type ShoppingListGenerateInput struct {
+	Name             string
+	From, To         time.Time // Planned meals in this range are included; zero to skip the meal plan
+	RecipeIDs        []uint
+	IncludeParLevels bool
+}
+
+type ShoppingListService interface {
+	GetLists(householdID uint) ([]models.ShoppingList, error)
+	GetList(householdID uint, id uint) (*models.ShoppingList, error)
+	CreateList(userID uint, householdID uint, name string, items []ShoppingItemInput) (*models.ShoppingList, error)
+	GenerateList(userID uint, householdID uint, input ShoppingListGenerateInput) (*models.ShoppingList, error)
+	DeleteList(householdID uint, id uint) error
+	AddItem(householdID uint, listID uint, input ShoppingItemInput) (*models.ShoppingList, error)
+	UpdateItem(userID uint, householdID uint, listID uint, itemID uint, changes ShoppingItemChanges) (*models.ShoppingList, error)
+	DeleteItem(householdID uint, listID uint, itemID uint) (*models.ShoppingList, error)
+
+	GetParLevels(householdID uint) ([]models.ParLevel, error)
+	SetParLevel(level *models.ParLevel) error
+	DeleteParLevel(householdID uint, id uint) error
+}
+
+type shoppingListService struct {
+	repo         repositories.ShoppingListRepository
+	mealPlanRepo repositories.MealPlanRepository
+	groceryRepo  repositories.GroceryRepository
+	recipeRepo   repositories.RecipeRepository
+	profileRepo  repositories.FoodProfileRepository
+	purchaseRepo repositories.PurchaseRepository
+}
+
+func NewShoppingListService(repo repositories.ShoppingListRepository, mealPlanRepo repositories.MealPlanRepository, groceryRepo repositories.GroceryRepository, recipeRepo repositories.RecipeRepository, profileRepo repositories.FoodProfileRepository, purchaseRepo repositories.PurchaseRepository) ShoppingListService {
+	return &shoppingListService{repo: repo, mealPlanRepo: mealPlanRepo, groceryRepo: groceryRepo, recipeRepo: recipeRepo, profileRepo: profileRepo, purchaseRepo: purchaseRepo}
+}
+
+// GetLists returns the household's shopping lists, newest first, warning about
+// items the pantry already holds enough of
+func (s *shoppingListService) GetLists(householdID uint) ([]models.ShoppingList, error) {
+	lists, err := s.repo.FindAll(householdID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shopping lists: %w", err)
+	}
+	if err := s.annotateLists(householdID, lists); err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+// GetList returns one of the household's shopping lists, warning about items the
+// pantry already holds enough of
+func (s *shoppingListService) GetList(householdID uint, id uint) (*models.ShoppingList, error) {
+	list, err := s.findList(householdID, id)
+	if err != nil {
+		return nil, err
+	}
+	lists := []models.ShoppingList{*list}
+	if err := s.annotateLists(householdID, lists); err != nil {
+		return nil, err
+	}
+	return &lists[0], nil
+}
+
+// CreateList creates a shopping list from items entered by hand
+func (s *shoppingListService) CreateList(userID uint, householdID uint, name string, items []ShoppingItemInput) (*models.ShoppingList, error) {
+	list := &models.ShoppingList{UserID: userID, HouseholdID: householdID, Name: listName(name, "Shopping list")}
+	for _, input := range items {
+		if err := validateShoppingItem(input.Name, input.Quantity); err != nil {
+			return nil, err
+		}
+		list.Items, _ = mergeShoppingItem(list.Items, strings.TrimSpace(input.Name), input.Quantity, strings.TrimSpace(input.Unit), models.ShoppingSourceManual)
+	}
+	return s.saveList(list)
+}
+
+// GenerateList creates a shopping list of what the pantry can't cover: the
+// planned meals in the range, the given recipes and stock below its par level.
+// Items needed more than once are merged, converting units where possible.
+func (s *shoppingListService) GenerateList(userID uint, householdID uint, input ShoppingListGenerateInput) (*models.ShoppingList, error) {
+	groceries, err := s.groceryRepo.FindAll(householdID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get household groceries: %w", err)
+	}
+	profile, err := loadFoodProfile(s.profileRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	// Stock already held back for planned meals isn't available for anything else
+	reserved, err := s.mealPlanRepo.ReservedQuantities(householdID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reserved stock: %w", err)
+	}
+
+	list := &models.ShoppingList{UserID: userID, HouseholdID: householdID}
+	addShortfalls := func(shortfalls []stockShortfall, source string) {
+		for _, shortfall := range shortfalls {
+			list.Items, _ = mergeShoppingItem(list.Items, shortfall.Name, shortfall.Quantity, shortfall.Unit, source)
+		}
+	}
+
+	if !input.From.IsZero() {
+		from, to := dateOnly(input.From), dateOnly(input.To)
+		entries, err := s.mealPlanRepo.FindRange(householdID, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get meal plan: %w", err)
+		}
+		sortMealPlan(entries)
+
+		for _, entry := range entries {
+			if entry.Status != models.MealPlanPlanned || entry.Recipe == nil {
+				continue
+			}
+			// Work out the entry again with its own reservations released, so
+			// stock bought or used since it was planned is taken into account
+			available := make(map[uint]float64, len(reserved))
+			for id, quantity := range reserved {
+				available[id] = quantity
+			}
+			for _, reservation := range entry.Reservations {
+				available[reservation.GroceryItemID] -= reservation.Quantity
+			}
+
+			_, shortfalls := allocateStock(scaleIngredients(*entry.Recipe, entryScale(entry)), groceries, available, entry.Date, profile.Staples)
+			addShortfalls(shortfalls, models.ShoppingSourceMealPlan)
+		}
+		list.Name = fmt.Sprintf("Meals %s to %s", from.Format(dateLayout), to.Format(dateLayout))
+	}
+
+	for _, recipeID := range input.RecipeIDs {
+		recipe, err := s.recipeRepo.FindByID(householdID, recipeID)
+		if err != nil {
+			if errors.Is(err, repositories.ErrRecordNotFound) {
+				return nil, models.ErrRecipeNotFound
+			}
+			return nil, fmt.Errorf("failed to get recipe: %w", err)
+		}
+		// Recipes share the pantry with each other, so reserved keeps growing
+		_, shortfalls := allocateStock(scaleIngredients(*recipe, 1), groceries, reserved, time.Now(), profile.Staples)
+		addShortfalls(shortfalls, models.ShoppingSourceRecipe)
+		if list.Name == "" && len(input.RecipeIDs) == 1 {
+			list.Name = recipe.Title
+		}
+	}
+
+	if input.IncludeParLevels {
+		levels, err := s.repo.FindParLevels(householdID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get par levels: %w", err)
+		}
+		for _, level := range levels {
+			if missing := belowParLevel(level, groceries, reserved); missing > 0 {
+				list.Items, _ = mergeShoppingItem(list.Items, level.Name, missing, level.Unit, models.ShoppingSourceParLevel)
+			}
+		}
+	}
+
+	list.Name = listName(input.Name, list.Name)
+	if list.Name == "" {
+		list.Name = "Shopping list " + time.Now().Format(dateLayout)
+	}
+	return s.saveList(list)
+}
+
+// DeleteList deletes one of the household's shopping lists
+func (s *shoppingListService) DeleteList(householdID uint, id uint) error {
+	if _, err := s.findList(householdID, id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete shopping list: %w", err)
+	}
+	return nil
+}
+
+// AddItem adds an item to a list. An unchecked item for the same product gets
+// the quantity added instead when the units can be converted.
+func (s *shoppingListService) AddItem(householdID uint, listID uint, input ShoppingItemInput) (*models.ShoppingList, error) {
+	if err := validateShoppingItem(input.Name, input.Quantity); err != nil {
+		return nil, err
+	}
+	list, err := s.findList(householdID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, i := mergeShoppingItem(list.Items, strings.TrimSpace(input.Name), input.Quantity, strings.TrimSpace(input.Unit), models.ShoppingSourceManual)
+	item := &items[i]
+	item.ShoppingListID = list.ID
+	item.Quantity, item.Unit = shoppingQuantity(item.Quantity, item.Unit)
+	if err := s.repo.SaveItem(item); err != nil {
+		return nil, fmt.Errorf("failed to save shopping list item: %w", err)
+	}
+	return s.GetList(householdID, listID)
+}
+
+// UpdateItem edits an item or checks it off. Checked items can be added to the
+// pantry once, with the storage location and expiry date estimated unless given.
+func (s *shoppingListService) UpdateItem(userID uint, householdID uint, listID uint, itemID uint, changes ShoppingItemChanges) (*models.ShoppingList, error) {
+	list, err := s.findList(householdID, listID)
+	if err != nil {
+		return nil, err
+	}
+	item := findShoppingItem(list, itemID)
+	if item == nil {
+		return nil, models.ErrShoppingListItemNotFound
+	}
+
+	if changes.Name != nil {
+		item.Name = strings.TrimSpace(*changes.Name)
+	}
+	if changes.Quantity != nil {
+		item.Quantity = *changes.Quantity
+	}
+	if changes.Unit != nil {
+		item.Unit = strings.TrimSpace(*changes.Unit)
+	}
+	if err := validateShoppingItem(item.Name, item.Quantity); err != nil {
+		return nil, err
+	}
+	if changes.StorageLocation != "" && !validStorageLocation(changes.StorageLocation) {
+		return nil, models.ErrInvalidStorageLocation
+	}
+
+	if changes.Checked != nil && *changes.Checked != item.Checked {
+		item.Checked = *changes.Checked
+		item.CheckedAt = nil
+		if item.Checked {
+			now := time.Now()
+			item.CheckedAt = &now
+		}
+	}
+
+	if item.Checked && changes.AddToPantry && item.GroceryItemID == nil {
+		grocery, err := s.addToPantry(userID, householdID, *item, changes)
+		if err != nil {
+			return nil, err
+		}
+		item.GroceryItemID = &grocery.ID
+	}
+
+	if err := s.repo.SaveItem(item); err != nil {
+		return nil, fmt.Errorf("failed to save shopping list item: %w", err)
+	}
+	return s.GetList(householdID, listID)
+}
+
+// DeleteItem removes an item from one of the household's lists
+func (s *shoppingListService) DeleteItem(householdID uint, listID uint, itemID uint) (*models.ShoppingList, error) {
+	list, err := s.findList(householdID, listID)
+	if err != nil {
+		return nil, err
+	}
+	if findShoppingItem(list, itemID) == nil {
+		return nil, models.ErrShoppingListItemNotFound
+	}
+	if err := s.repo.DeleteItem(itemID); err != nil {
+		return nil, fmt.Errorf("failed to delete shopping list item: %w", err)
+	}
+	return s.GetList(householdID, listID)
+}
+
+// GetParLevels returns the household's par levels
+func (s *shoppingListService) GetParLevels(householdID uint) ([]models.ParLevel, error) {
+	levels, err := s.repo.FindParLevels(householdID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get par levels: %w", err)
+	}
+	return levels, nil
+}
+
+// SetParLevel validates and stores a par level, replacing the one for the same product
+func (s *shoppingListService) SetParLevel(level *models.ParLevel) error {
+	level.Name = strings.TrimSpace(level.Name)
+	level.Unit = strings.TrimSpace(level.Unit)
+	level.StorageLocation = strings.TrimSpace(level.StorageLocation)
+	if level.Name == "" || level.Quantity <= 0 || level.ShelfLifeDays < 0 {
+		return models.ErrInvalidParLevel
+	}
+	if level.StorageLocation != "" && !validStorageLocation(level.StorageLocation) {
+		return models.ErrInvalidStorageLocation
+	}
+
+	existing, err := s.repo.FindParLevelByName(level.HouseholdID, level.Name)
+	if err != nil && !errors.Is(err, repositories.ErrRecordNotFound) {
+		return fmt.Errorf("failed to get par level: %w", err)
+	}
+	if existing != nil {
+		level.ID = existing.ID
+		level.CreatedAt = existing.CreatedAt
+	}
+
+	if err := s.repo.SaveParLevel(level); err != nil {
+		return fmt.Errorf("failed to save par level: %w", err)
+	}
+	return nil
+}
+
+// DeleteParLevel deletes one of the household's par levels
+func (s *shoppingListService) DeleteParLevel(householdID uint, id uint) error {
+	if err := s.repo.DeleteParLevel(householdID, id); err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return models.ErrParLevelNotFound
+		}
+		return fmt.Errorf("failed to delete par level: %w", err)
+	}
+	return nil
+}
+
+func (s *shoppingListService) findList(householdID uint, id uint) (*models.ShoppingList, error) {
+	list, err := s.repo.FindByID(householdID, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return nil, models.ErrShoppingListNotFound
+		}
+		return nil, fmt.Errorf("failed to get shopping list: %w", err)
+	}
+	return list, nil
+}
+
+// annotateLists warns about unchecked items the pantry already holds enough of
+func (s *shoppingListService) annotateLists(householdID uint, lists []models.ShoppingList) error {
+	groceries, err := s.groceryRepo.FindAll(householdID)
+	if err != nil {
+		return fmt.Errorf("failed to get household groceries: %w", err)
+	}
+	reserved, err := s.mealPlanRepo.ReservedQuantities(householdID, 0)
+	if err != nil {
+		return fmt.Errorf("failed to get reserved stock: %w", err)
+	}
+
+	for i := range lists {
+		for j := range lists[i].Items {
+			item := &lists[i].Items[j]
+			if !item.Checked {
+				item.Warning = overPurchaseWarning(PurchaseInput{Name: item.Name, Quantity: item.Quantity, Unit: item.Unit}, groceries, reserved)
+			}
+		}
+	}
+	return nil
+}
+
+// saveList rounds the list's quantities to what can be bought and stores it
+func (s *shoppingListService) saveList(list *models.ShoppingList) (*models.ShoppingList, error) {
+	sort.SliceStable(list.Items, func(i, j int) bool {
+		return strings.ToLower(list.Items[i].Name) < strings.ToLower(list.Items[j].Name)
+	})
+	for i := range list.Items {
+		list.Items[i].Quantity, list.Items[i].Unit = shoppingQuantity(list.Items[i].Quantity, list.Items[i].Unit)
+	}
+
+	if err := s.repo.Save(list); err != nil {
+		return nil, fmt.Errorf("failed to save shopping list: %w", err)
+	}
+	return s.GetList(list.HouseholdID, list.ID)
+}
+
+// addToPantry creates the grocery item for a bought shopping list item
+func (s *shoppingListService) addToPantry(userID uint, householdID uint, item models.ShoppingListItem, changes ShoppingItemChanges) (*models.GroceryItem, error) {
+	history, err := s.groceryRepo.FindAll(householdID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get household groceries: %w", err)
+	}
+
+	location, days := changes.StorageLocation, 0
+	level, err := s.repo.FindParLevelByName(householdID, item.Name)
+	if err != nil && !errors.Is(err, repositories.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get par level: %w", err)
+	}
+	if level != nil {
+		if location == "" {
+			location = level.StorageLocation
+		}
+		days = level.ShelfLifeDays
+	}
+
+	// Record the purchase before the new stock counts towards the pantry
+	reserved, err := s.mealPlanRepo.ReservedQuantities(householdID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reserved stock: %w", err)
+	}
+	_, records := checkPurchases(userID, householdID, models.PurchaseSourceShoppingList, []PurchaseInput{{Name: item.Name, Quantity: item.Quantity, Unit: item.Unit}}, history, reserved)
+	if err := s.purchaseRepo.CreateRecords(records); err != nil {
+		return nil, fmt.Errorf("failed to record purchase: %w", err)
+	}
+
+	storage, expiry := estimateShelfLife(item.Name, location, days, history)
+	if changes.ExpiryDate != nil {
+		expiry = *changes.ExpiryDate
+	}
+
+	quantity := item.Quantity
+	if quantity <= 0 {
+		quantity = 1
+	}
+	grocery := &models.GroceryItem{
+		UserID:          userID,
+		HouseholdID:     householdID,
+		Name:            item.Name,
+		Quantity:        quantity,
+		Unit:            item.Unit,
+		ExpiryDate:      expiry,
+		StorageLocation: string(storage),
+	}
+	if err := s.groceryRepo.Create(grocery); err != nil {
+		return nil, fmt.Errorf("failed to add item to pantry: %w", err)
+	}
+	return grocery, nil
+}
+
+// belowParLevel returns how much of a product is needed to get back to its par
+// level. Stock reserved for meals doesn't count, and stock in units that can't
+// be compared counts as enough.
+func belowParLevel(level models.ParLevel, groceries []models.GroceryItem, reserved map[uint]float64) float64 {
+	var stock float64
+	for _, item := range rankByExpiry(groceries) {
+		if !usesIngredient(item.Name, level.Name) && !usesIngredient(level.Name, item.Name) {
+			continue
+		}
+		available, ok := convertQuantity(item.Quantity-reserved[item.ID], item.Unit, level.Unit)
+		if !ok {
+			return 0
+		}
+		if available > 0 {
+			stock += available
+		}
+	}
+	return level.Quantity - stock
+}
+
+// mergeShoppingItem adds a need to the items, summing it into an unchecked item
+// for the same product when the units can be converted. It returns the items
+// and the index of the one that took the need.
+func mergeShoppingItem(items []models.ShoppingListItem, name string, quantity float64, unit, source string) ([]models.ShoppingListItem, int) {
+	for i := range items {
+		item := &items[i]
+		if item.Checked || !sameProduct(item.Name, name) {
+			continue
+		}
+
+		switch {
+		case quantity <= 0:
+		case item.Quantity <= 0:
+			item.Quantity, item.Unit = quantity, unit
+		default:
+			converted, ok := convertQuantity(quantity, unit, item.Unit)
+			if !ok {
+				continue
+			}
+			item.Quantity += converted
+		}
+		if !containsString(item.Sources, source) {
+			item.Sources = append(item.Sources, source)
+		}
+		return items, i
+	}
+
+	items = append(items, models.ShoppingListItem{
+		Name:     name,
+		Quantity: quantity,
+		Unit:     unit,
+		Sources:  []string{source},
+	})
+	return items, len(items) - 1
+}
+
+// shoppingQuantity picks a sensible unit for a quantity to buy. Counted items
+// are rounded up, since nobody buys half an egg.
+func shoppingQuantity(quantity float64, unit string) (float64, string) {
+	if quantity <= 0 {
+		return 0, unit
+	}
+	if _, ok := lookupUnit(unit); !ok {
+		return math.Ceil(quantity - 1e-9), unit
+	}
+	return humanizeQuantity(quantity, unit)
+}
+
+// sameProduct compares product names, ignoring case and plurals
+func sameProduct(a, b string) bool {
+	a, b = strings.ToLower(strings.TrimSpace(a)), strings.ToLower(strings.TrimSpace(b))
+	return a == b || a+"s" == b || b+"s" == a || a+"es" == b || b+"es" == a
+}
+
+func findShoppingItem(list *models.ShoppingList, itemID uint) *models.ShoppingListItem {
+	for i := range list.Items {
+		if list.Items[i].ID == itemID {
+			return &list.Items[i]
+		}
+	}
+	return nil
+}
+
+func validateShoppingItem(name string, quantity float64) error {
+	if strings.TrimSpace(name) == "" || quantity < 0 {
+		return models.ErrInvalidShoppingItem
+	}
+	return nil
+}
+
+func validStorageLocation(location string) bool {
+	switch models.StorageLocation(location) {
+	case models.DeepFreeze, models.Refrigerator, models.DryPantry:
+		return true
+	default:
+		return false
+	}
+}
+
+// entryScale is how much a planned meal scales its recipe
+func entryScale(entry models.MealPlanEntry) float64 {
+	if entry.Recipe != nil && entry.Recipe.Servings > 0 && entry.Servings > 0 {
+		return float64(entry.Servings) / float64(entry.Recipe.Servings)
+	}
+	return 1
+}
+
+func listName(name, fallback string) string {
+	if name = strings.TrimSpace(name); name != "" {
+		return name
+	}
+	return fallback
+}
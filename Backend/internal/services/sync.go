@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"zero-waste-kitchen/internal/events"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/repositories"
+)
+
+const (
+	// householdEventRetention is how long clients can be away and still catch up
+	householdEventRetention = 24 * time.Hour
+
+	// maxSyncBatch is how many missed events are read at a time
+	maxSyncBatch = 500
+)
+
+// syncEvents are the event types live sync clients get: changes to what the
+// household's apps show
+var syncEvents = []string{
+	events.ItemCreated,
+	events.ItemUpdated,
+	events.ItemDeleted,
+	events.ItemConsumed,
+	events.InventoryChanged,
+	events.ReceiptProcessed,
+	events.ShoppingListCreated,
+	events.ShoppingListUpdated,
+	events.ShoppingListDeleted,
+}
+
+type SyncService interface {
+	Publish(event events.Event)
+	Subscribe(householdID uint) (wake <-chan struct{}, unsubscribe func())
+	Resume(householdID uint, lastSeq uint64, resuming bool) (cursor uint64, reset bool, err error)
+	EventsAfter(householdID uint, afterSeq uint64) ([]models.HouseholdEvent, error)
+	PruneEvents(ctx context.Context, now time.Time) error
+}
+
+type syncService struct {
+	repo repositories.HouseholdEventRepository
+
+	mu          sync.Mutex
+	subscribers map[uint]map[chan struct{}]bool // By household
+}
+
+func NewSyncService(repo repositories.HouseholdEventRepository) SyncService {
+	return &syncService{repo: repo, subscribers: map[uint]map[chan struct{}]bool{}}
+}
+
+// Publish keeps a household change for live sync clients and wakes the ones
+// connected to this instance. Clients on other instances see it when they next poll.
+func (s *syncService) Publish(event events.Event) {
+	if !containsString(syncEvents, event.Type) {
+		return
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to encode %s event: %v", event.Type, err)
+		return
+	}
+	householdEvent := &models.HouseholdEvent{HouseholdID: event.HouseholdID, Type: event.Type, Payload: string(payload)}
+	if err := s.repo.Create(householdEvent); err != nil {
+		log.Printf("Failed to save %s event: %v", event.Type, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for wake := range s.subscribers[event.HouseholdID] {
+		select {
+		case wake <- struct{}{}:
+		default: // Already woken, it will read this event too
+		}
+	}
+}
+
+// Subscribe returns a channel that receives a value whenever the household has
+// new events, until unsubscribe is called
+func (s *syncService) Subscribe(householdID uint) (<-chan struct{}, func()) {
+	wake := make(chan struct{}, 1)
+
+	s.mu.Lock()
+	if s.subscribers[householdID] == nil {
+		s.subscribers[householdID] = map[chan struct{}]bool{}
+	}
+	s.subscribers[householdID][wake] = true
+	s.mu.Unlock()
+
+	return wake, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subscribers[householdID], wake)
+		if len(s.subscribers[householdID]) == 0 {
+			delete(s.subscribers, householdID)
+		}
+	}
+}
+
+// Resume decides where a client's stream starts, as a Seq of the household's
+// events. A new client starts after the newest event. A resuming client continues
+// after its last event, 0 for before the first, unless that event is no longer
+// kept; then it has missed too much and reset tells it to reload everything.
+func (s *syncService) Resume(householdID uint, lastSeq uint64, resuming bool) (uint64, bool, error) {
+	latest, err := s.repo.LatestSeq(householdID)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get latest household event: %w", err)
+	}
+	if !resuming {
+		return latest, false, nil
+	}
+	if lastSeq == 0 {
+		return 0, false, nil
+	}
+
+	kept, err := s.repo.Exists(householdID, lastSeq)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get household event: %w", err)
+	}
+	if !kept {
+		return latest, true, nil
+	}
+	return lastSeq, false, nil
+}
+
+// EventsAfter returns the next batch of the household's events after afterSeq, oldest first
+func (s *syncService) EventsAfter(householdID uint, afterSeq uint64) ([]models.HouseholdEvent, error) {
+	householdEvents, err := s.repo.FindAfter(householdID, afterSeq, maxSyncBatch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get household events: %w", err)
+	}
+	return householdEvents, nil
+}
+
+// PruneEvents removes events older than a day. It runs as a job.
+func (s *syncService) PruneEvents(ctx context.Context, now time.Time) error {
+	count, err := s.repo.DeleteBefore(now.Add(-householdEventRetention))
+	if err != nil {
+		return fmt.Errorf("failed to prune household events: %w", err)
+	}
+	if count > 0 {
+		log.Printf("Pruned %d old household events", count)
+	}
+	return nil
+}
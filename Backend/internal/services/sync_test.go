@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+	"zero-waste-kitchen/internal/events"
+	"zero-waste-kitchen/internal/models"
+)
+
+// fakeHouseholdEventRepo keeps events in memory, numbering them per household
+// like the database does
+type fakeHouseholdEventRepo struct {
+	events []models.HouseholdEvent
+	seqs   map[uint]uint64 // Outlive pruned events
+}
+
+func (r *fakeHouseholdEventRepo) Create(event *models.HouseholdEvent) error {
+	if r.seqs == nil {
+		r.seqs = map[uint]uint64{}
+	}
+	r.seqs[event.HouseholdID]++
+	event.Seq = r.seqs[event.HouseholdID]
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *fakeHouseholdEventRepo) FindAfter(householdID uint, afterSeq uint64, limit int) ([]models.HouseholdEvent, error) {
+	var found []models.HouseholdEvent
+	for _, event := range r.events {
+		if event.HouseholdID == householdID && event.Seq > afterSeq && len(found) < limit {
+			found = append(found, event)
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeHouseholdEventRepo) Exists(householdID uint, seq uint64) (bool, error) {
+	for _, event := range r.events {
+		if event.HouseholdID == householdID && event.Seq == seq {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeHouseholdEventRepo) LatestSeq(householdID uint) (uint64, error) {
+	return r.seqs[householdID], nil
+}
+
+func (r *fakeHouseholdEventRepo) DeleteBefore(before time.Time) (int64, error) {
+	kept := r.events[:0]
+	for _, event := range r.events {
+		if !event.CreatedAt.Before(before) {
+			kept = append(kept, event)
+		}
+	}
+	count := int64(len(r.events) - len(kept))
+	r.events = kept
+	return count, nil
+}
+
+func TestSyncPublish(t *testing.T) {
+	repo := &fakeHouseholdEventRepo{}
+	service := NewSyncService(repo)
+	wake, unsubscribe := service.Subscribe(1)
+	defer unsubscribe()
+	otherWake, otherUnsubscribe := service.Subscribe(2)
+	defer otherUnsubscribe()
+
+	service.Publish(events.New(events.ItemCreated, 1, 7, nil))
+	service.Publish(events.New(events.RecipeGenerated, 1, 7, nil)) // Not synced
+	service.Publish(events.New(events.ItemUpdated, 1, 7, nil))
+	service.Publish(events.New(events.ShoppingListCreated, 3, 8, nil))
+
+	householdEvents, err := service.EventsAfter(1, 0)
+	if err != nil {
+		t.Fatalf("EventsAfter failed: %v", err)
+	}
+	if len(householdEvents) != 2 {
+		t.Fatalf("got %d events, want 2", len(householdEvents))
+	}
+	for i, want := range []string{events.ItemCreated, events.ItemUpdated} {
+		if householdEvents[i].Type != want || householdEvents[i].Seq != uint64(i+1) {
+			t.Errorf("event %d = %s with seq %d, want %s with seq %d", i, householdEvents[i].Type, householdEvents[i].Seq, want, i+1)
+		}
+	}
+	if seq, _ := repo.LatestSeq(3); seq != 1 {
+		t.Errorf("household 3 latest seq = %d, want 1: households are numbered separately", seq)
+	}
+
+	// Two events woke household 1 once; household 2 had none
+	select {
+	case <-wake:
+	default:
+		t.Error("subscriber wasn't woken")
+	}
+	select {
+	case <-wake:
+		t.Error("subscriber was woken twice")
+	case <-otherWake:
+		t.Error("another household's subscriber was woken")
+	default:
+	}
+}
+
+func TestSyncResume(t *testing.T) {
+	repo := &fakeHouseholdEventRepo{}
+	service := NewSyncService(repo)
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		// Seq 1 and 2 are two days old and get pruned
+		age := time.Hour
+		if i < 2 {
+			age = 48 * time.Hour
+		}
+		repo.Create(&models.HouseholdEvent{HouseholdID: 1, Type: events.ItemCreated, CreatedAt: now.Add(-age)})
+	}
+	if err := service.PruneEvents(context.Background(), now); err != nil {
+		t.Fatalf("PruneEvents failed: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		householdID uint
+		lastSeq     uint64
+		resuming    bool
+		wantCursor  uint64
+		wantReset   bool
+		wantEvents  int
+	}{
+		{name: "new client starts after the newest event", householdID: 1, wantCursor: 5},
+		{name: "resumes after its last event", householdID: 1, lastSeq: 3, resuming: true, wantCursor: 3, wantEvents: 2},
+		{name: "up to date", householdID: 1, lastSeq: 5, resuming: true, wantCursor: 5},
+		{name: "resumes from before the first event", householdID: 1, resuming: true, wantEvents: 3},
+		{name: "last event was pruned", householdID: 1, lastSeq: 2, resuming: true, wantCursor: 5, wantReset: true},
+		{name: "last event never existed", householdID: 1, lastSeq: 9, resuming: true, wantCursor: 5, wantReset: true},
+		{name: "household without events", householdID: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, reset, err := service.Resume(tt.householdID, tt.lastSeq, tt.resuming)
+			if err != nil {
+				t.Fatalf("Resume failed: %v", err)
+			}
+			if cursor != tt.wantCursor || reset != tt.wantReset {
+				t.Errorf("Resume = %d, %v, want %d, %v", cursor, reset, tt.wantCursor, tt.wantReset)
+			}
+
+			householdEvents, err := service.EventsAfter(tt.householdID, cursor)
+			if err != nil {
+				t.Fatalf("EventsAfter failed: %v", err)
+			}
+			if len(householdEvents) != tt.wantEvents {
+				t.Errorf("got %d events after the cursor, want %d", len(householdEvents), tt.wantEvents)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Dimensions of measurable units. Units of different dimensions can't be converted.
+const (
+	dimensionMass   = "mass"   // Base unit: grams
+	dimensionVolume = "volume" // Base unit: milliliters
+)
+
+// unitInfo describes a unit of measure in terms of its dimension's base unit
+type unitInfo struct {
+	symbol    string  // Display form
+	dimension string  // Empty for count units such as cloves or cans
+	toBase    float64 // Base units per one of this unit
+	metric    bool
+}
+
+var (
+	unitGram       = unitInfo{"g", dimensionMass, 1, true}
+	unitKilogram   = unitInfo{"kg", dimensionMass, 1000, true}
+	unitMilligram  = unitInfo{"mg", dimensionMass, 0.001, true}
+	unitOunce      = unitInfo{"oz", dimensionMass, 28.3495, false}
+	unitPound      = unitInfo{"lb", dimensionMass, 453.592, false}
+	unitMilliliter = unitInfo{"ml", dimensionVolume, 1, true}
+	unitLiter      = unitInfo{"L", dimensionVolume, 1000, true}
+	unitTeaspoon   = unitInfo{"tsp", dimensionVolume, 4.92892, false}
+	unitTablespoon = unitInfo{"tbsp", dimensionVolume, 14.7868, false}
+	unitCup        = unitInfo{"cup", dimensionVolume, 236.588, false}
+)
+
+// units maps the spellings found in recipes and pantry entries to their unit
+var units = map[string]unitInfo{
+	"g": unitGram, "gram": unitGram, "grams": unitGram, "gr": unitGram,
+	"kg": unitKilogram, "kilogram": unitKilogram, "kilograms": unitKilogram, "kgs": unitKilogram,
+	"mg": unitMilligram, "milligram": unitMilligram, "milligrams": unitMilligram,
+	"oz": unitOunce, "ounce": unitOunce, "ounces": unitOunce,
+	"lb": unitPound, "lbs": unitPound, "pound": unitPound, "pounds": unitPound,
+	"ml": unitMilliliter, "milliliter": unitMilliliter, "milliliters": unitMilliliter, "millilitre": unitMilliliter, "millilitres": unitMilliliter,
+	"l": unitLiter, "liter": unitLiter, "liters": unitLiter, "litre": unitLiter, "litres": unitLiter,
+	"tsp": unitTeaspoon, "teaspoon": unitTeaspoon, "teaspoons": unitTeaspoon,
+	"tbsp": unitTablespoon, "tablespoon": unitTablespoon, "tablespoons": unitTablespoon,
+	"cup": unitCup, "cups": unitCup,
+}
+
+// countUnits are units that are scaled but never converted
+var countUnits = map[string]bool{
+	"clove": true, "cloves": true, "can": true, "cans": true, "slice": true, "slices": true,
+	"piece": true, "pieces": true, "pcs": true, "pc": true, "stick": true, "sticks": true,
+	"bunch": true, "handful": true, "pinch": true, "dash": true, "pack": true, "packs": true,
+}
+
+// lookupUnit returns the unit for a spelling, ignoring case and a trailing dot
+func lookupUnit(unit string) (unitInfo, bool) {
+	info, ok := units[strings.TrimSuffix(strings.ToLower(strings.TrimSpace(unit)), ".")]
+	return info, ok
+}
+
+// toBaseUnit converts a quantity to its dimension's base unit. ok is false for count or unknown units.
+func toBaseUnit(quantity float64, unit string) (base float64, dimension string, ok bool) {
+	info, ok := lookupUnit(unit)
+	if !ok {
+		return 0, "", false
+	}
+	return quantity * info.toBase, info.dimension, true
+}
+
+// humanizeQuantity picks a sensible unit for a quantity, staying in the original
+// measuring system, e.g. 1500 ml becomes 1.5 L and 48 tsp becomes 1 cup
+func humanizeQuantity(quantity float64, unit string) (float64, string) {
+	info, ok := lookupUnit(unit)
+	if !ok {
+		return roundQuantity(quantity, 0.25), unit
+	}
+
+	base := quantity * info.toBase
+	var target unitInfo
+	switch {
+	case info.dimension == dimensionMass && info.metric:
+		target = unitGram
+		if base >= unitKilogram.toBase {
+			target = unitKilogram
+		} else if base < 1 {
+			target = unitMilligram
+		}
+	case info.dimension == dimensionMass:
+		target = unitOunce
+		if base >= unitPound.toBase {
+			target = unitPound
+		}
+	case info.dimension == dimensionVolume && info.metric:
+		target = unitMilliliter
+		if base >= unitLiter.toBase {
+			target = unitLiter
+		}
+	default:
+		target = unitTeaspoon
+		if base >= unitCup.toBase/4 {
+			target = unitCup
+		} else if base >= unitTablespoon.toBase {
+			target = unitTablespoon
+		}
+	}
+
+	converted := base / target.toBase
+	switch {
+	case !target.metric:
+		// Kitchen measures come in quarters, teaspoons in eighths
+		step := 0.25
+		if target == unitTeaspoon {
+			step = 0.125
+		}
+		converted = roundQuantity(converted, step)
+	case converted >= 100:
+		converted = math.Round(converted)
+	case converted >= 10:
+		converted = roundQuantity(converted, 0.5)
+	default:
+		converted = roundQuantity(converted, 0.01)
+	}
+	return converted, target.symbol
+}
+
+// roundQuantity rounds to the nearest step, but never down to zero
+func roundQuantity(quantity, step float64) float64 {
+	rounded := math.Round(quantity/step) * step
+	if rounded == 0 && quantity > 0 {
+		rounded = step
+	}
+	return math.Round(rounded*1000) / 1000
+}
+
+// formatQuantity renders a quantity without trailing zeros
+func formatQuantity(quantity float64) string {
+	return strconv.FormatFloat(quantity, 'f', -1, 64)
+}
+
+// unicodeFractions are the vulgar fractions models like to write
+var unicodeFractions = map[rune]float64{
+	'½': 0.5, '⅓': 1.0 / 3, '⅔': 2.0 / 3, '¼': 0.25, '¾': 0.75, '⅛': 0.125, '⅜': 0.375, '⅝': 0.625, '⅞': 0.875,
+}
+
+// parsedIngredient is an ingredient line split into its parts
+type parsedIngredient struct {
+	Quantity float64 // 0 when the line has no quantity, e.g. "salt to taste"
+	Unit     string
+	Name     string
+	Rest     string // The line after the quantity and unit, in its original case
+	Optional bool
+}
+
+// parseIngredientLine splits a free-text ingredient line such as "1 1/2 cups rice, rinsed"
+// or "200g chicken" into quantity, unit and name
+func parseIngredientLine(line string) parsedIngredient {
+	trimmed := strings.TrimLeft(strings.TrimSpace(line), "-*• ")
+	parsed := parsedIngredient{
+		Name:     ingredientName(trimmed),
+		Optional: strings.Contains(strings.ToLower(trimmed), "optional"),
+	}
+
+	words := strings.Fields(trimmed)
+	consumed := 0
+	for consumed < len(words) {
+		word := strings.ToLower(words[consumed])
+		if quantity, ok := parseQuantity(word); ok {
+			parsed.Quantity += quantity
+			consumed++
+			continue
+		}
+		// A number glued to its unit, e.g. "200g"
+		if i := strings.IndexFunc(word, unicode.IsLetter); i > 0 && parsed.Unit == "" {
+			if quantity, ok := parseQuantity(word[:i]); ok && isUnit(word[i:]) {
+				parsed.Quantity += quantity
+				parsed.Unit = word[i:]
+				consumed++
+			}
+		}
+		break
+	}
+	if parsed.Quantity > 0 && parsed.Unit == "" && consumed < len(words) && isUnit(strings.ToLower(words[consumed])) {
+		parsed.Unit = strings.ToLower(words[consumed])
+		consumed++
+	}
+	parsed.Rest = strings.Join(words[consumed:], " ")
+	return parsed
+}
+
+// parseQuantity parses "2", "1.5", "1/2", "½", "1½" or the first number of a range like "2-3"
+func parseQuantity(word string) (float64, bool) {
+	if i := strings.Index(word, "-"); i > 0 {
+		word = word[:i]
+	}
+	if value, err := strconv.ParseFloat(word, 64); err == nil && value >= 0 {
+		return value, true
+	}
+	if numerator, denominator, found := strings.Cut(word, "/"); found {
+		n, errN := strconv.ParseFloat(numerator, 64)
+		d, errD := strconv.ParseFloat(denominator, 64)
+		if errN == nil && errD == nil && d > 0 {
+			return n / d, true
+		}
+		return 0, false
+	}
+
+	runes := []rune(word)
+	if len(runes) == 0 {
+		return 0, false
+	}
+	fraction, ok := unicodeFractions[runes[len(runes)-1]]
+	if !ok {
+		return 0, false
+	}
+	whole := 0.0
+	if len(runes) > 1 {
+		value, err := strconv.ParseFloat(string(runes[:len(runes)-1]), 64)
+		if err != nil {
+			return 0, false
+		}
+		whole = value
+	}
+	return whole + fraction, true
+}
+
+func isUnit(word string) bool {
+	if _, ok := lookupUnit(word); ok {
+		return true
+	}
+	return countUnits[word]
+}
+
+// convertQuantity converts a quantity between units of the same dimension, or
+// between matching count units. ok is false when the units can't be compared.
+func convertQuantity(quantity float64, from, to string) (float64, bool) {
+	fromInfo, fromMeasured := lookupUnit(from)
+	toInfo, toMeasured := lookupUnit(to)
+	switch {
+	case fromMeasured && toMeasured && fromInfo.dimension == toInfo.dimension:
+		return quantity * fromInfo.toBase / toInfo.toBase, true
+	case !fromMeasured && !toMeasured && sameCountUnit(from, to):
+		return quantity, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseIngredientLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		quantity float64
+		unit     string
+		name     string
+		rest     string
+		optional bool
+	}{
+		{"1 1/2 cups rice, rinsed", 1.5, "cups", "rice", "rice, rinsed", false},
+		{"200g chicken breast", 200, "g", "chicken breast", "chicken breast", false},
+		{"½ tsp salt", 0.5, "tsp", "salt", "salt", false},
+		{"1½ cups Milk", 1.5, "cups", "milk", "Milk", false},
+		{"2-3 cloves garlic", 2, "cloves", "garlic", "garlic", false},
+		{"- 3 eggs (optional)", 3, "", "eggs", "eggs (optional)", true},
+		{"1 L stock", 1, "l", "stock", "stock", false},
+		{"salt to taste", 0, "", "salt to taste", "salt to taste", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got := parseIngredientLine(tt.line)
+			if math.Abs(got.Quantity-tt.quantity) > 1e-9 || got.Unit != tt.unit || got.Name != tt.name || got.Rest != tt.rest || got.Optional != tt.optional {
+				t.Errorf("parseIngredientLine(%q) = %+v, want quantity %v, unit %q, name %q, rest %q, optional %v",
+					tt.line, got, tt.quantity, tt.unit, tt.name, tt.rest, tt.optional)
+			}
+		})
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		word string
+		want float64
+		ok   bool
+	}{
+		{"2", 2, true},
+		{"1.5", 1.5, true},
+		{"3/4", 0.75, true},
+		{"¼", 0.25, true},
+		{"2½", 2.5, true},
+		{"2-3", 2, true},
+		{"1/0", 0, false},
+		{"-1", 0, false},
+		{"a½", 0, false},
+		{"cup", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			got, ok := parseQuantity(tt.word)
+			if ok != tt.ok || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("parseQuantity(%q) = %v, %v, want %v, %v", tt.word, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestConvertQuantity(t *testing.T) {
+	tests := []struct {
+		quantity float64
+		from, to string
+		want     float64
+		ok       bool
+	}{
+		{1, "kg", "g", 1000, true},
+		{2, "cups", "ml", 473.176, true},
+		{1, "lb", "oz", 16, true},
+		{3, "tsp", "Tbsp.", 1, true},
+		{2, "cans", "can", 2, true},
+		{4, "", "pcs", 4, true},
+		{100, "g", "ml", 0, false},
+		{1, "can", "", 0, false},
+		{1, "cup", "clove", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			got, ok := convertQuantity(tt.quantity, tt.from, tt.to)
+			if ok != tt.ok || math.Abs(got-tt.want) > 0.001 {
+				t.Errorf("convertQuantity(%v, %q, %q) = %v, %v, want %v, %v", tt.quantity, tt.from, tt.to, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestHumanizeQuantity(t *testing.T) {
+	tests := []struct {
+		quantity float64
+		unit     string
+		want     float64
+		wantUnit string
+	}{
+		{1500, "ml", 1.5, "L"},
+		{2000, "g", 2, "kg"},
+		{0.5, "g", 500, "mg"},
+		{20, "oz", 1.25, "lb"},
+		{48, "tsp", 1, "cup"},
+		{6, "tsp", 2, "tbsp"},
+		{0.3, "tsp", 0.25, "tsp"},
+		{123.4, "g", 123, "g"},
+		{12.3, "ml", 12.5, "ml"},
+		{0.001, "cup", 0.125, "tsp"}, // Never rounded down to nothing
+		{2.6, "cans", 2.5, "cans"},
+	}
+
+	for _, tt := range tests {
+		t.Run(formatQuantity(tt.quantity)+" "+tt.unit, func(t *testing.T) {
+			got, unit := humanizeQuantity(tt.quantity, tt.unit)
+			if got != tt.want || unit != tt.wantUnit {
+				t.Errorf("humanizeQuantity(%v, %q) = %v %s, want %v %s", tt.quantity, tt.unit, got, unit, tt.want, tt.wantUnit)
+			}
+		})
+	}
+}
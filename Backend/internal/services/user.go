@@ -13,7 +13,6 @@ type UserService interface {
 	Login(email, password string) (*models.User, error)
 	GetUserByID(id uint) (*models.User, error)
 	UpdateUser(user *models.User) error
-	UpdateFCMToken(userID uint, token string) error
 }
 
 type userService struct {
@@ -80,7 +79,3 @@ func (s *userService) UpdateUser(user *models.User) error {
 
 	return s.repo.Update(existing)
 }
-
-func (s *userService) UpdateFCMToken(userID uint, token string) error {
-	return s.repo.UpdateFCMToken(userID, token)
-}
@@ -0,0 +1,477 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"zero-waste-kitchen/internal/events"
+	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/notify"
+	"zero-waste-kitchen/internal/repositories"
+)
+
+const (
+	webhookTimeout           = 10 * time.Second
+	webhookDeliveryLease     = time.Minute // Longer than an attempt can take
+	webhookDeliveriesBatch   = 500         // Due deliveries attempted per run
+	webhookDeliveryRetention = 30 * 24 * time.Hour
+	maxWebhookURLLength      = 500
+	maxWebhookDescription    = 255
+	maxWebhookError          = 1000
+
+	// webhookExpiryWindow is how far ahead item.expiring and how far back
+	// item.expired look. The expiry job runs more often than this, each event
+	// goes out once.
+	webhookExpiryWindow = 24 * time.Hour
+
+	// webhookTestEvent is what the test-fire endpoint sends, whatever the webhook subscribes to
+	webhookTestEvent = "webhook.test"
+)
+
+// webhookRetryDelays is how long to wait after each failed attempt. A delivery
+// that fails once more after the last delay is given up.
+var webhookRetryDelays = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	6 * time.Hour,
+	24 * time.Hour,
+}
+
+var webhookEvents = []string{
+	events.ItemCreated,
+	events.ItemExpiring,
+	events.ItemExpired,
+	events.ItemConsumed,
+	events.ReceiptProcessed,
+	events.RecipeGenerated,
+}
+
+type WebhookInput struct {
+	URL         string
+	Events      []string
+	Description string
+	Enabled     *bool // Defaults to true
+}
+
+type WebhookService interface {
+	CreateWebhook(userID uint, householdID *uint, input WebhookInput) (*models.Webhook, error)
+	GetWebhooks(userID uint) ([]models.Webhook, error)
+	GetWebhook(userID, id uint) (*models.Webhook, error)
+	UpdateWebhook(userID, id uint, input WebhookInput) (*models.Webhook, error)
+	DeleteWebhook(userID, id uint) error
+	GetDeliveries(userID, id uint, limit, offset int) ([]models.WebhookDelivery, int64, error)
+	TestWebhook(ctx context.Context, userID, id uint) (*models.WebhookDelivery, error)
+
+	Publish(event events.Event)
+	DeliverDue(ctx context.Context, now time.Time) error
+	SendExpiryEvents(ctx context.Context, now time.Time) error
+	PruneDeliveries(ctx context.Context, now time.Time) error
+}
+
+type webhookService struct {
+	repo        repositories.WebhookRepository
+	groceryRepo repositories.GroceryRepository
+	httpClient  *http.Client
+}
+
+func NewWebhookService(repo repositories.WebhookRepository, groceryRepo repositories.GroceryRepository) WebhookService {
+	return &webhookService{
+		repo:        repo,
+		groceryRepo: groceryRepo,
+		httpClient:  notify.NewWebhookHTTPClient(webhookTimeout),
+	}
+}
+
+// CreateWebhook registers an endpoint for a household's events, or for every
+// household's when householdID is nil. The returned webhook carries its signing
+// secret, which isn't shown again.
+func (s *webhookService) CreateWebhook(userID uint, householdID *uint, input WebhookInput) (*models.Webhook, error) {
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	webhook := &models.Webhook{UserID: userID, HouseholdID: householdID, Secret: secret, Enabled: true}
+	if err := applyWebhookInput(webhook, input); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(webhook); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+func (s *webhookService) GetWebhooks(userID uint) ([]models.Webhook, error) {
+	webhooks, err := s.repo.FindByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// GetWebhook returns one of the user's webhooks
+func (s *webhookService) GetWebhook(userID, id uint) (*models.Webhook, error) {
+	webhook, err := s.repo.FindByID(id)
+	if errors.Is(err, repositories.ErrRecordNotFound) || (err == nil && webhook.UserID != userID) {
+		return nil, models.ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// UpdateWebhook changes the URL, events, description or enabled state of one of
+// the user's webhooks. Empty fields are left as they are.
+func (s *webhookService) UpdateWebhook(userID, id uint, input WebhookInput) (*models.Webhook, error) {
+	webhook, err := s.GetWebhook(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if input.URL == "" {
+		input.URL = webhook.URL
+	}
+	if input.Events == nil {
+		input.Events = webhook.Events
+	}
+	if input.Description == "" {
+		input.Description = webhook.Description
+	}
+	if err := applyWebhookInput(webhook, input); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Save(webhook); err != nil {
+		return nil, fmt.Errorf("failed to save webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook removes one of the user's webhooks, pending deliveries included
+func (s *webhookService) DeleteWebhook(userID, id uint) error {
+	if err := s.repo.Delete(userID, id); err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return models.ErrWebhookNotFound
+		}
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// GetDeliveries returns a page of a webhook's delivery log, newest first
+func (s *webhookService) GetDeliveries(userID, id uint, limit, offset int) ([]models.WebhookDelivery, int64, error) {
+	if _, err := s.GetWebhook(userID, id); err != nil {
+		return nil, 0, err
+	}
+	deliveries, total, err := s.repo.FindDeliveries(id, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	return deliveries, total, nil
+}
+
+// TestWebhook sends a webhook.test event to one of the user's webhooks right away
+// and returns how it went. A failed test is retried like any other delivery.
+func (s *webhookService) TestWebhook(ctx context.Context, userID, id uint) (*models.WebhookDelivery, error) {
+	webhook, err := s.GetWebhook(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var householdID uint
+	if webhook.HouseholdID != nil {
+		householdID = *webhook.HouseholdID
+	}
+	event := events.New(webhookTestEvent, householdID, userID, map[string]any{"webhook_id": webhook.ID})
+	delivery, err := s.enqueue(webhook, event)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attempt(ctx, delivery.ID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	delivery, err = s.repo.FindDelivery(delivery.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// Publish queues an event for every webhook subscribed to it and makes a first
+// attempt at each in the background. Failed attempts are retried by DeliverDue.
+func (s *webhookService) Publish(event events.Event) {
+	webhooks, err := s.repo.FindSubscribers(event.HouseholdID)
+	if err != nil {
+		log.Printf("Failed to find webhooks for %s: %v", event.Type, err)
+		return
+	}
+
+	for i := range webhooks {
+		if !containsString(webhooks[i].Events, event.Type) {
+			continue
+		}
+		delivery, err := s.enqueue(&webhooks[i], event)
+		if err != nil {
+			log.Printf("Failed to queue %s for webhook %d: %v", event.Type, webhooks[i].ID, err)
+			continue
+		}
+		if delivery == nil {
+			continue // Sent before
+		}
+		go func(id uint) {
+			if err := s.attempt(context.Background(), id, time.Now()); err != nil {
+				log.Printf("Failed to deliver webhook delivery %d: %v", id, err)
+			}
+		}(delivery.ID)
+	}
+}
+
+// DeliverDue attempts the deliveries whose first attempt or next retry is due.
+// The scheduler runs it every minute.
+func (s *webhookService) DeliverDue(ctx context.Context, now time.Time) error {
+	ids, err := s.repo.FindDueDeliveries(now, webhookDeliveriesBatch)
+	if err != nil {
+		return fmt.Errorf("failed to get due webhook deliveries: %w", err)
+	}
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.attempt(ctx, id, now); err != nil {
+			log.Printf("Failed to deliver webhook delivery %d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// SendExpiryEvents publishes item.expiring for items that expire within a day
+// and item.expired for items that expired within the last day. Event IDs are
+// derived from the item and its expiry date, so each goes out once however
+// often the job runs.
+func (s *webhookService) SendExpiryEvents(ctx context.Context, now time.Time) error {
+	for _, window := range []struct {
+		event    string
+		from, to time.Time
+	}{
+		{events.ItemExpiring, now, now.Add(webhookExpiryWindow)},
+		{events.ItemExpired, now.Add(-webhookExpiryWindow), now},
+	} {
+		items, err := s.groceryRepo.FindExpiringBetween(window.from, window.to)
+		if err != nil {
+			return fmt.Errorf("failed to get expiring groceries: %w", err)
+		}
+		for _, item := range items {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			s.Publish(events.Event{
+				ID:          fmt.Sprintf("evt_%s_%d_%d", strings.ReplaceAll(window.event, ".", "_"), item.ID, item.ExpiryDate.Unix()),
+				Type:        window.event,
+				HouseholdID: item.HouseholdID,
+				Data:        map[string]any{"item": item},
+				OccurredAt:  now.UTC(),
+			})
+		}
+	}
+	return nil
+}
+
+// PruneDeliveries removes finished deliveries older than a month. It runs as a job.
+func (s *webhookService) PruneDeliveries(ctx context.Context, now time.Time) error {
+	count, err := s.repo.DeleteDeliveriesBefore(now.Add(-webhookDeliveryRetention))
+	if err != nil {
+		return fmt.Errorf("failed to prune webhook deliveries: %w", err)
+	}
+	if count > 0 {
+		log.Printf("Pruned %d old webhook deliveries", count)
+	}
+	return nil
+}
+
+// enqueue stores an event as a pending delivery to a webhook. It returns nil if
+// the webhook already has the event.
+func (s *webhookService) enqueue(webhook *models.Webhook, event events.Event) (*models.WebhookDelivery, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	now := time.Now()
+	delivery := &models.WebhookDelivery{
+		WebhookID:     webhook.ID,
+		EventID:       event.ID,
+		Event:         event.Type,
+		Payload:       string(payload),
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: &now,
+	}
+	created, err := s.repo.CreateDelivery(delivery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue webhook delivery: %w", err)
+	}
+	if !created {
+		return nil, nil
+	}
+	return delivery, nil
+}
+
+// attempt sends a due delivery once and schedules its retry if that fails.
+// Deliveries someone else is attempting, or that aren't due, are left alone.
+func (s *webhookService) attempt(ctx context.Context, id uint, now time.Time) error {
+	claimed, err := s.repo.ClaimDelivery(id, now, now.Add(webhookDeliveryLease))
+	if err != nil {
+		return fmt.Errorf("failed to claim webhook delivery: %w", err)
+	}
+	if !claimed {
+		return nil
+	}
+	delivery, err := s.repo.FindDelivery(id)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	webhook, err := s.repo.FindByID(delivery.WebhookID)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	delivery.Attempts++
+	var status int
+	if webhook.Enabled {
+		status, err = s.send(ctx, webhook, delivery)
+	}
+	delivery.ResponseStatus = status
+	finished := time.Now()
+	switch {
+	case !webhook.Enabled:
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.Error = "webhook is disabled"
+		delivery.NextAttemptAt = nil
+	case err == nil:
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.Error = ""
+		delivery.DeliveredAt = &finished
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts > len(webhookRetryDelays):
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.Error = truncateWebhookError(err.Error())
+		delivery.NextAttemptAt = nil
+	default:
+		retryAt := finished.Add(webhookRetryDelays[delivery.Attempts-1])
+		delivery.Error = truncateWebhookError(err.Error())
+		delivery.NextAttemptAt = &retryAt
+	}
+
+	if err := s.repo.SaveDelivery(delivery); err != nil {
+		return fmt.Errorf("failed to save webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// send POSTs a delivery's payload, signed with the webhook's secret, and returns
+// the response status
+func (s *webhookService) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("invalid webhook request: %w", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ZeroWasteKitchen-Webhooks/1.0")
+	req.Header.Set("X-Webhook-Id", delivery.EventID)
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Signature", "t="+timestamp+",v1="+signWebhookPayload(webhook.Secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := s.httpClient.Do(req)
+	if errors.Is(err, notify.ErrAddressBlocked) {
+		return 0, notify.ErrAddressBlocked
+	}
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	// The body is never stored, only drained so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// signWebhookPayload is the hex HMAC-SHA256 of "<timestamp>.<payload>". Receivers
+// recompute it with their copy of the secret, and reject old timestamps to stop replays.
+func signWebhookPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// applyWebhookInput validates input and copies it onto webhook
+func applyWebhookInput(webhook *models.Webhook, input WebhookInput) error {
+	rawURL := strings.TrimSpace(input.URL)
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(rawURL) > maxWebhookURLLength {
+		return fmt.Errorf("%w: url must be an http or https URL of at most %d characters", models.ErrInvalidWebhook, maxWebhookURLLength)
+	}
+	if !notify.IsPublicHost(u) {
+		return fmt.Errorf("%w: url must point to a public address", models.ErrInvalidWebhook)
+	}
+
+	subscribed := cleanTerms(input.Events)
+	if len(subscribed) == 0 {
+		return fmt.Errorf("%w: subscribe to at least one of %s", models.ErrInvalidWebhook, strings.Join(webhookEvents, ", "))
+	}
+	for _, event := range subscribed {
+		if !containsString(webhookEvents, event) {
+			return fmt.Errorf("%w: unknown event %s", models.ErrInvalidWebhook, event)
+		}
+	}
+
+	description := strings.TrimSpace(input.Description)
+	if len(description) > maxWebhookDescription {
+		return fmt.Errorf("%w: description must be at most %d characters", models.ErrInvalidWebhook, maxWebhookDescription)
+	}
+
+	webhook.URL = rawURL
+	webhook.Events = subscribed
+	webhook.Description = description
+	if input.Enabled != nil {
+		webhook.Enabled = *input.Enabled
+	}
+	return nil
+}
+
+// truncateWebhookError shortens an error for the delivery log and drops invalid UTF-8
+func truncateWebhookError(message string) string {
+	if len(message) > maxWebhookError {
+		message = message[:maxWebhookError]
+	}
+	return strings.ToValidUTF8(message, "")
+}
+
+// newWebhookSecret returns a random signing secret such as whsec_3f9a...
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
@@ -2,16 +2,22 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Time zones for notification preferences, even without system zoneinfo
 	"zero-waste-kitchen/internal/config"
 	"zero-waste-kitchen/internal/controllers"
+	"zero-waste-kitchen/internal/events"
+	"zero-waste-kitchen/internal/llm"
 	"zero-waste-kitchen/internal/models"
+	"zero-waste-kitchen/internal/notify"
 	"zero-waste-kitchen/internal/repositories"
+	"zero-waste-kitchen/internal/scheduler"
 	"zero-waste-kitchen/internal/services"
 	"zero-waste-kitchen/pkg/database"
 	"zero-waste-kitchen/pkg/middleware"
@@ -31,20 +37,89 @@ func main() {
 	// Assign initialized database instance to db
 	db := database.DB
 
-	// Initialize Firebase
-	if err := services.InitializeFirebase(); err != nil {
-		log.Fatalf("Failed to initialize Firebase: %v", err)
-	}
-
 	// Initialize services
 	groceryRepo := repositories.NewGroceryRepository(db)
+	eventBus := events.NewBus()
+	webhookService := services.NewWebhookService(repositories.NewWebhookRepository(db), groceryRepo)
+	eventBus.Subscribe(webhookService.Publish)
+	syncService := services.NewSyncService(repositories.NewHouseholdEventRepository(db))
+	eventBus.Subscribe(syncService.Publish)
+	webhookController := controllers.NewWebhookController(webhookService)
+	groceryController := controllers.NewGroceryController(eventBus)
 	recipeRepo := repositories.NewRecipeRepository(db)
+	profileRepo := repositories.NewFoodProfileRepository(db)
+	generationRepo := repositories.NewRecipeGenerationRepository(db)
+	llmClient, err := llm.NewClient(llm.Config{
+		Provider:    config.AppConfig.LLMProvider,
+		BaseURL:     config.AppConfig.LLMBaseURL,
+		APIKey:      config.AppConfig.LLMAPIKey,
+		Model:       config.AppConfig.LLMModel,
+		Temperature: config.AppConfig.LLMTemperature,
+		MaxTokens:   config.AppConfig.LLMMaxTokens,
+	})
+	if errors.Is(err, llm.ErrNotConfigured) {
+		log.Println("Warning: No LLM API key set - recipes will come from the local recipe library")
+	} else if err != nil {
+		log.Fatalf("Failed to initialize LLM client: %v", err)
+	}
+	mealPlanRepo := repositories.NewMealPlanRepository(db)
 	recipeService := services.NewRecipeService(
 		groceryRepo,
 		recipeRepo,
-		os.Getenv("GROQ_API_KEY"),
+		profileRepo,
+		generationRepo,
+		mealPlanRepo,
+		llmClient,
+		services.GenerationLimits{
+			DailyQuota: config.AppConfig.RecipeDailyQuota,
+			CacheTTL:   time.Duration(config.AppConfig.RecipeCacheTTLHours) * time.Hour,
+		},
 	)
-	recipeController := controllers.NewRecipeController(recipeService)
+	recipeController := controllers.NewRecipeController(recipeService, eventBus)
+	profileController := controllers.NewFoodProfileController(services.NewFoodProfileService(profileRepo))
+
+	nutritionService := services.NewNutritionService(repositories.NewNutritionRepository(db), groceryRepo, recipeRepo)
+	if imported, err := nutritionService.ImportBundled(config.AppConfig.NutritionCSVPath); err != nil {
+		log.Printf("Warning: Failed to import bundled nutrition data: %v", err)
+	} else if imported > 0 {
+		log.Printf("Imported %d foods into the nutrition database", imported)
+	}
+	nutritionController := controllers.NewNutritionController(nutritionService, eventBus)
+	mealPlanController := controllers.NewMealPlanController(services.NewMealPlanService(
+		mealPlanRepo,
+		groceryRepo,
+		recipeRepo,
+		profileRepo,
+	), eventBus)
+	purchaseRepo := repositories.NewPurchaseRepository(db)
+	shoppingListController := controllers.NewShoppingListController(services.NewShoppingListService(
+		repositories.NewShoppingListRepository(db),
+		mealPlanRepo,
+		groceryRepo,
+		recipeRepo,
+		profileRepo,
+		purchaseRepo,
+	), eventBus)
+	receiptController := controllers.NewReceiptController(services.NewOverPurchaseService(purchaseRepo, groceryRepo, mealPlanRepo), eventBus)
+	householdService := services.NewHouseholdService(repositories.NewHouseholdRepository(db), repositories.NewUserRepository(db))
+	householdController := controllers.NewHouseholdController(householdService)
+	syncController := controllers.NewSyncController(syncService, householdService)
+	deviceRepo := repositories.NewDeviceRepository(db)
+	deviceService := services.NewDeviceService(deviceRepo)
+	deviceController := controllers.NewDeviceController(deviceService)
+	notificationService := services.NewNotificationService(repositories.NewNotificationRepository(db), repositories.NewUserRepository(db), groceryRepo, householdService, deviceRepo, initNotifiers(deviceService.PruneTokens))
+	notificationController := controllers.NewNotificationController(notificationService)
+	broadcastService := services.NewBroadcastService(repositories.NewBroadcastRepository(db), repositories.NewHouseholdRepository(db), notificationService, config.AppConfig.BroadcastRatePerMinute)
+	broadcastController := controllers.NewBroadcastController(broadcastService)
+	calendarController := controllers.NewCalendarController(services.NewCalendarService(
+		repositories.NewCalendarRepository(db),
+		repositories.NewUserRepository(db),
+		groceryRepo,
+		mealPlanRepo,
+		householdService,
+	))
+	jobService := services.NewJobService(repositories.NewJobRepository(db))
+	jobController := controllers.NewJobController(jobService)
 
 	// Set Gin mode based on environment
 	if config.AppConfig.ServerPort == "8080" {
@@ -62,7 +137,7 @@ func main() {
 	)
 
 	// Register routes
-	registerRoutes(router, recipeController)
+	registerRoutes(router, householdService, recipeController, profileController, nutritionController, mealPlanController, shoppingListController, receiptController, householdController, notificationController, deviceController, jobController, broadcastController, calendarController, groceryController, webhookController, syncController)
 
 	// Create HTTP server with graceful shutdown
 	server := &http.Server{
@@ -73,8 +148,19 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
-	// Start notification service in background
-	go startNotificationService()
+	// Start background jobs. Every instance runs a scheduler, each due job runs on only one of them.
+	jobScheduler := scheduler.New(repositories.NewJobRepository(db),
+		scheduler.Job{Name: "expiry_alerts", Schedule: "*/15 * * * *", Run: notificationService.SendExpiryAlerts},
+		scheduler.Job{Name: "broadcasts", Schedule: "* * * * *", Run: broadcastService.SendDueBroadcasts},
+		scheduler.Job{Name: "prune_job_runs", Schedule: "0 3 * * *", Run: jobService.PruneRuns},
+		scheduler.Job{Name: "webhook_deliveries", Schedule: "* * * * *", Run: webhookService.DeliverDue},
+		scheduler.Job{Name: "webhook_expiry_events", Schedule: "*/15 * * * *", Run: webhookService.SendExpiryEvents},
+		scheduler.Job{Name: "prune_webhook_deliveries", Schedule: "30 3 * * *", Run: webhookService.PruneDeliveries},
+		scheduler.Job{Name: "prune_household_events", Schedule: "0 * * * *", Run: syncService.PruneEvents},
+	)
+	if err := jobScheduler.Start(); err != nil {
+		log.Fatalf("Failed to start scheduler: %v", err)
+	}
 
 	// Start server in a goroutine
 	go func() {
@@ -99,11 +185,12 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		log.Printf("Server forced to shutdown: %v", err)
 	}
+	jobScheduler.Stop(ctx)
 
 	log.Println("Server exited properly")
 }
 
-func registerRoutes(router *gin.Engine, recipeController *controllers.RecipeController) {
+func registerRoutes(router *gin.Engine, householdService services.HouseholdService, recipeController *controllers.RecipeController, profileController *controllers.FoodProfileController, nutritionController *controllers.NutritionController, mealPlanController *controllers.MealPlanController, shoppingListController *controllers.ShoppingListController, receiptController *controllers.ReceiptController, householdController *controllers.HouseholdController, notificationController *controllers.NotificationController, deviceController *controllers.DeviceController, jobController *controllers.JobController, broadcastController *controllers.BroadcastController, calendarController *controllers.CalendarController, groceryController *controllers.GroceryController, webhookController *controllers.WebhookController, syncController *controllers.SyncController) {
 	api := router.Group("/api")
 	{
 		// Health check endpoint
@@ -123,35 +210,73 @@ func registerRoutes(router *gin.Engine, recipeController *controllers.RecipeCont
 			auth.GET("/isadmin", middleware.JWTAuthMiddleware(), controllers.CheckAdminStatus)
 		}
 
+		// Calendar feeds, authenticated by the secret token in the URL
+		api.GET("/calendar/:token", calendarController.ServeCalendar)
+
+		// Live household changes, as Server-Sent Events. Browsers can't set headers
+		// on EventSource, so a stream token from /sync/token also works as ?token.
+		api.GET("/sync/events", middleware.StreamAuthMiddleware(), middleware.HouseholdScope(householdService.Membership), syncController.StreamEvents)
+
 		// Admin routes
 		adminRoutes := api.Group("/admin")
 		adminRoutes.Use(middleware.JWTAuthMiddleware(), middleware.RequireAdmin())
 		{
 			adminRoutes.GET("/users", controllers.GetUsersList)
-			adminRoutes.POST("/send-notification", controllers.SendNotification)
+			adminRoutes.POST("/send-notification", notificationController.SendNotification)
+			adminRoutes.GET("/notifications", notificationController.GetSentNotifications)
+			adminRoutes.GET("/broadcasts", broadcastController.GetBroadcasts)
+			adminRoutes.POST("/broadcasts", broadcastController.CreateBroadcast)
+			adminRoutes.GET("/broadcasts/:id", broadcastController.GetBroadcast)
+			adminRoutes.DELETE("/broadcasts/:id", broadcastController.CancelBroadcast)
+			adminRoutes.GET("/broadcasts/:id/deliveries", broadcastController.GetBroadcastDeliveries)
+			adminRoutes.POST("/recipes/import", recipeController.ImportRecipeLibrary)
+			adminRoutes.GET("/recipes/usage", recipeController.GetGenerationUsage)
+			adminRoutes.POST("/nutrition/import", nutritionController.ImportFoods)
+			adminRoutes.GET("/jobs", jobController.GetJobs)
+			adminRoutes.PUT("/jobs/:name", jobController.UpdateJob)
+			adminRoutes.POST("/jobs/:name/run", jobController.RunJob)
+			adminRoutes.GET("/jobs/:name/runs", jobController.GetJobRuns)
+			adminRoutes.POST("/webhooks", webhookController.CreateGlobalWebhook) // Every household's events, managed through /webhooks
 		}
 
 		// Protected routes
 		protected := api.Group("")
-		protected.Use(middleware.JWTAuthMiddleware())
+		protected.Use(middleware.JWTAuthMiddleware(), middleware.HouseholdScope(householdService.Membership))
 		{
+			// Household routes
+			household := protected.Group("/household")
+			{
+				household.GET("", householdController.GetHousehold)
+				household.PUT("", householdController.RenameHousehold)
+				household.GET("/invites", householdController.GetInvites)
+				household.POST("/invites", householdController.CreateInvite)
+				household.DELETE("/invites/:id", householdController.RevokeInvite)
+				household.POST("/join", householdController.JoinHousehold)
+				household.POST("/leave", householdController.LeaveHousehold)
+				household.POST("/transfer", householdController.TransferHousehold)
+				household.PUT("/members/:userId", householdController.UpdateHouseholdMember)
+				household.DELETE("/members/:userId", householdController.RemoveHouseholdMember)
+			}
+
 			// Grocery routes
-			grocery := protected.Group("/groceries")
+			grocery := protected.Group("/groceries", middleware.RequireHouseholdWriter())
 			{
-				grocery.GET("", controllers.GetAllGroceries)
-				grocery.POST("", controllers.CreateGrocery)
-				grocery.GET("/:id", controllers.GetGrocery)
-				grocery.PUT("/:id", controllers.UpdateGrocery)
-				grocery.DELETE("/:id", controllers.DeleteGrocery)
-				grocery.GET("/expiring", controllers.GetExpiringGroceries)
+				grocery.GET("", groceryController.GetAllGroceries)
+				grocery.POST("", groceryController.CreateGrocery)
+				grocery.GET("/:id", groceryController.GetGrocery)
+				grocery.PUT("/:id", groceryController.UpdateGrocery)
+				grocery.DELETE("/:id", groceryController.DeleteGrocery)
+				grocery.GET("/expiring", groceryController.GetExpiringGroceries)
+				grocery.POST("/:id/consume", nutritionController.ConsumeGrocery)
 			}
 
 			// Receipt routes
-			receipt := protected.Group("/receipts")
+			receipt := protected.Group("/receipts", middleware.RequireHouseholdWriter())
 			{
-				receipt.POST("/upload", controllers.UploadReceipt)
-				receipt.GET("", controllers.GetAllReceipts)
-				receipt.GET("/:id", controllers.GetReceipt)
+				receipt.POST("/upload", receiptController.UploadReceipt)
+				receipt.GET("", receiptController.GetAllReceipts)
+				receipt.GET("/over-purchases", receiptController.GetOverPurchaseRates)
+				receipt.GET("/:id", receiptController.GetReceipt)
 			}
 
 			// User routes
@@ -159,74 +284,125 @@ func registerRoutes(router *gin.Engine, recipeController *controllers.RecipeCont
 			{
 				user.GET("", controllers.GetCurrentUser)
 				user.PUT("", controllers.UpdateUser)
-				user.POST("/fcm-token", controllers.RegisterFCMToken)
+				user.POST("/fcm-token", deviceController.RegisterDevice) // Older clients, same as POST /user/devices
+				user.GET("/devices", deviceController.GetDevices)
+				user.POST("/devices", deviceController.RegisterDevice)
+				user.DELETE("/devices/:id", deviceController.RemoveDevice)
+				user.GET("/food-profile", profileController.GetFoodProfile)
+				user.PUT("/food-profile", profileController.UpdateFoodProfile)
+				user.GET("/notification-preferences", notificationController.GetNotificationPreferences)
+				user.PUT("/notification-preferences", notificationController.UpdateNotificationPreferences)
+				user.GET("/calendar", calendarController.GetCalendarFeed)
+				user.POST("/calendar/rotate", calendarController.RotateCalendarFeed)
+				user.DELETE("/calendar", calendarController.DeleteCalendarFeed)
+			}
+
+			// Notification inbox routes
+			notifications := protected.Group("/notifications")
+			{
+				notifications.GET("", notificationController.GetNotifications)
+				notifications.PUT("/read", notificationController.MarkNotificationsRead)
+			}
+
+			// Tokens for opening /sync/events from a browser
+			protected.POST("/sync/token", syncController.CreateStreamToken)
+
+			// Outbound webhook routes
+			webhooks := protected.Group("/webhooks")
+			{
+				webhooks.GET("", webhookController.GetWebhooks)
+				webhooks.POST("", webhookController.CreateWebhook)
+				webhooks.GET("/:id", webhookController.GetWebhook)
+				webhooks.PUT("/:id", webhookController.UpdateWebhook)
+				webhooks.DELETE("/:id", webhookController.DeleteWebhook)
+				webhooks.GET("/:id/deliveries", webhookController.GetWebhookDeliveries)
+				webhooks.POST("/:id/test", webhookController.TestWebhook)
+			}
+
+			// Nutrition routes
+			nutrition := protected.Group("/nutrition")
+			{
+				nutrition.GET("/foods", nutritionController.SearchFoods)
+				nutrition.GET("/weekly", nutritionController.GetWeeklyNutrition)
+			}
+
+			// Meal plan routes
+			mealPlan := protected.Group("/meal-plan", middleware.RequireHouseholdWriter())
+			{
+				mealPlan.GET("", mealPlanController.GetMealPlan)
+				mealPlan.POST("", mealPlanController.AddMealPlanEntry)
+				mealPlan.GET("/suggestions", mealPlanController.GetMealPlanSuggestions)
+				mealPlan.PUT("/:id", mealPlanController.UpdateMealPlanEntry)
+				mealPlan.DELETE("/:id", mealPlanController.DeleteMealPlanEntry)
+				mealPlan.POST("/:id/cook", mealPlanController.CookMealPlanEntry)
+			}
+
+			// Shopping list routes
+			shoppingList := protected.Group("/shopping-lists", middleware.RequireHouseholdWriter())
+			{
+				shoppingList.GET("", shoppingListController.GetShoppingLists)
+				shoppingList.POST("", shoppingListController.CreateShoppingList)
+				shoppingList.POST("/generate", shoppingListController.GenerateShoppingList)
+				shoppingList.GET("/:id", shoppingListController.GetShoppingList)
+				shoppingList.DELETE("/:id", shoppingListController.DeleteShoppingList)
+				shoppingList.POST("/:id/items", shoppingListController.AddShoppingListItem)
+				shoppingList.PUT("/:id/items/:itemId", shoppingListController.UpdateShoppingListItem)
+				shoppingList.DELETE("/:id/items/:itemId", shoppingListController.DeleteShoppingListItem)
+			}
+
+			// Par level routes
+			parLevel := protected.Group("/par-levels", middleware.RequireHouseholdWriter())
+			{
+				parLevel.GET("", shoppingListController.GetParLevels)
+				parLevel.PUT("", shoppingListController.SetParLevel)
+				parLevel.DELETE("/:id", shoppingListController.DeleteParLevel)
 			}
 
 			// Recipe routes (new)
-			recipe := protected.Group("/recipes")
+			recipe := protected.Group("/recipes", middleware.RequireHouseholdWriter())
 			{
 				recipe.GET("", recipeController.GetAllRecipes)
+				recipe.GET("/match", recipeController.MatchRecipes)
 				recipe.GET("/:id", recipeController.GetRecipeByID)
+				recipe.GET("/:id/nutrition", nutritionController.GetRecipeNutrition)
+				recipe.PUT("/:id", recipeController.UpdateRecipe)
+				recipe.DELETE("/:id", recipeController.DeleteRecipe)
 				recipe.POST("/generate", recipeController.GenerateRecipes)
+				recipe.POST("/generate/stream", recipeController.GenerateRecipesStream)
 			}
 		}
 	}
 }
 
-func startNotificationService() {
-	// Create tickers for different notification frequencies
-	sevenDayTicker := time.NewTicker(24 * time.Hour)
-	threeDayTicker := time.NewTicker(12 * time.Hour)
-	oneDayTicker := time.NewTicker(4 * time.Hour)
-
-	defer func() {
-		sevenDayTicker.Stop()
-		threeDayTicker.Stop()
-		oneDayTicker.Stop()
-	}()
-
-	for {
-		select {
-		case <-sevenDayTicker.C:
-			log.Println("Checking for items expiring in 7 days...")
-			checkAndNotifyItems(7 * 24 * time.Hour)
-		case <-threeDayTicker.C:
-			log.Println("Checking for items expiring in 3 days...")
-			checkAndNotifyItems(3 * 24 * time.Hour)
-		case <-oneDayTicker.C:
-			log.Println("Checking for items expiring in 1 day...")
-			checkAndNotifyItems(24 * time.Hour)
-		}
+// initNotifiers sets up a notifier per channel. A channel that isn't configured
+// falls back to logging, so the API still starts without Firebase or SMTP.
+// pruneTokens removes devices that push reports as gone.
+func initNotifiers(pruneTokens func(tokens []string)) map[string]notify.Notifier {
+	notifiers := map[string]notify.Notifier{
+		models.ChannelWebhook: notify.NewWebhookNotifier(),
 	}
-}
 
-func checkAndNotifyItems(threshold time.Duration) {
-	var users []models.User
-	if err := database.DB.Find(&users).Error; err != nil {
-		log.Printf("Notification service error: %v", err)
-		return
+	fcm, err := notify.NewFCMNotifier(context.Background(), config.AppConfig.FirebaseProjectID, config.AppConfig.FirebaseCredentialsFile, pruneTokens)
+	if err != nil {
+		log.Printf("Warning: Firebase not initialized (%v) - push notifications will only be logged", err)
+		notifiers[models.ChannelPush] = notify.NewLogNotifier(models.ChannelPush)
+	} else {
+		log.Println("Firebase Cloud Messaging initialized successfully")
+		notifiers[models.ChannelPush] = fcm
 	}
 
-	for _, user := range users {
-		if user.FCMToken == "" {
-			continue
-		}
-
-		var expiringItems []models.GroceryItem
-		expiryThreshold := time.Now().Add(threshold)
-
-		if err := database.DB.Where(
-			"user_id = ? AND expiry_date <= ? AND expiry_date > ?",
-			user.ID,
-			expiryThreshold,
-			time.Now(),
-		).Find(&expiringItems).Error; err != nil {
-			log.Printf("Failed to fetch expiring items for user %d: %v", user.ID, err)
-			continue
-		}
-
-		if len(expiringItems) > 0 {
-			services.SendExpiryNotification(user, expiringItems)
-		}
+	if config.AppConfig.SMTPHost == "" {
+		log.Println("Warning: No SMTP_HOST set - email notifications will only be logged")
+		notifiers[models.ChannelEmail] = notify.NewLogNotifier(models.ChannelEmail)
+	} else {
+		notifiers[models.ChannelEmail] = notify.NewSMTPNotifier(notify.SMTPConfig{
+			Host:     config.AppConfig.SMTPHost,
+			Port:     config.AppConfig.SMTPPort,
+			Username: config.AppConfig.SMTPUsername,
+			Password: config.AppConfig.SMTPPassword,
+			From:     config.AppConfig.SMTPFrom,
+		})
 	}
+
+	return notifiers
 }
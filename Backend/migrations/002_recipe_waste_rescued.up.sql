@@ -0,0 +1,4 @@
+-- Track how much soon-expiring stock each generated recipe uses
+ALTER TABLE recipes
+    ADD COLUMN waste_rescued_score DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN expiring_ingredients TEXT NOT NULL DEFAULT '';
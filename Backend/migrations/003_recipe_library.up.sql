@@ -0,0 +1,14 @@
+-- Library recipes belong to no user
+ALTER TABLE recipes ALTER COLUMN user_id DROP NOT NULL;
+
+-- Structured ingredients for library recipes
+CREATE TABLE recipe_ingredients (
+    id SERIAL PRIMARY KEY,
+    recipe_id INTEGER NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    quantity DECIMAL(10, 2),
+    unit VARCHAR(50),
+    optional BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX idx_recipe_ingredients_recipe_id ON recipe_ingredients(recipe_id);
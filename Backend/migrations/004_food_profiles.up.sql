@@ -0,0 +1,13 @@
+-- Per-user dietary restrictions and kitchen constraints for recipe generation
+CREATE TABLE food_profiles (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER UNIQUE NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    diet VARCHAR(50), -- vegetarian, vegan, halal or kosher
+    allergens TEXT NOT NULL DEFAULT '[]', -- JSON array
+    disliked_ingredients TEXT NOT NULL DEFAULT '[]', -- JSON array
+    household_size INTEGER NOT NULL DEFAULT 0,
+    equipment TEXT NOT NULL DEFAULT '[]', -- JSON array
+    max_cook_time INTEGER NOT NULL DEFAULT 0, -- In minutes, 0 for no limit
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
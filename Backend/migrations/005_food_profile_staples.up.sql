@@ -0,0 +1,2 @@
+-- Ingredients a user always has on hand, never flagged as missing from the pantry
+ALTER TABLE food_profiles ADD COLUMN staples TEXT NOT NULL DEFAULT '[]'; -- JSON array
@@ -0,0 +1,7 @@
+-- User feedback on saved recipes
+ALTER TABLE recipes
+    ADD COLUMN favorite BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN rating INTEGER NOT NULL DEFAULT 0, -- 1-5, 0 when unrated
+    ADD COLUMN notes TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_recipes_user_rating ON recipes(user_id, rating);
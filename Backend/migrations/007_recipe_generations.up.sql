@@ -0,0 +1,15 @@
+-- Recipe generation log, used to cache results, enforce daily quotas and report token usage
+CREATE TABLE recipe_generations (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    fingerprint VARCHAR(64) NOT NULL, -- SHA-256 of the pantry, food profile and cuisine
+    source VARCHAR(20) NOT NULL, -- model or cache
+    recipe_ids TEXT NOT NULL DEFAULT '[]', -- JSON array
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_recipe_generations_user_id ON recipe_generations(user_id);
+CREATE INDEX idx_recipe_generations_fingerprint ON recipe_generations(fingerprint);
+CREATE INDEX idx_recipe_generations_created_at ON recipe_generations(created_at);
@@ -0,0 +1,46 @@
+-- Offline nutrition database, values per 100 g
+CREATE TABLE nutrition_foods (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) UNIQUE NOT NULL, -- Lowercase
+    category VARCHAR(100),
+    source VARCHAR(50), -- e.g. usda-sr-legacy
+    source_id VARCHAR(50),
+    calories DECIMAL(10,2) NOT NULL DEFAULT 0, -- kcal
+    protein DECIMAL(10,2) NOT NULL DEFAULT 0, -- g
+    fat DECIMAL(10,2) NOT NULL DEFAULT 0, -- g
+    carbohydrates DECIMAL(10,2) NOT NULL DEFAULT 0, -- g
+    fiber DECIMAL(10,2) NOT NULL DEFAULT 0, -- g
+    sugar DECIMAL(10,2) NOT NULL DEFAULT 0, -- g
+    sodium DECIMAL(10,2) NOT NULL DEFAULT 0, -- mg
+    grams_per_piece DECIMAL(10,2) NOT NULL DEFAULT 0, -- 0 when unknown
+    density DECIMAL(10,3) NOT NULL DEFAULT 0, -- g/ml, 0 for water-like
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Explicit links from pantry items and library ingredients to the nutrition database
+ALTER TABLE grocery_items ADD COLUMN nutrition_food_id INTEGER REFERENCES nutrition_foods(id) ON DELETE SET NULL;
+ALTER TABLE recipe_ingredients ADD COLUMN nutrition_food_id INTEGER REFERENCES nutrition_foods(id) ON DELETE SET NULL;
+
+-- Pantry stock the user ate, with its nutrition at the time
+CREATE TABLE consumption_entries (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    grocery_item_id INTEGER REFERENCES grocery_items(id) ON DELETE SET NULL,
+    nutrition_food_id INTEGER REFERENCES nutrition_foods(id) ON DELETE SET NULL,
+    name VARCHAR(255) NOT NULL,
+    quantity DECIMAL(10,2) NOT NULL,
+    unit VARCHAR(50),
+    grams DECIMAL(10,2) NOT NULL DEFAULT 0,
+    calories DECIMAL(10,2) NOT NULL DEFAULT 0,
+    protein DECIMAL(10,2) NOT NULL DEFAULT 0,
+    fat DECIMAL(10,2) NOT NULL DEFAULT 0,
+    carbohydrates DECIMAL(10,2) NOT NULL DEFAULT 0,
+    fiber DECIMAL(10,2) NOT NULL DEFAULT 0,
+    sugar DECIMAL(10,2) NOT NULL DEFAULT 0,
+    sodium DECIMAL(10,2) NOT NULL DEFAULT 0,
+    consumed_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_consumption_entries_user_consumed ON consumption_entries(user_id, consumed_at);
@@ -0,0 +1,29 @@
+-- Recipes planned for a date and meal
+CREATE TABLE meal_plan_entries (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recipe_id INTEGER NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    meal VARCHAR(20) NOT NULL, -- breakfast, lunch, dinner or snack
+    servings INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'planned', -- planned or cooked
+    shortfalls TEXT NOT NULL DEFAULT '[]', -- JSON array
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_meal_plan_entries_user_date ON meal_plan_entries(user_id, date);
+
+-- Pantry stock held back for planned meals
+CREATE TABLE pantry_reservations (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    meal_plan_entry_id INTEGER NOT NULL REFERENCES meal_plan_entries(id) ON DELETE CASCADE,
+    grocery_item_id INTEGER NOT NULL REFERENCES grocery_items(id) ON DELETE CASCADE,
+    ingredient VARCHAR(255),
+    quantity DECIMAL(10,3) NOT NULL, -- In the grocery item's unit
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_pantry_reservations_entry ON pantry_reservations(meal_plan_entry_id);
+CREATE INDEX idx_pantry_reservations_grocery ON pantry_reservations(grocery_item_id);
@@ -0,0 +1,40 @@
+-- Shopping lists, entered by hand or generated from the meal plan, recipes and par levels
+CREATE TABLE shopping_lists (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_shopping_lists_user_id ON shopping_lists(user_id);
+
+CREATE TABLE shopping_list_items (
+    id SERIAL PRIMARY KEY,
+    shopping_list_id INTEGER NOT NULL REFERENCES shopping_lists(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    quantity DECIMAL(10,3) NOT NULL DEFAULT 0, -- 0 when no amount is known
+    unit VARCHAR(50),
+    sources TEXT NOT NULL DEFAULT '[]', -- JSON array of manual, recipe, meal_plan or par_level
+    checked BOOLEAN NOT NULL DEFAULT FALSE,
+    checked_at TIMESTAMP,
+    grocery_item_id INTEGER REFERENCES grocery_items(id) ON DELETE SET NULL, -- Pantry item created when checked off
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_shopping_list_items_list_id ON shopping_list_items(shopping_list_id);
+
+-- Minimum stock to keep per product
+CREATE TABLE par_levels (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    quantity DECIMAL(10,3) NOT NULL,
+    unit VARCHAR(50),
+    storage_location VARCHAR(50), -- Where restocked items go
+    shelf_life_days INTEGER NOT NULL DEFAULT 0, -- 0 to estimate
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
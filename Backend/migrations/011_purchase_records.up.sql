@@ -0,0 +1,16 @@
+-- Products bought, flagged when the pantry already held enough of them
+CREATE TABLE purchase_records (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    product VARCHAR(255) NOT NULL, -- Lower-case product name
+    quantity DECIMAL(10,3) NOT NULL DEFAULT 0,
+    unit VARCHAR(50),
+    source VARCHAR(20) NOT NULL, -- receipt or shopping_list
+    receipt_id INTEGER REFERENCES receipts(id) ON DELETE SET NULL,
+    over_purchase BOOLEAN NOT NULL DEFAULT FALSE,
+    pantry_quantity DECIMAL(10,3) NOT NULL DEFAULT 0, -- Usable stock already held, in unit
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_purchase_records_user_product ON purchase_records(user_id, product);
+CREATE INDEX idx_purchase_records_created_at ON purchase_records(created_at);
@@ -0,0 +1,77 @@
+-- Households share one pantry, its receipts, recipes, meal plan and shopping lists
+CREATE TABLE households (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    owner_id INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_households_owner_id ON households(owner_id);
+
+-- Every user belongs to exactly one household
+CREATE TABLE household_members (
+    id SERIAL PRIMARY KEY,
+    household_id INTEGER NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL, -- owner, member or viewer
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_household_members_household_id ON household_members(household_id);
+
+-- Codes for joining a household, without needing an email address
+CREATE TABLE household_invites (
+    id SERIAL PRIMARY KEY,
+    household_id INTEGER NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    code VARCHAR(16) NOT NULL UNIQUE,
+    role VARCHAR(20) NOT NULL, -- Role given to whoever joins, member or viewer
+    created_by INTEGER,
+    max_uses INTEGER NOT NULL DEFAULT 1,
+    uses INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_household_invites_household_id ON household_invites(household_id);
+
+-- Give every existing user a household of their own. owner_id temporarily
+-- holds the user so the memberships can be matched up.
+INSERT INTO households (name, owner_id)
+SELECT name || '''s household', id FROM users;
+
+INSERT INTO household_members (household_id, user_id, role)
+SELECT id, owner_id, 'owner' FROM households;
+
+-- Move existing data into its owner's household
+ALTER TABLE grocery_items ADD COLUMN household_id INTEGER REFERENCES households(id) ON DELETE CASCADE;
+ALTER TABLE receipts ADD COLUMN household_id INTEGER REFERENCES households(id) ON DELETE CASCADE;
+ALTER TABLE recipes ADD COLUMN household_id INTEGER REFERENCES households(id) ON DELETE CASCADE; -- NULL for library recipes
+ALTER TABLE shopping_lists ADD COLUMN household_id INTEGER REFERENCES households(id) ON DELETE CASCADE;
+ALTER TABLE par_levels ADD COLUMN household_id INTEGER REFERENCES households(id) ON DELETE CASCADE;
+ALTER TABLE meal_plan_entries ADD COLUMN household_id INTEGER REFERENCES households(id) ON DELETE CASCADE;
+ALTER TABLE pantry_reservations ADD COLUMN household_id INTEGER REFERENCES households(id) ON DELETE CASCADE;
+ALTER TABLE purchase_records ADD COLUMN household_id INTEGER REFERENCES households(id) ON DELETE CASCADE;
+
+UPDATE grocery_items t SET household_id = m.household_id FROM household_members m WHERE m.user_id = t.user_id;
+UPDATE receipts t SET household_id = m.household_id FROM household_members m WHERE m.user_id = t.user_id;
+UPDATE recipes t SET household_id = m.household_id FROM household_members m WHERE m.user_id = t.user_id;
+UPDATE shopping_lists t SET household_id = m.household_id FROM household_members m WHERE m.user_id = t.user_id;
+UPDATE par_levels t SET household_id = m.household_id FROM household_members m WHERE m.user_id = t.user_id;
+UPDATE meal_plan_entries t SET household_id = m.household_id FROM household_members m WHERE m.user_id = t.user_id;
+UPDATE pantry_reservations t SET household_id = m.household_id FROM household_members m WHERE m.user_id = t.user_id;
+UPDATE purchase_records t SET household_id = m.household_id FROM household_members m WHERE m.user_id = t.user_id;
+
+CREATE INDEX idx_grocery_items_household_expiry ON grocery_items(household_id, expiry_date);
+CREATE INDEX idx_receipts_household_id ON receipts(household_id);
+CREATE INDEX idx_recipes_household_id ON recipes(household_id);
+CREATE INDEX idx_shopping_lists_household_id ON shopping_lists(household_id);
+CREATE INDEX idx_meal_plan_entries_household_date ON meal_plan_entries(household_id, date);
+CREATE INDEX idx_pantry_reservations_household_id ON pantry_reservations(household_id);
+
+-- Par levels and over-purchase rates are kept per household instead of per user
+ALTER TABLE par_levels DROP CONSTRAINT IF EXISTS par_levels_user_id_name_key;
+ALTER TABLE par_levels ADD CONSTRAINT par_levels_household_id_name_key UNIQUE (household_id, name);
+
+DROP INDEX IF EXISTS idx_purchase_records_user_product;
+CREATE INDEX idx_purchase_records_household_product ON purchase_records(household_id, product);
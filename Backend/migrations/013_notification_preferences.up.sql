@@ -0,0 +1,16 @@
+-- When and how each user hears about expiring items
+CREATE TABLE notification_preferences (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    lead_days TEXT NOT NULL DEFAULT '[7,3,1]', -- JSON array of days before expiry
+    delivery VARCHAR(20) NOT NULL DEFAULT 'instant', -- instant or digest
+    digest_time VARCHAR(5), -- HH:MM in time_zone
+    quiet_hours_start VARCHAR(5), -- HH:MM in time_zone, empty for no quiet hours
+    quiet_hours_end VARCHAR(5),
+    time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC', -- IANA name
+    channels TEXT NOT NULL DEFAULT '["push"]', -- JSON array of enabled channels
+    last_alert_at TIMESTAMP, -- Instant alerts due up to here have been handled
+    last_digest_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+-- Users can also be notified by email or on their own webhook
+ALTER TABLE notification_preferences ADD COLUMN webhook_url VARCHAR(500);
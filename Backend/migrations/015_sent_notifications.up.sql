@@ -0,0 +1,17 @@
+-- Ledger of expiry alerts, so each item is alerted at most once per lead time and channel
+CREATE TABLE sent_notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    grocery_item_id INTEGER NOT NULL, -- No foreign key, entries outlive the item
+    threshold INTEGER NOT NULL, -- Lead time in days the alert was for
+    channel VARCHAR(20) NOT NULL,
+    kind VARCHAR(30) NOT NULL, -- expiry_alert or expiry_digest
+    item_name VARCHAR(255),
+    sent_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_sent_notifications_dedup ON sent_notifications(user_id, grocery_item_id, threshold, channel);
+CREATE INDEX idx_sent_notifications_sent_at ON sent_notifications(sent_at);
+
+-- The ledger replaces the time window used to avoid repeating alerts
+ALTER TABLE notification_preferences DROP COLUMN last_alert_at;
@@ -0,0 +1,13 @@
+-- In-app inbox holding every notification sent to a user
+CREATE TABLE inbox_notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    body TEXT,
+    kind VARCHAR(30) NOT NULL,
+    data TEXT, -- JSON object of deep-link IDs, e.g. item_ids or recipe_id
+    read_at TIMESTAMP, -- NULL while unread
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_inbox_notifications_user_created ON inbox_notifications(user_id, created_at);
@@ -0,0 +1,20 @@
+-- Push tokens per device instead of a single token per user
+CREATE TABLE devices (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token VARCHAR(512) NOT NULL UNIQUE,
+    platform VARCHAR(20) NOT NULL, -- android, ios, web or unknown
+    app_version VARCHAR(50),
+    last_seen_at TIMESTAMP NOT NULL, -- Last registration, apps re-register on launch
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_devices_user_id ON devices(user_id);
+
+-- Existing tokens become each user's first device
+INSERT INTO devices (user_id, token, platform, last_seen_at)
+SELECT id, fcm_token, 'unknown', updated_at FROM users WHERE fcm_token IS NOT NULL AND fcm_token <> ''
+ON CONFLICT (token) DO NOTHING;
+
+ALTER TABLE users DROP COLUMN fcm_token;
@@ -0,0 +1,22 @@
+-- Background jobs shared by all API instances, with their run history
+CREATE TABLE scheduled_jobs (
+    name VARCHAR(100) PRIMARY KEY,
+    schedule VARCHAR(100) NOT NULL, -- Cron expression, evaluated in UTC
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at TIMESTAMP NOT NULL,
+    last_run_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE job_runs (
+    id SERIAL PRIMARY KEY,
+    job VARCHAR(100) NOT NULL,
+    instance VARCHAR(255), -- Host and process that ran it
+    status VARCHAR(20) NOT NULL, -- running, succeeded, failed or interrupted
+    error TEXT,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX idx_job_runs_job_started ON job_runs(job, started_at);
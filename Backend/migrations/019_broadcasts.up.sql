@@ -0,0 +1,38 @@
+-- Last login, to find users who have gone inactive
+ALTER TABLE users ADD COLUMN last_login_at TIMESTAMP;
+
+-- Admin notifications to a segment of users, with a delivery report per recipient
+CREATE TABLE broadcasts (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    segment VARCHAR(20) NOT NULL, -- all, inactive, expiring or household
+    inactive_days INTEGER,
+    expiring_days INTEGER,
+    household_id INTEGER,
+    status VARCHAR(20) NOT NULL, -- scheduled, sending, sent or cancelled
+    scheduled_at TIMESTAMP NOT NULL,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    recipient_count INTEGER NOT NULL DEFAULT 0,
+    delivered_count INTEGER NOT NULL DEFAULT 0,
+    inbox_only_count INTEGER NOT NULL DEFAULT 0,
+    failed_count INTEGER NOT NULL DEFAULT 0,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_broadcasts_status ON broadcasts(status);
+
+CREATE TABLE broadcast_deliveries (
+    id SERIAL PRIMARY KEY,
+    broadcast_id INTEGER NOT NULL REFERENCES broadcasts(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL, -- delivered, inbox_only or failed
+    channels TEXT, -- JSON array of channels that took it, besides the inbox
+    error TEXT,
+    sent_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_broadcast_deliveries_recipient ON broadcast_deliveries(broadcast_id, user_id);
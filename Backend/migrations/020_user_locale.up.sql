@@ -0,0 +1,10 @@
+-- Language and time zone of a user's notifications
+ALTER TABLE users ADD COLUMN locale VARCHAR(10) NOT NULL DEFAULT 'en';
+ALTER TABLE users ADD COLUMN time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC'; -- IANA name
+
+-- The time zone used to be a notification preference
+UPDATE users SET time_zone = p.time_zone
+FROM notification_preferences p
+WHERE p.user_id = users.id AND p.time_zone <> '';
+
+ALTER TABLE notification_preferences DROP COLUMN time_zone;
@@ -0,0 +1,9 @@
+-- Secret calendar subscription URLs, one per user
+CREATE TABLE calendar_feeds (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    last_fetched_at TIMESTAMP, -- Last time a calendar app read the feed
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
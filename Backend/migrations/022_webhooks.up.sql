@@ -0,0 +1,37 @@
+-- Endpoints that receive a household's events as signed JSON
+CREATE TABLE webhooks (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Who registered it
+    household_id INTEGER REFERENCES households(id) ON DELETE CASCADE, -- NULL for every household (admins only)
+    url VARCHAR(500) NOT NULL,
+    secret VARCHAR(100) NOT NULL, -- HMAC-SHA256 signing key
+    events TEXT, -- JSON array of event types, e.g. ["item.created"]
+    description VARCHAR(255),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_webhooks_user_id ON webhooks(user_id);
+CREATE INDEX idx_webhooks_household_id ON webhooks(household_id);
+
+-- One event sent, or being retried, to one webhook
+CREATE TABLE webhook_deliveries (
+    id SERIAL PRIMARY KEY,
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_id VARCHAR(100) NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL, -- Kept so retries send the same body
+    status VARCHAR(20) NOT NULL, -- pending, succeeded or failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_status INTEGER, -- HTTP status of the last attempt
+    error TEXT,
+    next_attempt_at TIMESTAMP,
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_webhook_deliveries_event ON webhook_deliveries(webhook_id, event_id);
+CREATE INDEX idx_webhook_deliveries_status ON webhook_deliveries(status);
+CREATE INDEX idx_webhook_deliveries_next_attempt_at ON webhook_deliveries(next_attempt_at);
@@ -0,0 +1,11 @@
+-- Recent household changes, so live sync clients can resume after reconnecting
+CREATE TABLE household_events (
+    id BIGSERIAL PRIMARY KEY, -- Clients resume from the last ID they saw
+    household_id INTEGER NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL, -- The event as JSON
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_household_events_household_id ON household_events(household_id, id);
+CREATE INDEX idx_household_events_created_at ON household_events(created_at);
@@ -0,0 +1,4 @@
+-- The bundled nutrition table used to be labelled as USDA SR Legacy.
+-- Foods from a real SR Legacy import carry their NDB number in source_id.
+UPDATE nutrition_foods SET source = 'bundled'
+WHERE source = 'usda-sr-legacy' AND COALESCE(source_id, '') = '';
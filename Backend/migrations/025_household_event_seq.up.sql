@@ -0,0 +1,13 @@
+-- Household events are resumed from a per-household sequence number instead of
+-- their global ID, which could commit out of order. They only live a day, so
+-- old ones are dropped rather than numbered; clients get a reset.
+DELETE FROM household_events;
+DROP INDEX idx_household_events_household_id;
+ALTER TABLE household_events ADD COLUMN seq BIGINT NOT NULL;
+CREATE UNIQUE INDEX idx_household_events_seq ON household_events(household_id, seq);
+
+-- The last sequence number given to each household's events
+CREATE TABLE household_event_sequences (
+    household_id INTEGER PRIMARY KEY REFERENCES households(id) ON DELETE CASCADE,
+    seq BIGINT NOT NULL
+);
@@ -0,0 +1,33 @@
+-- Household data stays in the household when the member who added it deletes
+-- their account; only who added it is forgotten. Library recipes are told
+-- apart by their missing household from now on.
+ALTER TABLE receipts ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE receipts DROP CONSTRAINT receipts_user_id_fkey;
+ALTER TABLE receipts ADD CONSTRAINT receipts_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE grocery_items ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE grocery_items DROP CONSTRAINT grocery_items_user_id_fkey;
+ALTER TABLE grocery_items ADD CONSTRAINT grocery_items_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE recipes DROP CONSTRAINT recipes_user_id_fkey;
+ALTER TABLE recipes ADD CONSTRAINT recipes_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE shopping_lists ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE shopping_lists DROP CONSTRAINT shopping_lists_user_id_fkey;
+ALTER TABLE shopping_lists ADD CONSTRAINT shopping_lists_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE par_levels ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE par_levels DROP CONSTRAINT par_levels_user_id_fkey;
+ALTER TABLE par_levels ADD CONSTRAINT par_levels_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE meal_plan_entries ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE meal_plan_entries DROP CONSTRAINT meal_plan_entries_user_id_fkey;
+ALTER TABLE meal_plan_entries ADD CONSTRAINT meal_plan_entries_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE pantry_reservations ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE pantry_reservations DROP CONSTRAINT pantry_reservations_user_id_fkey;
+ALTER TABLE pantry_reservations ADD CONSTRAINT pantry_reservations_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE purchase_records ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE purchase_records DROP CONSTRAINT purchase_records_user_id_fkey;
+ALTER TABLE purchase_records ADD CONSTRAINT purchase_records_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
@@ -65,6 +65,21 @@ func AutoMigrate() {
 		log.Fatalf("Failed to migrate users: %v", err)
 	}
 
+	err = DB.AutoMigrate(&models.Household{})
+	if err != nil {
+		log.Fatalf("Failed to migrate households: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.HouseholdMember{})
+	if err != nil {
+		log.Fatalf("Failed to migrate household_members: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.HouseholdInvite{})
+	if err != nil {
+		log.Fatalf("Failed to migrate household_invites: %v", err)
+	}
+
 	err = DB.AutoMigrate(&models.Receipt{})
 	if err != nil {
 		log.Fatalf("Failed to migrate receipts: %v", err)
@@ -76,12 +91,209 @@ func AutoMigrate() {
 		log.Fatalf("Failed to migrate grocery_items: %v", err)
 	}
 
+	err = DB.AutoMigrate(&models.Recipe{})
+	if err != nil {
+		log.Fatalf("Failed to migrate recipes: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.RecipeIngredient{})
+	if err != nil {
+		log.Fatalf("Failed to migrate recipe_ingredients: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.FoodProfile{})
+	if err != nil {
+		log.Fatalf("Failed to migrate food_profiles: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.RecipeGeneration{})
+	if err != nil {
+		log.Fatalf("Failed to migrate recipe_generations: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.NutritionFood{})
+	if err != nil {
+		log.Fatalf("Failed to migrate nutrition_foods: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.ConsumptionEntry{})
+	if err != nil {
+		log.Fatalf("Failed to migrate consumption_entries: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.MealPlanEntry{})
+	if err != nil {
+		log.Fatalf("Failed to migrate meal_plan_entries: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.PantryReservation{})
+	if err != nil {
+		log.Fatalf("Failed to migrate pantry_reservations: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.ShoppingList{})
+	if err != nil {
+		log.Fatalf("Failed to migrate shopping_lists: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.ShoppingListItem{})
+	if err != nil {
+		log.Fatalf("Failed to migrate shopping_list_items: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.ParLevel{})
+	if err != nil {
+		log.Fatalf("Failed to migrate par_levels: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.PurchaseRecord{})
+	if err != nil {
+		log.Fatalf("Failed to migrate purchase_records: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.NotificationPreferences{})
+	if err != nil {
+		log.Fatalf("Failed to migrate notification_preferences: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.SentNotification{})
+	if err != nil {
+		log.Fatalf("Failed to migrate sent_notifications: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.InboxNotification{})
+	if err != nil {
+		log.Fatalf("Failed to migrate inbox_notifications: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.Device{})
+	if err != nil {
+		log.Fatalf("Failed to migrate devices: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.ScheduledJob{})
+	if err != nil {
+		log.Fatalf("Failed to migrate scheduled_jobs: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.JobRun{})
+	if err != nil {
+		log.Fatalf("Failed to migrate job_runs: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.Broadcast{})
+	if err != nil {
+		log.Fatalf("Failed to migrate broadcasts: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.BroadcastDelivery{})
+	if err != nil {
+		log.Fatalf("Failed to migrate broadcast_deliveries: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.CalendarFeed{})
+	if err != nil {
+		log.Fatalf("Failed to migrate calendar_feeds: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.Webhook{})
+	if err != nil {
+		log.Fatalf("Failed to migrate webhooks: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.WebhookDelivery{})
+	if err != nil {
+		log.Fatalf("Failed to migrate webhook_deliveries: %v", err)
+	}
+
+	// Events used to be resumed from their global ID. They only live a day, so
+	// old ones are dropped rather than numbered; clients get a reset.
+	if DB.Migrator().HasTable(&models.HouseholdEvent{}) && !DB.Migrator().HasColumn(&models.HouseholdEvent{}, "seq") {
+		err = DB.Exec("DELETE FROM household_events").Error
+		if err == nil {
+			err = DB.Exec("DROP INDEX IF EXISTS idx_household_events_household_id").Error
+		}
+		if err != nil {
+			log.Fatalf("Failed to clear household_events: %v", err)
+		}
+	}
+
+	err = DB.AutoMigrate(&models.HouseholdEvent{})
+	if err != nil {
+		log.Fatalf("Failed to migrate household_events: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.HouseholdEventSequence{})
+	if err != nil {
+		log.Fatalf("Failed to migrate household_event_sequences: %v", err)
+	}
+
 	// Create indexes
 	err = DB.Exec("CREATE INDEX IF NOT EXISTS idx_grocery_items_user_expiry ON grocery_items(user_id, expiry_date)").Error
 	if err != nil {
 		log.Printf("Failed to create index: %v", err)
 	}
 
+	err = DB.Exec("CREATE INDEX IF NOT EXISTS idx_grocery_items_household_expiry ON grocery_items(household_id, expiry_date)").Error
+	if err != nil {
+		log.Printf("Failed to create index: %v", err)
+	}
+
+	// Par levels used to be unique per user, now they are unique per household
+	err = DB.Exec("DROP INDEX IF EXISTS idx_par_levels_user_name").Error
+	if err != nil {
+		log.Printf("Failed to drop index: %v", err)
+	}
+
+	// Users used to have a single FCM token, it becomes their first device
+	if DB.Migrator().HasColumn(&models.User{}, "fcm_token") {
+		err = DB.Exec(`INSERT INTO devices (user_id, token, platform, last_seen_at, created_at, updated_at)
+			SELECT id, fcm_token, ?, updated_at, NOW(), NOW() FROM users WHERE fcm_token <> ''
+			ON CONFLICT (token) DO NOTHING`, models.PlatformUnknown).Error
+		if err == nil {
+			err = DB.Migrator().DropColumn(&models.User{}, "fcm_token")
+		}
+		if err != nil {
+			log.Printf("Failed to move FCM tokens to devices: %v", err)
+		}
+	}
+
+	// The time zone used to be a notification preference, it moved to the profile
+	if DB.Migrator().HasColumn(&models.NotificationPreferences{}, "time_zone") {
+		err = DB.Exec(`UPDATE users SET time_zone = p.time_zone
+			FROM notification_preferences p WHERE p.user_id = users.id AND p.time_zone <> ''`).Error
+		if err == nil {
+			err = DB.Migrator().DropColumn(&models.NotificationPreferences{}, "time_zone")
+		}
+		if err != nil {
+			log.Printf("Failed to move time zones to users: %v", err)
+		}
+	}
+
+	// The bundled nutrition table used to be labelled as USDA SR Legacy
+	err = DB.Exec("UPDATE nutrition_foods SET source = 'bundled' WHERE source = 'usda-sr-legacy' AND COALESCE(source_id, '') = ''").Error
+	if err != nil {
+		log.Printf("Failed to relabel bundled nutrition foods: %v", err)
+	}
+
+	// Household data used to be deleted with the member who added it
+	for _, table := range []string{"receipts", "grocery_items", "recipes", "shopping_lists", "par_levels", "meal_plan_entries", "pantry_reservations", "purchase_records"} {
+		constraint := table + "_user_id_fkey"
+		var cascades bool
+		err = DB.Raw("SELECT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = ? AND confdeltype = 'c')", constraint).Scan(&cascades).Error
+		if err == nil && cascades {
+			err = DB.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", table, constraint)).Error; err != nil {
+					return err
+				}
+				return tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL", table, constraint)).Error
+			})
+		}
+		if err != nil {
+			log.Printf("Failed to keep %s when their user is deleted: %v", table, err)
+		}
+	}
+
 	log.Println("Database migration completed successfully")
 }
 
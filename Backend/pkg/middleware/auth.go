@@ -15,6 +15,19 @@ import (
 
 var jwtSecret = []byte("your_jwt_secret_key")
 
+// StreamTokenTTL is how long a stream token can open event streams. Browsers'
+// EventSource can't send an Authorization header, so it passes one in ?token.
+const StreamTokenTTL = 5 * time.Minute
+
+// StreamTokenResumeGrace is how long after expiring a stream token still lets an
+// EventSource reconnect with Last-Event-ID. EventSource retries with the URL it
+// was opened with, so a stream dropped after its token expired could otherwise
+// never resume. It matches how long household events are kept.
+const StreamTokenResumeGrace = 24 * time.Hour
+
+// streamScope marks stream tokens, which only work on event streams
+const streamScope = "stream"
+
 // RequestLogger logs incoming requests
 func RequestLogger() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -33,6 +46,15 @@ func RequestLogger() gin.HandlerFunc {
 		statusCode := c.Writer.Status()
 		errorMessage := c.Errors.ByType(gin.ErrorTypePrivate).String()
 
+		// Secret tokens in the path, e.g. calendar feeds, or in the query, e.g.
+		// stream tokens, stay out of the logs
+		if c.Param("token") != "" {
+			path = c.FullPath()
+		}
+		if query := c.Request.URL.Query(); query.Has("token") {
+			query.Set("token", "REDACTED")
+			raw = query.Encode()
+		}
 		if raw != "" {
 			path = path + "?" + raw
 		}
@@ -60,7 +82,7 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, Last-Event-ID")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -81,26 +103,71 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return jwtSecret, nil
-		})
+		if authenticate(c, strings.TrimPrefix(authHeader, "Bearer "), "", 0) {
+			c.Next()
+		}
+	}
+}
 
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+// StreamAuthMiddleware validates the Authorization header like JWTAuthMiddleware,
+// or else a stream token in ?token. Expiry is checked when a stream is opened:
+// an EventSource reconnecting with Last-Event-ID may use a token that expired
+// up to StreamTokenResumeGrace ago.
+func StreamAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+			if authenticate(c, strings.TrimPrefix(authHeader, "Bearer "), "", 0) {
+				c.Next()
+			}
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		tokenString := c.Query("token")
+		if tokenString == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header or stream token required"})
 			return
 		}
+		var grace time.Duration
+		if c.GetHeader("Last-Event-ID") != "" {
+			grace = StreamTokenResumeGrace
+		}
+		if authenticate(c, tokenString, streamScope, grace) {
+			c.Next()
+		}
+	}
+}
 
-		userID := uint(claims["user_id"].(float64))
-		c.Set("userID", userID)
-		c.Next()
+// authenticate sets the user of a valid token with the given scope, "" for
+// regular tokens, or aborts with 401. A token that expired less than grace ago
+// still counts.
+func authenticate(c *gin.Context, tokenString string, scope string, grace time.Duration) bool {
+	parser := jwt.NewParser()
+	if grace > 0 {
+		parser = jwt.NewParser(jwt.WithoutClaimsValidation()) // Expiry is checked below
 	}
+	token, err := parser.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	})
+
+	if err != nil || !token.Valid {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return false
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		return false
+	}
+	tokenScope, _ := claims["scope"].(string)
+	userID, ok := claims["user_id"].(float64)
+	if !ok || tokenScope != scope || (grace > 0 && !claims.VerifyExpiresAt(time.Now().Add(-grace).Unix(), true)) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return false
+	}
+
+	c.Set("userID", uint(userID))
+	return true
 }
 
 // GenerateToken creates a new JWT token
@@ -113,6 +180,19 @@ func GenerateToken(userID uint) (string, error) {
 	return token.SignedString(jwtSecret)
 }
 
+// GenerateStreamToken creates a short-lived JWT token that only opens event streams
+func GenerateStreamToken(userID uint) (string, time.Time, error) {
+	expiresAt := time.Now().Add(StreamTokenTTL)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": userID,
+		"scope":   streamScope,
+		"exp":     expiresAt.Unix(),
+	})
+
+	signed, err := token.SignedString(jwtSecret)
+	return signed, expiresAt, err
+}
+
 func RequireAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Retrieve userID from the context (set by JWTAuthMiddleware)
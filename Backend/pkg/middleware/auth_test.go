@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func signTestToken(t *testing.T, scope string, expiresAt time.Time) string {
+	t.Helper()
+	claims := jwt.MapClaims{"user_id": 7, "exp": expiresAt.Unix()}
+	if scope != "" {
+		claims["scope"] = scope
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecret)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func TestStreamAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/stream", StreamAuthMiddleware(), func(c *gin.Context) {
+		c.String(http.StatusOK, strconv.Itoa(int(c.GetUint("userID"))))
+	})
+
+	now := time.Now()
+	regular := signTestToken(t, "", now.Add(time.Hour))
+	stream := signTestToken(t, streamScope, now.Add(StreamTokenTTL))
+	expired := signTestToken(t, streamScope, now.Add(-time.Minute))
+	longExpired := signTestToken(t, streamScope, now.Add(-StreamTokenResumeGrace-time.Minute))
+	expiredRegular := signTestToken(t, "", now.Add(-time.Minute))
+
+	tests := []struct {
+		name        string
+		header      string // Authorization
+		token       string // ?token
+		lastEventID string
+		want        int
+	}{
+		{name: "regular token in the header", header: "Bearer " + regular, want: http.StatusOK},
+		{name: "stream token in the header", header: "Bearer " + stream, want: http.StatusUnauthorized},
+		{name: "expired token in the header", header: "Bearer " + expiredRegular, lastEventID: "1-5", want: http.StatusUnauthorized},
+		{name: "stream token", token: stream, want: http.StatusOK},
+		{name: "regular token in the query", token: regular, want: http.StatusUnauthorized},
+		{name: "expired stream token", token: expired, want: http.StatusUnauthorized},
+		{name: "reconnect with a recently expired stream token", token: expired, lastEventID: "1-5", want: http.StatusOK},
+		{name: "reconnect with a long expired stream token", token: longExpired, lastEventID: "1-5", want: http.StatusUnauthorized},
+		{name: "reconnect with an expired regular token", token: expiredRegular, lastEventID: "1-5", want: http.StatusUnauthorized},
+		{name: "tampered stream token", token: expired + "x", lastEventID: "1-5", want: http.StatusUnauthorized},
+		{name: "no token", want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/stream?token="+tt.token, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if tt.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tt.lastEventID)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusOK && rec.Body.String() != "7" {
+				t.Errorf("user = %s, want 7", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestGenerateStreamToken(t *testing.T) {
+	token, expiresAt, err := GenerateStreamToken(7)
+	if err != nil {
+		t.Fatalf("GenerateStreamToken failed: %v", err)
+	}
+	if ttl := time.Until(expiresAt); ttl <= 0 || ttl > StreamTokenTTL {
+		t.Errorf("expires in %v, want within %v", ttl, StreamTokenTTL)
+	}
+
+	// Stream tokens don't work as regular tokens
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", JWTAuthMiddleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
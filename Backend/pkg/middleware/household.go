@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"zero-waste-kitchen/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HouseholdScope sets the household the authenticated user belongs to and their
+// role in it. It must run after JWTAuthMiddleware.
+func HouseholdScope(membership func(userID uint) (uint, models.HouseholdRole, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		householdID, role, err := membership(c.GetUint("userID"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set("householdID", householdID)
+		c.Set("householdRole", role)
+		c.Next()
+	}
+}
+
+// RequireHouseholdWriter lets viewers read their household's data but not change it
+func RequireHouseholdWriter() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet {
+			c.Next()
+			return
+		}
+
+		role, _ := c.Get("householdRole")
+		if householdRole, ok := role.(models.HouseholdRole); !ok || !householdRole.CanWrite() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access denied: viewers can't change the household's data"})
+			return
+		}
+		c.Next()
+	}
+}
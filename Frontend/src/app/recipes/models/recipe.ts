@@ -8,6 +8,24 @@ export interface Recipe {
     servings: number;
     difficulty: string;
     cuisine: string;
+    waste_rescued_score?: number;
+    expiring_ingredients?: string;
+    favorite?: boolean;
+    rating?: number;
+    notes?: string;
     user_id?: number;
     created_at?: string;
+    original_servings?: number;
+    scale?: number;
+    scaled_ingredients?: ScaledIngredient[];
+  }
+
+export interface ScaledIngredient {
+    name: string;
+    quantity: number;
+    unit: string;
+    text: string;
+    optional: boolean;
+    availability: 'available' | 'partial' | 'missing';
+    pantry_quantity?: number;
   }
\ No newline at end of file